@@ -0,0 +1,163 @@
+// Package arena schedules round-robin matches between bots and keeps their
+// cross table and ratings.
+//
+// Every pair of players meets on its own table for a fixed number of games,
+// with the first move alternating between games as it does for any table.
+package arena
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Arena states
+const (
+	StatusRunning  = "running"
+	StatusFinished = "finished"
+	StatusFailed   = "failed"
+)
+
+// Limits of an arena
+const (
+	MinPlayers         = 2
+	MaxPlayers         = 16
+	DefaultGames       = 2
+	MaxGamesPerPairing = 100
+)
+
+// Record is a player's results against one opponent
+type Record struct {
+	Wins   int `json:"wins"`
+	Draws  int `json:"draws"`
+	Losses int `json:"losses"`
+}
+
+// Games returns the number of games in the record
+func (r Record) Games() int {
+	return r.Wins + r.Draws + r.Losses
+}
+
+// Score counts wins as one point and draws as half
+func (r Record) Score() float64 {
+	return float64(r.Wins) + float64(r.Draws)/2
+}
+
+// Pairing is one scheduled match of the round robin
+type Pairing struct {
+	Players [2]string `json:"players"`
+	TableID string    `json:"table_id,omitempty"`
+	Played  int       `json:"played"`
+}
+
+// Arena is a round robin between bots
+type Arena struct {
+	ID      string   `json:"id"`
+	Status  string   `json:"status"`
+	Error   string   `json:"error,omitempty"`
+	Players []string `json:"players"`
+	// Games is the number of games each pair of players plays
+	Games    int       `json:"games"`
+	Pairings []Pairing `json:"pairings"`
+	// Cross holds each player's record against each opponent
+	Cross     map[string]map[string]Record `json:"cross"`
+	Standings []Standing                   `json:"standings"`
+	Started   time.Time                    `json:"started"`
+	Finished  *time.Time                   `json:"finished,omitempty"`
+}
+
+// New schedules a round robin where every pair of players meets for games
+// games
+func New(id string, players []string, games int) (*Arena, error) {
+	if len(players) < MinPlayers || len(players) > MaxPlayers {
+		return nil, fmt.Errorf("An arena needs %d to %d players", MinPlayers, MaxPlayers)
+	}
+	if games < 1 || games > MaxGamesPerPairing {
+		return nil, fmt.Errorf("Games per pairing must be between 1 and %d", MaxGamesPerPairing)
+	}
+	a := &Arena{
+		ID:       id,
+		Status:   StatusRunning,
+		Players:  players,
+		Games:    games,
+		Pairings: []Pairing{},
+		Cross:    map[string]map[string]Record{},
+		Started:  time.Now().UTC(),
+	}
+	for i, player := range players {
+		if a.Cross[player] != nil {
+			return nil, fmt.Errorf("Player %s is entered twice", player)
+		}
+		a.Cross[player] = map[string]Record{}
+		for _, opponent := range players[:i] {
+			a.Pairings = append(a.Pairings, Pairing{Players: [2]string{opponent, player}})
+		}
+	}
+	a.Standings = a.standings()
+	return a, nil
+}
+
+// Record adds the result of a game of a pairing. Winner is the index of the
+// winning player in the pairing, or -1 for a draw.
+func (a *Arena) Record(pairing, winner int) {
+	p := &a.Pairings[pairing]
+	p.Played++
+	for side, player := range p.Players {
+		opponent := p.Players[1-side]
+		record := a.Cross[player][opponent]
+		switch winner {
+		case -1:
+			record.Draws++
+		case side:
+			record.Wins++
+		default:
+			record.Losses++
+		}
+		a.Cross[player][opponent] = record
+	}
+	a.Standings = a.standings()
+}
+
+// Finish marks the arena as over, with the error that stopped it if any
+func (a *Arena) Finish(err error) {
+	now := time.Now().UTC()
+	a.Finished = &now
+	a.Status = StatusFinished
+	if err != nil {
+		a.Status = StatusFailed
+		a.Error = err.Error()
+	}
+}
+
+func arenaKey(id string) string {
+	return "arena:" + id
+}
+
+// Get retrieves an arena
+func Get(ctx context.Context, id string, client *redis.Client) (*Arena, error) {
+	val, err := client.Get(ctx, arenaKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var a Arena
+	if err := json.Unmarshal([]byte(val), &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Save stores an arena
+func Save(ctx context.Context, a *Arena, client *redis.Client) error {
+	if a.ID == "" {
+		return errors.New("arena has no ID")
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return client.Set(ctx, arenaKey(a.ID), data, 0).Err()
+}
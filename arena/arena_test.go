@@ -0,0 +1,82 @@
+package arena
+
+import "testing"
+
+// TestNew tests scheduling and the limits of an arena
+func TestNew(t *testing.T) {
+	a, err := New("id", []string{"a", "b", "c"}, 4)
+	if err != nil {
+		t.Fatalf("Error creating arena: %v", err)
+	}
+	if len(a.Pairings) != 3 {
+		t.Errorf("Expected 3 pairings for 3 players, got %d", len(a.Pairings))
+	}
+	if len(a.Standings) != 3 || a.Standings[0].Elo != 0 {
+		t.Errorf("Expected 3 unrated standings, got %+v", a.Standings)
+	}
+	for _, players := range [][]string{{"a"}, {"a", "a"}} {
+		if _, err := New("id", players, 2); err == nil {
+			t.Errorf("Expected an error for players %v", players)
+		}
+	}
+	if _, err := New("id", []string{"a", "b"}, 0); err == nil {
+		t.Errorf("Expected an error for 0 games")
+	}
+}
+
+// TestRecord tests the cross table and standings after some games
+func TestRecord(t *testing.T) {
+	a, err := New("id", []string{"a", "b"}, 4)
+	if err != nil {
+		t.Fatalf("Error creating arena: %v", err)
+	}
+	// Pairing 0 is a against b
+	a.Record(0, 0)
+	a.Record(0, 0)
+	a.Record(0, 0)
+	a.Record(0, -1)
+	if got := a.Cross["a"]["b"]; got != (Record{Wins: 3, Draws: 1}) {
+		t.Errorf("Expected a to have 3 wins and a draw against b, got %+v", got)
+	}
+	if got := a.Cross["b"]["a"]; got != (Record{Losses: 3, Draws: 1}) {
+		t.Errorf("Expected b to have 3 losses and a draw against a, got %+v", got)
+	}
+	if a.Pairings[0].Played != 4 {
+		t.Errorf("Expected 4 games played, got %d", a.Pairings[0].Played)
+	}
+
+	first, second := a.Standings[0], a.Standings[1]
+	if first.Player != "a" || first.Score != 3.5 || first.Games != 4 {
+		t.Errorf("Expected a to lead with 3.5 from 4 games, got %+v", first)
+	}
+	if first.Elo <= 0 || first.Elo != -second.Elo {
+		t.Errorf("Expected opposite ratings with a above 0, got %v and %v", first.Elo, second.Elo)
+	}
+	for _, s := range a.Standings {
+		if s.EloLow >= s.Elo || s.EloHigh <= s.Elo {
+			t.Errorf("Expected the interval of %s to contain its rating, got %+v", s.Player, s)
+		}
+	}
+}
+
+// TestRatingsOrder tests that ratings follow the results of a round robin
+func TestRatingsOrder(t *testing.T) {
+	a, err := New("id", []string{"weak", "middle", "strong"}, 2)
+	if err != nil {
+		t.Fatalf("Error creating arena: %v", err)
+	}
+	// Pairings are weak-middle, weak-strong and middle-strong
+	for i := range a.Pairings {
+		a.Record(i, 1)
+		a.Record(i, 1)
+	}
+	order := []string{"strong", "middle", "weak"}
+	for i, s := range a.Standings {
+		if s.Player != order[i] {
+			t.Errorf("Expected %s in place %d, got %s", order[i], i+1, s.Player)
+		}
+	}
+	if middle := a.Standings[1]; middle.Elo != 0 {
+		t.Errorf("Expected the middle player to be rated 0, got %v", middle.Elo)
+	}
+}
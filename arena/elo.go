@@ -0,0 +1,139 @@
+package arena
+
+import (
+	"math"
+	"sort"
+)
+
+// z95 is the normal quantile of a two-sided 95% confidence interval
+const z95 = 1.96
+
+// Standing is a player's total results and rating estimate. Ratings are Elo
+// points relative to the field, whose average is 0.
+type Standing struct {
+	Player string  `json:"player"`
+	Games  int     `json:"games"`
+	Wins   int     `json:"wins"`
+	Draws  int     `json:"draws"`
+	Losses int     `json:"losses"`
+	Score  float64 `json:"score"`
+	Elo    float64 `json:"elo"`
+	// EloLow and EloHigh bound the 95% confidence interval of the rating
+	EloLow  float64 `json:"elo_low"`
+	EloHigh float64 `json:"elo_high"`
+}
+
+// standings totals the cross table and sorts the players by score
+func (a *Arena) standings() []Standing {
+	ratings := a.ratings()
+	standings := make([]Standing, len(a.Players))
+	for i, player := range a.Players {
+		s := Standing{Player: player, Elo: ratings[i]}
+		for _, record := range a.Cross[player] {
+			s.Wins += record.Wins
+			s.Draws += record.Draws
+			s.Losses += record.Losses
+		}
+		total := Record{Wins: s.Wins, Draws: s.Draws, Losses: s.Losses}
+		s.Games = total.Games()
+		s.Score = total.Score()
+		s.EloLow, s.EloHigh = s.Elo, s.Elo
+		if s.Games > 0 {
+			// The virtual draw of the ratings also keeps the interval of a
+			// perfect score from collapsing
+			low, high := interval(Record{Wins: s.Wins, Draws: s.Draws + 1, Losses: s.Losses})
+			s.EloLow += low
+			s.EloHigh += high
+		}
+		s.Elo, s.EloLow, s.EloHigh = round(s.Elo), round(s.EloLow), round(s.EloHigh)
+		standings[i] = s
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Score != standings[j].Score {
+			return standings[i].Score > standings[j].Score
+		}
+		return standings[i].Elo > standings[j].Elo
+	})
+	return standings
+}
+
+// ratings finds the maximum likelihood Bradley-Terry strengths of the players
+// with the minorization-maximization algorithm and converts them to Elo.
+// Every pair is credited with one virtual draw, which keeps the ratings of
+// players who won or lost every game finite.
+func (a *Arena) ratings() []float64 {
+	n := len(a.Players)
+	games := make([][]float64, n)
+	scores := make([]float64, n)
+	for i, player := range a.Players {
+		games[i] = make([]float64, n)
+		for j, opponent := range a.Players {
+			if i == j {
+				continue
+			}
+			record := a.Cross[player][opponent]
+			games[i][j] = float64(record.Games()) + 1
+			scores[i] += record.Score() + 0.5
+		}
+	}
+
+	strength := make([]float64, n)
+	for i := range strength {
+		strength[i] = 1
+	}
+	for iteration := 0; iteration < 1000; iteration++ {
+		change := 0.0
+		next := make([]float64, n)
+		for i := range strength {
+			denominator := 0.0
+			for j := range strength {
+				if i != j {
+					denominator += games[i][j] / (strength[i] + strength[j])
+				}
+			}
+			next[i] = scores[i] / denominator
+		}
+		// Keep the geometric mean at 1 so the average rating is 0
+		logMean := 0.0
+		for _, s := range next {
+			logMean += math.Log(s)
+		}
+		scale := math.Exp(logMean / float64(n))
+		for i := range next {
+			next[i] /= scale
+			change = math.Max(change, math.Abs(next[i]-strength[i]))
+		}
+		strength = next
+		if change < 1e-9 {
+			break
+		}
+	}
+
+	ratings := make([]float64, n)
+	for i, s := range strength {
+		ratings[i] = 400 * math.Log10(s)
+	}
+	return ratings
+}
+
+// interval returns the offsets of the 95% confidence bounds of a rating from
+// the standard error of the player's score
+func interval(r Record) (low, high float64) {
+	n := float64(r.Games())
+	p := r.Score() / n
+	variance := (float64(r.Wins)*(1-p)*(1-p) + float64(r.Draws)*(0.5-p)*(0.5-p) + float64(r.Losses)*p*p) / n
+	margin := z95 * math.Sqrt(variance/n)
+	return eloDifference(p-margin, n) - eloDifference(p, n), eloDifference(p+margin, n) - eloDifference(p, n)
+}
+
+// eloDifference converts an expected score to a rating difference, treating
+// scores of 0 and 1 as half a game away from them
+func eloDifference(p, games float64) float64 {
+	edge := 1 / (2 * games)
+	p = math.Max(edge, math.Min(1-edge, p))
+	return -400 * math.Log10(1/p-1)
+}
+
+func round(x float64) float64 {
+	return math.Round(x*10) / 10
+}
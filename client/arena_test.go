@@ -0,0 +1,54 @@
+package client
+
+import (
+	"blackjackapi/arena"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestArena tests a round robin between two levels of the built-in AI
+func TestArena(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	var apiErr *Error
+	if _, err := c.CreateArena(ctx, []string{"ai:1", "nobody"}, 2); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a bad request for an unknown bot, got %v", err)
+	}
+
+	a, err := c.CreateArena(ctx, []string{"ai:1", "ai:2"}, 2)
+	if err != nil {
+		t.Fatalf("Error creating arena: %v", err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for a.Status == arena.StatusRunning {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the arena, played %+v", a.Pairings)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if a, err = c.Arena(ctx, a.ID); err != nil {
+			t.Fatalf("Error getting arena: %v", err)
+		}
+	}
+	if a.Status != arena.StatusFinished {
+		t.Fatalf("Expected the arena to finish, got %s: %s", a.Status, a.Error)
+	}
+	record := a.Cross["ai:1"]["ai:2"]
+	if record.Games() != 2 || a.Cross["ai:2"]["ai:1"].Wins != record.Losses {
+		t.Errorf("Expected 2 games with matching records, got %+v", a.Cross)
+	}
+
+	table, err := c.Table(ctx, a.Pairings[0].TableID)
+	if err != nil {
+		t.Fatalf("Error getting table: %v", err)
+	}
+	if table.Starts != 2 || table.Status {
+		t.Errorf("Expected 2 finished games on the pairing's table, got %d starts", table.Starts)
+	}
+	if err := c.Drop(ctx, table.ID, "ai:1", 0); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected forbidden dropping for the AI, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"blackjackapi/models"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newBotTable seats alice and then a registered bot, which moves first in
+// the first game, and returns the bot's client and event stream
+func newBotTable(t *testing.T, c *Client, ctx context.Context, moveTime time.Duration) (string, *Client, <-chan models.Event) {
+	t.Helper()
+	token, err := c.RegisterBot(ctx, "robot", moveTime)
+	if err != nil {
+		t.Fatalf("Error registering bot: %v", err)
+	}
+	bot := New(c.BaseURL)
+	bot.Token = token
+	events, err := bot.BotEvents(ctx)
+	if err != nil {
+		t.Fatalf("Error subscribing to bot events: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventConnected {
+		t.Errorf("Expected connected event, got %s", event.Type)
+	}
+
+	tableID, err := c.CreateTable(ctx)
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	if err := c.Join(ctx, tableID, "alice"); err != nil {
+		t.Fatalf("Error joining alice: %v", err)
+	}
+	if err := bot.BotJoin(ctx, tableID); err != nil {
+		t.Fatalf("Error joining bot: %v", err)
+	}
+	return tableID, bot, events
+}
+
+// TestBot tests registering a bot and playing it through its event stream
+func TestBot(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tableID, bot, events := newBotTable(t, c, ctx, time.Minute)
+
+	var apiErr *Error
+	if _, err := c.RegisterBot(ctx, "robot", time.Second); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected a conflict registering a taken name, got %v", err)
+	}
+	impostor := New(c.BaseURL)
+	impostor.Token = "robot.0000"
+	if err := impostor.BotJoin(ctx, tableID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected unauthorized for a wrong token, got %v", err)
+	}
+
+	if err := c.Start(ctx, tableID); err != nil {
+		t.Fatalf("Error starting game: %v", err)
+	}
+	event := nextEvent(t, events)
+	if event.Type != models.EventYourTurn || event.TableID != tableID || event.Deadline == nil {
+		t.Fatalf("Expected your_turn with a deadline, got %+v", event)
+	}
+	if event.Position != "7/7/7/7/7/7 O" {
+		t.Errorf("Expected the empty board with O to move, got %q", event.Position)
+	}
+	if err := c.Drop(ctx, tableID, "robot", 3); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected forbidden dropping for a bot, got %v", err)
+	}
+	if err := bot.BotMove(ctx, tableID, 3); err != nil {
+		t.Fatalf("Error moving bot: %v", err)
+	}
+	if err := bot.BotMove(ctx, tableID, 3); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a bad request moving out of turn, got %v", err)
+	}
+	if err := c.Drop(ctx, tableID, "alice", 2); err != nil {
+		t.Fatalf("Error dropping piece: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventYourTurn || event.Position != "7/7/7/7/7/2XO3 O" {
+		t.Errorf("Expected your_turn after alice's move, got %+v", event)
+	}
+}
+
+// TestBotTimeout tests that a bot missing its deadline forfeits the game
+func TestBotTimeout(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tableID, _, events := newBotTable(t, c, ctx, models.MinBotMoveTime)
+
+	if err := c.Start(ctx, tableID); err != nil {
+		t.Fatalf("Error starting game: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventYourTurn {
+		t.Fatalf("Expected your_turn, got %s", event.Type)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventMoveTimeout {
+		t.Fatalf("Expected move_timeout, got %s", event.Type)
+	}
+	table, err := c.Table(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error getting table: %v", err)
+	}
+	if table.Status || table.Players[0].Wins != 1 {
+		t.Errorf("Expected the game to end with a win for alice, got %+v", table.Players[0])
+	}
+}
+
+// TestBotSocket tests receiving turns and sending moves over a WebSocket
+func TestBotSocket(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tableID, bot, _ := newBotTable(t, c, ctx, time.Minute)
+
+	url := "ws" + strings.TrimPrefix(c.BaseURL, "http") + "/bots/ws?token=" + bot.Token
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		t.Fatalf("Error connecting: %v", err)
+	}
+	defer conn.Close()
+	read := func() models.Event {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var event models.Event
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("Error reading event: %v", err)
+		}
+		return event
+	}
+	if event := read(); event.Type != models.EventConnected {
+		t.Fatalf("Expected connected event, got %s", event.Type)
+	}
+
+	if err := c.Start(ctx, tableID); err != nil {
+		t.Fatalf("Error starting game: %v", err)
+	}
+	if event := read(); event.Type != models.EventYourTurn {
+		t.Fatalf("Expected your_turn, got %s", event.Type)
+	}
+	conn.WriteJSON(map[string]any{"table_id": tableID, "column": 9})
+	if event := read(); event.Type != "move_rejected" {
+		t.Errorf("Expected move_rejected for column 9, got %+v", event)
+	}
+	conn.WriteJSON(map[string]any{"table_id": tableID, "column": 3})
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		table, err := c.Table(ctx, tableID)
+		if err != nil {
+			t.Fatalf("Error getting table: %v", err)
+		}
+		if len(table.Moves) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the bot's move")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,768 @@
+// Package client is a Go client for the Connect 4 API described by /openapi.json.
+package client
+
+import (
+	"blackjackapi/arena"
+	"blackjackapi/engine"
+	"blackjackapi/models"
+	"blackjackapi/tournament"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client talks to a Connect 4 API server
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Token authenticates the bot requests
+	Token string
+}
+
+// Error is returned when the server answers with a non-success status
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("connect4: %d %s", e.StatusCode, e.Message)
+}
+
+// New initializes and returns a Client for the server at baseURL
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// CreateTable creates a table and returns its ID
+func (c *Client) CreateTable(ctx context.Context) (string, error) {
+	body, err := c.do(ctx, "GET", "/create", nil)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// TableOptions are the optional settings of a new table
+type TableOptions struct {
+	// Name must be unique among tables
+	Name string
+	// Password must be given by players joining the table
+	Password string
+	// Private leaves the table out of the lobby's public listing
+	Private bool
+	// Seats is how many players the table takes, 2 to 4. Zero leaves the
+	// default: four for team play, two otherwise.
+	Seats int
+	// Teams pairs four players into two teams, the first and third
+	// players to join against the second and fourth
+	Teams bool
+	// FirstMove is the policy choosing who moves first in each game, one
+	// of models.FirstMoves. Empty leaves the default of alternating.
+	FirstMove string
+}
+
+// JoinOptions are the optional choices of a player joining a table
+type JoinOptions struct {
+	// Password of a password protected table
+	Password string
+	// Symbol shown for the player's pieces, a single character
+	Symbol string
+	// Color of the player's pieces, one of the colors the server knows
+	Color string
+	// Avatar is an http or https URL of the player's picture
+	Avatar string
+}
+
+// CreatedTable identifies a new table
+type CreatedTable struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Code      string `json:"code"`
+	Invite    string `json:"invite"`
+	HostToken string `json:"host_token"`
+	Private   bool   `json:"private"`
+	Protected bool   `json:"protected"`
+}
+
+// CreateTableWith creates a table with the given options and returns its
+// ID, join code, invite link and the host token for the host-only actions
+func (c *Client) CreateTableWith(ctx context.Context, options TableOptions) (*CreatedTable, error) {
+	query := url.Values{"format": {"json"}}
+	if options.Name != "" {
+		query.Set("name", options.Name)
+	}
+	if options.Password != "" {
+		query.Set("password", options.Password)
+	}
+	if options.Private {
+		query.Set("private", "true")
+	}
+	if options.Seats != 0 {
+		query.Set("seats", strconv.Itoa(options.Seats))
+	}
+	if options.Teams {
+		query.Set("teams", "true")
+	}
+	if options.FirstMove != "" {
+		query.Set("first_move", options.FirstMove)
+	}
+	body, err := c.do(ctx, "GET", "/create?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var table CreatedTable
+	if err := json.Unmarshal(body, &table); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+// Invite returns the table an invite link's join code leads to
+func (c *Client) Invite(ctx context.Context, code string) (*models.TableSummary, error) {
+	body, err := c.do(ctx, "GET", tablePath("join", code), nil)
+	if err != nil {
+		return nil, err
+	}
+	var table models.TableSummary
+	if err := json.Unmarshal(body, &table); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+// Lobby is one page of the tables listed by the server
+type Lobby struct {
+	Tables []models.TableSummary `json:"tables"`
+	Total  int                   `json:"total"`
+	Offset int                   `json:"offset"`
+	Limit  int                   `json:"limit"`
+}
+
+// Tables lists the public tables passing the filter, most recently active first.
+// A zero limit lets the server choose the page size.
+func (c *Client) Tables(ctx context.Context, filter models.LobbyFilter) (*Lobby, error) {
+	query := url.Values{}
+	if filter.State != "" {
+		query.Set("state", filter.State)
+	}
+	if filter.Variant != "" {
+		query.Set("variant", filter.Variant)
+	}
+	if filter.Offset > 0 {
+		query.Set("offset", strconv.Itoa(filter.Offset))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	body, err := c.do(ctx, "GET", "/tables?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var lobby Lobby
+	if err := json.Unmarshal(body, &lobby); err != nil {
+		return nil, err
+	}
+	return &lobby, nil
+}
+
+// SubscribeLobby streams the table_created, table_updated and table_closed
+// events of every public table. The first event is always models.EventConnected.
+func (c *Client) SubscribeLobby(ctx context.Context) (<-chan models.Event, error) {
+	return c.stream(ctx, "/tables/connect?format=json")
+}
+
+// Table returns the current state of a table
+func (c *Client) Table(ctx context.Context, tableID string) (*models.Session, error) {
+	body, err := c.do(ctx, "GET", "/"+url.PathEscape(tableID), nil)
+	if err != nil {
+		return nil, err
+	}
+	var session models.Session
+	if err := json.Unmarshal(body, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeleteTable deletes a table. Only its host can.
+func (c *Client) DeleteTable(ctx context.Context, tableID, hostToken string) error {
+	_, err := c.doAsHost(ctx, "GET", tablePath(tableID, "delete"), nil, hostToken)
+	return err
+}
+
+// JoinAsHost seats the table's host, who gets past the password and the lock
+func (c *Client) JoinAsHost(ctx context.Context, tableID, name, hostToken string) error {
+	_, err := c.doAsHost(ctx, "GET", tablePath(tableID, name, "join"), nil, hostToken)
+	return err
+}
+
+// SeatAI seats the built-in AI of the given level at a table, which only
+// the host may do. It joins as ai:<level> and moves on its own.
+func (c *Client) SeatAI(ctx context.Context, tableID, hostToken string, level int) error {
+	return c.JoinAsHost(ctx, tableID, fmt.Sprintf("ai:%d", level), hostToken)
+}
+
+// Kick removes a player or named viewer from a table for good. A player
+// kicked during a game forfeits it.
+func (c *Client) Kick(ctx context.Context, tableID, name, hostToken string) error {
+	_, err := c.doAsHost(ctx, "POST", tablePath(tableID, name, "kick"), nil, hostToken)
+	return err
+}
+
+// Lock stops anyone but the host from taking a seat at a table
+func (c *Client) Lock(ctx context.Context, tableID, hostToken string) error {
+	_, err := c.doAsHost(ctx, "POST", tablePath(tableID, "lock"), nil, hostToken)
+	return err
+}
+
+// Unlock lets players take the seats of a table again
+func (c *Client) Unlock(ctx context.Context, tableID, hostToken string) error {
+	_, err := c.doAsHost(ctx, "POST", tablePath(tableID, "unlock"), nil, hostToken)
+	return err
+}
+
+// TableSettings are the settings the host may change between games. Nil
+// fields are left unchanged; an empty name, password, first player or
+// handicap removes it.
+type TableSettings struct {
+	Name        *string          `json:"name,omitempty"`
+	Password    *string          `json:"password,omitempty"`
+	Private     *bool            `json:"private,omitempty"`
+	FirstMove   *string          `json:"first_move,omitempty"`
+	FirstPlayer *string          `json:"first_player,omitempty"`
+	Handicap    *models.Handicap `json:"handicap,omitempty"`
+}
+
+// UpdateSettings changes the settings of a table between games
+func (c *Client) UpdateSettings(ctx context.Context, tableID, hostToken string, settings TableSettings) (*models.Session, error) {
+	request, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.doAsHost(ctx, "POST", tablePath(tableID, "settings"), bytes.NewReader(request), hostToken)
+	if err != nil {
+		return nil, err
+	}
+	var session models.Session
+	if err := json.Unmarshal(body, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// TransferHost hands the host role to a seated player and returns the new
+// host token to pass on. The old token stops working.
+func (c *Client) TransferHost(ctx context.Context, tableID, name, hostToken string) (string, error) {
+	body, err := c.doAsHost(ctx, "POST", tablePath(tableID, name, "host"), nil, hostToken)
+	if err != nil {
+		return "", err
+	}
+	var transfer struct {
+		HostToken string `json:"host_token"`
+	}
+	if err := json.Unmarshal(body, &transfer); err != nil {
+		return "", err
+	}
+	return transfer.HostToken, nil
+}
+
+// Start starts a game at a table with two seated players
+func (c *Client) Start(ctx context.Context, tableID string) error {
+	_, err := c.do(ctx, "GET", tablePath(tableID, "start"), nil)
+	return err
+}
+
+// Join seats a player at a table
+func (c *Client) Join(ctx context.Context, tableID, name string) error {
+	_, err := c.do(ctx, "GET", tablePath(tableID, name, "join"), nil)
+	return err
+}
+
+// JoinWithPassword seats a player at a password protected table
+func (c *Client) JoinWithPassword(ctx context.Context, tableID, name, password string) error {
+	_, err := c.do(ctx, "GET", tablePath(tableID, name, "join")+"?"+url.Values{"password": {password}}.Encode(), nil)
+	return err
+}
+
+// JoinWith seats a player at a table with the given choices. A symbol or
+// color another player already shows is replaced by the server; read the
+// table to see what the player got.
+func (c *Client) JoinWith(ctx context.Context, tableID, name string, options JoinOptions) error {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"password": options.Password,
+		"symbol":   options.Symbol,
+		"color":    options.Color,
+		"avatar":   options.Avatar,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	path := tablePath(tableID, name, "join")
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	_, err := c.do(ctx, "GET", path, nil)
+	return err
+}
+
+// Leave removes a player from a table
+func (c *Client) Leave(ctx context.Context, tableID, name string) error {
+	_, err := c.do(ctx, "GET", tablePath(tableID, name, "leave"), nil)
+	return err
+}
+
+// Ready signals that the player wants the next game. The game starts once
+// both players are ready.
+func (c *Client) Ready(ctx context.Context, tableID, name string) error {
+	_, err := c.do(ctx, "GET", tablePath(tableID, name, "ready"), nil)
+	return err
+}
+
+// Resign ends the game in progress with a win for the player's opponent
+func (c *Client) Resign(ctx context.Context, tableID, name string) error {
+	_, err := c.do(ctx, "GET", tablePath(tableID, name, "resign"), nil)
+	return err
+}
+
+// OfferDraw offers the player's opponent a draw, which stands until the
+// opponent answers it or makes a move
+func (c *Client) OfferDraw(ctx context.Context, tableID, name string) error {
+	_, err := c.do(ctx, "GET", tablePath(tableID, name, "draw"), nil)
+	return err
+}
+
+// AcceptDraw accepts the opponent's draw offer, ending the game without a winner
+func (c *Client) AcceptDraw(ctx context.Context, tableID, name string) error {
+	_, err := c.do(ctx, "GET", tablePath(tableID, name, "draw", "accept"), nil)
+	return err
+}
+
+// DeclineDraw turns down the opponent's draw offer
+func (c *Client) DeclineDraw(ctx context.Context, tableID, name string) error {
+	_, err := c.do(ctx, "GET", tablePath(tableID, name, "draw", "decline"), nil)
+	return err
+}
+
+// ClaimAbandonment wins the game for a player whose opponent has been
+// disconnected for longer than the server's grace period
+func (c *Client) ClaimAbandonment(ctx context.Context, tableID, name string) error {
+	_, err := c.do(ctx, "GET", tablePath(tableID, name, "abandon"), nil)
+	return err
+}
+
+// Drop drops the player's piece into the zero-based column
+func (c *Client) Drop(ctx context.Context, tableID, name string, column int) error {
+	_, err := c.do(ctx, "GET", tablePath(tableID, name, strconv.Itoa(column), "drop"), nil)
+	return err
+}
+
+// SetMatch starts a models.MatchFirstTo or models.MatchBestOf match at a
+// table, which only the host may do. Once a game is started, the next ones
+// start by themselves until the match is decided.
+func (c *Client) SetMatch(ctx context.Context, tableID, hostToken, format string, games int) (*models.Session, error) {
+	request, err := json.Marshal(map[string]any{"format": format, "games": games})
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.doAsHost(ctx, "POST", tablePath(tableID, "match"), bytes.NewReader(request), hostToken)
+	if err != nil {
+		return nil, err
+	}
+	var session models.Session
+	if err := json.Unmarshal(body, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// BoardSVG returns the current board of a table as an SVG image
+func (c *Client) BoardSVG(ctx context.Context, tableID string) ([]byte, error) {
+	return c.do(ctx, "GET", tablePath(tableID, "board.svg"), nil)
+}
+
+// BoardPNG returns the current board of a table as a PNG image
+func (c *Client) BoardPNG(ctx context.Context, tableID string) ([]byte, error) {
+	return c.do(ctx, "GET", tablePath(tableID, "board.png"), nil)
+}
+
+// HistoryGIF returns an animated replay of the last finished game at a table
+func (c *Client) HistoryGIF(ctx context.Context, tableID string) ([]byte, error) {
+	return c.do(ctx, "GET", tablePath(tableID, "history.gif"), nil)
+}
+
+// Export returns the current game of a table in game notation
+func (c *Client) Export(ctx context.Context, tableID string) (string, error) {
+	body, err := c.do(ctx, "GET", tablePath(tableID, "export"), nil)
+	return string(body), err
+}
+
+// Position returns the current position of a table in position notation
+func (c *Client) Position(ctx context.Context, tableID string) (string, error) {
+	body, err := c.do(ctx, "GET", tablePath(tableID, "export")+"?format=position", nil)
+	return strings.TrimSpace(string(body)), err
+}
+
+// Import creates a table from a game in notation and returns its ID
+func (c *Client) Import(ctx context.Context, game string) (string, error) {
+	body, err := c.do(ctx, "POST", "/tables/import", strings.NewReader(game))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// AnalyzeTable evaluates every column of the current position of a table
+func (c *Client) AnalyzeTable(ctx context.Context, tableID string, budget engine.Budget) (*engine.Analysis, error) {
+	return c.analyze(ctx, tablePath(tableID, "analysis")+"?"+budgetQuery(budget).Encode())
+}
+
+// AnalyzePosition evaluates every column of a position in position notation
+func (c *Client) AnalyzePosition(ctx context.Context, position string, budget engine.Budget) (*engine.Analysis, error) {
+	query := budgetQuery(budget)
+	query.Set("position", position)
+	return c.analyze(ctx, "/analysis?"+query.Encode())
+}
+
+func (c *Client) analyze(ctx context.Context, path string) (*engine.Analysis, error) {
+	body, err := c.do(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var analysis engine.Analysis
+	if err := json.Unmarshal(body, &analysis); err != nil {
+		return nil, err
+	}
+	return &analysis, nil
+}
+
+// GameReview is the annotated review of a finished game
+type GameReview struct {
+	Players []struct {
+		Name     string  `json:"name"`
+		Symbol   string  `json:"symbol"`
+		Accuracy float64 `json:"accuracy"`
+	} `json:"players"`
+	Moves []struct {
+		Player string `json:"player"`
+		engine.MoveReview
+	} `json:"moves"`
+}
+
+// Review labels every move of the last finished game of a table. The budget
+// applies to each position.
+func (c *Client) Review(ctx context.Context, tableID string, budget engine.Budget) (*GameReview, error) {
+	body, err := c.do(ctx, "GET", tablePath(tableID, "review")+"?"+budgetQuery(budget).Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var review GameReview
+	if err := json.Unmarshal(body, &review); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// TableOpening is the opening being played at a table
+type TableOpening struct {
+	TableID       string `json:"table_id"`
+	InBook        bool   `json:"in_book"`
+	Name          string `json:"name"`
+	Moves         []int  `json:"moves"`
+	Continuations []int  `json:"continuations"`
+}
+
+// Openings lists the named openings of the server's book
+func (c *Client) Openings(ctx context.Context) ([]engine.Opening, error) {
+	body, err := c.do(ctx, "GET", "/openings", nil)
+	if err != nil {
+		return nil, err
+	}
+	var openings []engine.Opening
+	if err := json.Unmarshal(body, &openings); err != nil {
+		return nil, err
+	}
+	return openings, nil
+}
+
+// Opening names the opening being played at a table
+func (c *Client) Opening(ctx context.Context, tableID string) (*TableOpening, error) {
+	body, err := c.do(ctx, "GET", "/openings?"+url.Values{"table": {tableID}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var opening TableOpening
+	if err := json.Unmarshal(body, &opening); err != nil {
+		return nil, err
+	}
+	return &opening, nil
+}
+
+func budgetQuery(budget engine.Budget) url.Values {
+	query := url.Values{}
+	if budget.MaxTime > 0 {
+		query.Set("time_ms", strconv.FormatInt(budget.MaxTime.Milliseconds(), 10))
+	}
+	if budget.MaxNodes > 0 {
+		query.Set("nodes", strconv.FormatInt(budget.MaxNodes, 10))
+	}
+	if budget.MaxDepth > 0 {
+		query.Set("depth", strconv.Itoa(budget.MaxDepth))
+	}
+	return query
+}
+
+// RegisterBot registers an external engine and returns its token, which
+// should be kept in Token for the other bot requests
+func (c *Client) RegisterBot(ctx context.Context, name string, moveTime time.Duration) (string, error) {
+	request, err := json.Marshal(map[string]any{"name": name, "move_time_ms": moveTime.Milliseconds()})
+	if err != nil {
+		return "", err
+	}
+	body, err := c.do(ctx, "POST", "/bots", bytes.NewReader(request))
+	if err != nil {
+		return "", err
+	}
+	var response struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", err
+	}
+	return response.Token, nil
+}
+
+// BotJoin seats the bot at a table
+func (c *Client) BotJoin(ctx context.Context, tableID string) error {
+	_, err := c.do(ctx, "POST", tablePath("bots", "tables", tableID, "join"), nil)
+	return err
+}
+
+// BotReady signals that the bot wants the next game at a table
+func (c *Client) BotReady(ctx context.Context, tableID string) error {
+	_, err := c.do(ctx, "POST", tablePath("bots", "tables", tableID, "ready"), nil)
+	return err
+}
+
+// BotMove drops a piece for the bot into a zero-based column
+func (c *Client) BotMove(ctx context.Context, tableID string, column int) error {
+	_, err := c.do(ctx, "POST", tablePath("bots", "tables", tableID, strconv.Itoa(column), "move"), nil)
+	return err
+}
+
+// BotEvents streams the your_turn and move_timeout events of the bot until
+// ctx is done
+func (c *Client) BotEvents(ctx context.Context) (<-chan models.Event, error) {
+	return c.stream(ctx, "/bots/events")
+}
+
+// CreateArena schedules a round robin between bots and levels of the
+// built-in AI, written ai:1 to ai:5, with games games per pairing
+func (c *Client) CreateArena(ctx context.Context, players []string, games int) (*arena.Arena, error) {
+	request, err := json.Marshal(map[string]any{"players": players, "games": games})
+	if err != nil {
+		return nil, err
+	}
+	return c.arena(ctx, "POST", "/arenas", bytes.NewReader(request))
+}
+
+// Arena returns the progress, cross table and standings of an arena
+func (c *Client) Arena(ctx context.Context, arenaID string) (*arena.Arena, error) {
+	return c.arena(ctx, "GET", tablePath("arenas", arenaID), nil)
+}
+
+func (c *Client) arena(ctx context.Context, method, path string, body io.Reader) (*arena.Arena, error) {
+	data, err := c.do(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	var a arena.Arena
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// CreateTournament opens registration for a swiss, single or double
+// elimination tournament. Swiss tournaments with no rounds given play
+// enough rounds to separate the players.
+func (c *Client) CreateTournament(ctx context.Context, name, format string, rounds int) (*tournament.Tournament, error) {
+	request, err := json.Marshal(map[string]any{"name": name, "format": format, "rounds": rounds})
+	if err != nil {
+		return nil, err
+	}
+	return c.tournament(ctx, "POST", "/tournaments", bytes.NewReader(request))
+}
+
+// Tournament returns a tournament and all of its matches
+func (c *Client) Tournament(ctx context.Context, tournamentID string) (*tournament.Tournament, error) {
+	return c.tournament(ctx, "GET", tablePath("tournaments", tournamentID), nil)
+}
+
+// RegisterTournament enters a player in a tournament
+func (c *Client) RegisterTournament(ctx context.Context, tournamentID, name string) error {
+	_, err := c.do(ctx, "POST", tablePath("tournaments", tournamentID, name, "register"), nil)
+	return err
+}
+
+// StartTournament closes registration and pairs the first round
+func (c *Client) StartTournament(ctx context.Context, tournamentID string) (*tournament.Tournament, error) {
+	return c.tournament(ctx, "POST", tablePath("tournaments", tournamentID, "start"), nil)
+}
+
+// Standings ranks the players of a tournament
+func (c *Client) Standings(ctx context.Context, tournamentID string) ([]tournament.Standing, error) {
+	body, err := c.do(ctx, "GET", tablePath("tournaments", tournamentID, "standings"), nil)
+	if err != nil {
+		return nil, err
+	}
+	var standings []tournament.Standing
+	if err := json.Unmarshal(body, &standings); err != nil {
+		return nil, err
+	}
+	return standings, nil
+}
+
+// SubscribeTournament streams the pairings and results of a tournament. The
+// first event is always connected.
+func (c *Client) SubscribeTournament(ctx context.Context, tournamentID string) (<-chan models.Event, error) {
+	return c.stream(ctx, tablePath("tournaments", tournamentID, "connect")+"?format=json")
+}
+
+func (c *Client) tournament(ctx context.Context, method, path string, body io.Reader) (*tournament.Tournament, error) {
+	data, err := c.do(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	var t tournament.Tournament
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Subscribe streams the events of a table. The first event is always
+// models.EventConnected. The channel is closed when ctx is done or the
+// server ends the stream.
+func (c *Client) Subscribe(ctx context.Context, tableID string) (<-chan models.Event, error) {
+	return c.stream(ctx, tablePath(tableID, "connect")+"?format=json")
+}
+
+// SubscribeAs streams the events of a table like Subscribe for a viewer
+// known by name, whom the host may kick. A seated player is present at the
+// table while the stream is open; once it closes they have the server's
+// grace period to subscribe again before they forfeit or the game pauses.
+func (c *Client) SubscribeAs(ctx context.Context, tableID, name string) (<-chan models.Event, error) {
+	return c.stream(ctx, tablePath(tableID, "connect")+"?"+url.Values{"format": {"json"}, "name": {name}}.Encode())
+}
+
+// stream reads the JSON SSE frames of an event stream
+func (c *Client) stream(ctx context.Context, path string) (<-chan models.Event, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	c.authorize(req)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+
+	events := make(chan models.Event)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		var data strings.Builder
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line != "" {
+				// Only the data field is needed, the event type is repeated inside it
+				if value, ok := strings.CutPrefix(line, "data:"); ok {
+					data.WriteString(strings.TrimPrefix(value, " "))
+				}
+				continue
+			}
+			if data.Len() == 0 {
+				continue
+			}
+			var event models.Event
+			err := json.Unmarshal([]byte(data.String()), &event)
+			data.Reset()
+			if err != nil {
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// do sends a request and returns the body of a successful response
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader) ([]byte, error) {
+	return c.doAsHost(ctx, method, path, body, "")
+}
+
+// doAsHost is do for requests made with a table's host token
+func (c *Client) doAsHost(ctx context.Context, method, path string, body io.Reader, hostToken string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	c.authorize(req)
+	if hostToken != "" {
+		req.Header.Set("X-Host-Token", hostToken)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, responseError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (c *Client) authorize(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+}
+
+func tablePath(segments ...string) string {
+	var sb strings.Builder
+	for _, segment := range segments {
+		sb.WriteString("/")
+		sb.WriteString(url.PathEscape(segment))
+	}
+	return sb.String()
+}
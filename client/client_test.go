@@ -0,0 +1,1063 @@
+package client
+
+import (
+	"blackjackapi/engine"
+	"blackjackapi/models"
+	"blackjackapi/server"
+	"blackjackapi/server/handlers"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestClient runs the real router in-process against an in-memory Redis and broker
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	store := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: store.Addr()})
+	handler := handlers.NewHandler(redisClient, "", "", "")
+	handler.Broker = models.NewMemoryBroker()
+	ts := httptest.NewServer(server.NewRouter(handler))
+	t.Cleanup(ts.Close)
+	return New(ts.URL)
+}
+
+// nextEvent waits for the next event on the stream
+func nextEvent(t *testing.T, events <-chan models.Event) models.Event {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("Expected an event, stream was closed")
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+	return models.Event{}
+}
+
+// TestGame plays a full game through the client
+func TestGame(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	table, err := c.CreateTableWith(ctx, TableOptions{})
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	tableID := table.ID
+	events, err := c.Subscribe(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error subscribing: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventConnected || event.TableID != tableID {
+		t.Errorf("Expected connected event for %s, got %s for %s", tableID, event.Type, event.TableID)
+	}
+
+	for _, name := range []string{"alice", "bob"} {
+		if err := c.Join(ctx, tableID, name); err != nil {
+			t.Fatalf("Error joining %s: %v", name, err)
+		}
+		if event := nextEvent(t, events); event.Type != models.EventPlayerJoined {
+			t.Errorf("Expected player_joined event, got %s", event.Type)
+		}
+	}
+	if err := c.Start(ctx, tableID); err != nil {
+		t.Fatalf("Error starting game: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventGameStarted || !event.Session.Status {
+		t.Errorf("Expected game_started event with a running game, got %s", event.Type)
+	}
+
+	// The first game is started by the second player to join
+	moves := []struct {
+		name   string
+		column int
+	}{{"bob", 0}, {"alice", 1}, {"bob", 0}, {"alice", 1}, {"bob", 0}, {"alice", 1}, {"bob", 0}}
+	for _, move := range moves {
+		if err := c.Drop(ctx, tableID, move.name, move.column); err != nil {
+			t.Fatalf("Error dropping for %s: %v", move.name, err)
+		}
+		if event := nextEvent(t, events); event.Type != models.EventPieceDropped {
+			t.Errorf("Expected piece_dropped event, got %s", event.Type)
+		}
+	}
+
+	state, err := c.Table(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error fetching table: %v", err)
+	}
+	if state.Status {
+		t.Errorf("Expected game to be over")
+	}
+	if state.Players[1].Wins != 1 {
+		t.Errorf("Expected bob to have 1 win, got %d", state.Players[1].Wins)
+	}
+
+	if gif, err := c.HistoryGIF(ctx, tableID); err != nil || !bytes.HasPrefix(gif, []byte("GIF89a")) {
+		t.Errorf("Expected a GIF replay of the game, got error %v", err)
+	}
+
+	if err := c.DeleteTable(ctx, tableID, table.HostToken); err != nil {
+		t.Fatalf("Error deleting table: %v", err)
+	}
+	var apiErr *Error
+	if _, err := c.Table(ctx, tableID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for deleted table, got %v", err)
+	}
+}
+
+// TestErrors tests that rejected requests surface as *Error
+func TestErrors(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	tableID, err := c.CreateTable(ctx)
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	var apiErr *Error
+	if err := c.Start(ctx, tableID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 starting an empty table, got %v", err)
+	}
+	c.Join(ctx, tableID, "alice")
+	if err := c.Join(ctx, tableID, "alice"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 joining with a taken name, got %v", err)
+	}
+	if err := c.Drop(ctx, tableID, "alice", 9); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 dropping out of range, got %v", err)
+	}
+}
+
+// TestImportExport tests that an imported game exports with the same moves,
+// gets a join code like any other table and counts as a played game
+func TestImportExport(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	tableID, err := c.Import(ctx, "[X \"alice\"]\n[O \"bob\"]\n\n4453 *\n")
+	if err != nil {
+		t.Fatalf("Error importing game: %v", err)
+	}
+	game, err := c.Export(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error exporting game: %v", err)
+	}
+	if !strings.Contains(game, "4453 *") {
+		t.Errorf("Expected the exported moves to match, got\n%s", game)
+	}
+	session, err := c.Table(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error getting table: %v", err)
+	}
+	if summary, err := c.Invite(ctx, session.Code); err != nil || summary.ID != tableID {
+		t.Errorf("Expected the imported table to be reachable by its join code %q, got %v", session.Code, err)
+	}
+	if err := c.Drop(ctx, tableID, "alice", 4); err != nil {
+		t.Errorf("Expected the imported game to continue with alice, got %v", err)
+	}
+
+	var apiErr *Error
+	if _, err := c.Import(ctx, "[X \"alice\"]\n[O \"bob\"]\n\n1111111 *\n"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 importing an illegal game, got %v", err)
+	}
+
+	finished, err := c.Import(ctx, "[X \"alice\"]\n[O \"bob\"]\n\n12121242 0-1\n")
+	if err != nil {
+		t.Fatalf("Error importing game: %v", err)
+	}
+	if err := c.Start(ctx, finished); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 restarting an imported game before both players are ready, got %v", err)
+	}
+}
+
+// TestAnalysis tests that a table and a position are analyzed alike
+func TestAnalysis(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	tableID, err := c.Import(ctx, "[X \"alice\"]\n[O \"bob\"]\n\n121212 *\n")
+	if err != nil {
+		t.Fatalf("Error importing game: %v", err)
+	}
+	budget := engine.Budget{MaxDepth: 4}
+	analysis, err := c.AnalyzeTable(ctx, tableID, budget)
+	if err != nil {
+		t.Fatalf("Error analyzing table: %v", err)
+	}
+	if analysis.BestMove != 0 {
+		t.Errorf("Expected the winning column 0 to be best, got %d", analysis.BestMove)
+	}
+	position, err := c.Position(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error exporting position: %v", err)
+	}
+	fromPosition, err := c.AnalyzePosition(ctx, position, budget)
+	if err != nil {
+		t.Fatalf("Error analyzing position: %v", err)
+	}
+	if fromPosition.BestMove != analysis.BestMove || len(fromPosition.Evaluations) != len(analysis.Evaluations) {
+		t.Errorf("Expected the position analysis to match the table analysis")
+	}
+}
+
+// TestReview tests reviewing a finished game and refusing one in progress
+func TestReview(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	budget := engine.Budget{MaxDepth: 4}
+
+	inProgress, err := c.Import(ctx, "[X \"alice\"]\n[O \"bob\"]\n\n1212 *\n")
+	if err != nil {
+		t.Fatalf("Error importing game: %v", err)
+	}
+	var apiErr *Error
+	if _, err := c.Review(ctx, inProgress, budget); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected a conflict reviewing a game in progress, got %v", err)
+	}
+
+	finished, err := c.Import(ctx, "[X \"alice\"]\n[O \"bob\"]\n\n12121242 0-1\n")
+	if err != nil {
+		t.Fatalf("Error importing game: %v", err)
+	}
+	review, err := c.Review(ctx, finished, budget)
+	if err != nil {
+		t.Fatalf("Error reviewing game: %v", err)
+	}
+	if len(review.Moves) != 8 || len(review.Players) != 2 {
+		t.Fatalf("Expected 8 moves and 2 players, got %+v", review)
+	}
+	if move := review.Moves[6]; move.Player != "alice" || move.Label != engine.LabelMissedWin {
+		t.Errorf("Expected alice's 7th move to be a missed win, got %+v", move)
+	}
+	if review.Players[0].Accuracy >= review.Players[1].Accuracy {
+		t.Errorf("Expected alice to be less accurate than bob, got %+v", review.Players)
+	}
+}
+
+// TestOpenings tests listing the book and naming the opening of a table
+func TestOpenings(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	openings, err := c.Openings(ctx)
+	if err != nil || len(openings) == 0 {
+		t.Fatalf("Expected named openings, got %v, %v", openings, err)
+	}
+	tableID, err := c.Import(ctx, "[X \"alice\"]\n[O \"bob\"]\n\n44 *\n")
+	if err != nil {
+		t.Fatalf("Error importing game: %v", err)
+	}
+	opening, err := c.Opening(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error naming opening: %v", err)
+	}
+	if !opening.InBook || opening.Name != "Center, Stacked" || len(opening.Moves) != 2 {
+		t.Errorf("Expected the table to be in the Center, Stacked opening, got %+v", opening)
+	}
+
+	var apiErr *Error
+	if _, err := c.Opening(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected not found for a missing table, got %v", err)
+	}
+}
+
+// TestMatch tests that a match starts its next game by itself until decided
+func TestMatch(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	table, err := c.CreateTableWith(ctx, TableOptions{})
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	tableID := table.ID
+	c.Join(ctx, tableID, "alice")
+	c.Join(ctx, tableID, "bob")
+	var apiErr *Error
+	if _, err := c.SetMatch(ctx, tableID, table.HostToken, "best_of", 0); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a match without games, got %v", err)
+	}
+	if _, err := c.SetMatch(ctx, tableID, "", models.MatchFirstTo, 2); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 setting a match without the host token, got %v", err)
+	}
+	if _, err := c.SetMatch(ctx, tableID, table.HostToken, models.MatchFirstTo, 2); err != nil {
+		t.Fatalf("Error setting match: %v", err)
+	}
+	events, err := c.Subscribe(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error subscribing: %v", err)
+	}
+	nextEvent(t, events)
+	if err := c.Start(ctx, tableID); err != nil {
+		t.Fatalf("Error starting game: %v", err)
+	}
+
+	// bob opens the first game and alice the second, and each wins the game they open
+	for game, names := range [][2]string{{"bob", "alice"}, {"alice", "bob"}} {
+		if event := nextEvent(t, events); event.Type != models.EventGameStarted {
+			t.Fatalf("Expected game %d to start, got %s", game+1, event.Type)
+		}
+		for i := 0; i < 7; i++ {
+			name := names[i%2]
+			if err := c.Drop(ctx, tableID, name, i%2); err != nil {
+				t.Fatalf("Error dropping for %s: %v", name, err)
+			}
+			nextEvent(t, events)
+		}
+	}
+	if event := nextEvent(t, events); event.Type != models.EventGameStarted {
+		t.Fatalf("Expected the deciding game to start, got %s", event.Type)
+	}
+	for i, name := range []string{"bob", "alice", "bob", "alice", "bob", "alice", "bob"} {
+		if err := c.Drop(ctx, tableID, name, i%2); err != nil {
+			t.Fatalf("Error dropping for %s: %v", name, err)
+		}
+		nextEvent(t, events)
+	}
+	event := nextEvent(t, events)
+	if event.Type != models.EventMatchDecided {
+		t.Fatalf("Expected the match to be decided, got %s", event.Type)
+	}
+	if match := event.Session.Match; match.Winner != "bob" || match.Score["bob"] != 2 || match.Played != 3 {
+		t.Errorf("Expected bob to win the match 2-1, got %+v", match)
+	}
+	if event.Session.Status {
+		t.Errorf("Expected no game to start after the match was decided")
+	}
+}
+
+// TestKickDecidesMatch tests that kicking a player mid-game counts their
+// forfeit towards the match before they leave
+func TestKickDecidesMatch(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	table, err := c.CreateTableWith(ctx, TableOptions{})
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	c.Join(ctx, table.ID, "alice")
+	c.Join(ctx, table.ID, "bob")
+	if _, err := c.SetMatch(ctx, table.ID, table.HostToken, models.MatchFirstTo, 1); err != nil {
+		t.Fatalf("Error setting match: %v", err)
+	}
+	c.Start(ctx, table.ID)
+	events, err := c.Subscribe(ctx, table.ID)
+	if err != nil {
+		t.Fatalf("Error subscribing: %v", err)
+	}
+	nextEvent(t, events)
+	if err := c.Kick(ctx, table.ID, "bob", table.HostToken); err != nil {
+		t.Fatalf("Error kicking bob: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventMatchDecided || event.Session.Players[0].Wins != 1 {
+		t.Errorf("Expected the forfeit to decide the match for alice, got %s", event.Type)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventPlayerKicked || len(event.Session.Players) != 1 {
+		t.Errorf("Expected bob to be removed after the match was decided, got %s", event.Type)
+	}
+}
+
+// TestRematch tests that a rematch starts only once both players are ready
+func TestRematch(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	tableID, err := c.CreateTable(ctx)
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	c.Join(ctx, tableID, "alice")
+	c.Join(ctx, tableID, "bob")
+	if err := c.Start(ctx, tableID); err != nil {
+		t.Fatalf("Error starting game: %v", err)
+	}
+	var apiErr *Error
+	if err := c.Start(ctx, tableID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 starting during a game, got %v", err)
+	}
+	if err := c.Ready(ctx, tableID, "alice"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 signalling ready during a game, got %v", err)
+	}
+	for i, name := range []string{"bob", "alice", "bob", "alice", "bob", "alice", "bob"} {
+		if err := c.Drop(ctx, tableID, name, i%2); err != nil {
+			t.Fatalf("Error dropping for %s: %v", name, err)
+		}
+	}
+
+	if err := c.Start(ctx, tableID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 starting a rematch nobody is ready for, got %v", err)
+	}
+	if err := c.Ready(ctx, tableID, "alice"); err != nil {
+		t.Fatalf("Error signalling ready: %v", err)
+	}
+	table, err := c.Table(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error fetching table: %v", err)
+	}
+	if table.Status || !table.Players[0].Ready || table.Players[1].Ready {
+		t.Errorf("Expected only alice to be ready and no game to start")
+	}
+	if err := c.Ready(ctx, tableID, "bob"); err != nil {
+		t.Fatalf("Error signalling ready: %v", err)
+	}
+	table, err = c.Table(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error fetching table: %v", err)
+	}
+	if !table.Status || table.GetPlayersTurn() != "alice" || table.Players[0].Ready {
+		t.Errorf("Expected the rematch to start with alice and clear ready, got %+v", table)
+	}
+}
+
+// TestPlayAI tests that the host can seat the built-in AI, which answers
+// the moves of the player at the table
+func TestPlayAI(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	table, err := c.CreateTableWith(ctx, TableOptions{})
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	c.Join(ctx, table.ID, "alice")
+	var apiErr *Error
+	if err := c.Join(ctx, table.ID, "ai:1"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 seating the AI without the host token, got %v", err)
+	}
+	if err := c.SeatAI(ctx, table.ID, table.HostToken, 9); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown AI level, got %v", err)
+	}
+	if err := c.SeatAI(ctx, table.ID, table.HostToken, 1); err != nil {
+		t.Fatalf("Error seating the AI: %v", err)
+	}
+	session, err := c.Table(ctx, table.ID)
+	if err != nil {
+		t.Fatalf("Error getting table: %v", err)
+	}
+	if session.Players[1].AI != 1 || session.Host == "ai:1" {
+		t.Errorf("Expected the AI in the second seat without the host role, got %+v", session.Players[1])
+	}
+
+	events, err := c.Subscribe(ctx, table.ID)
+	if err != nil {
+		t.Fatalf("Error subscribing: %v", err)
+	}
+	nextEvent(t, events)
+	if err := c.Start(ctx, table.ID); err != nil {
+		t.Fatalf("Error starting game: %v", err)
+	}
+	// wait for alice's turn, as the AI may have the first move
+	event := nextEvent(t, events)
+	for event.Session.GetPlayersTurn() != "alice" {
+		event = nextEvent(t, events)
+	}
+	moves := len(event.Session.Moves)
+	if err := c.Drop(ctx, table.ID, "alice", 3); err != nil {
+		t.Fatalf("Error dropping: %v", err)
+	}
+	nextEvent(t, events)
+	if event := nextEvent(t, events); event.Type != models.EventPieceDropped || len(event.Session.Moves) != moves+2 || event.Session.GetPlayersTurn() != "alice" {
+		t.Errorf("Expected the AI to answer alice's move, got %s %q", event.Type, event.Message)
+	}
+}
+
+// TestTableExpiry tests that tables are stored under namespaced keys with an
+// expiry and that the janitor closes abandoned tables
+func TestTableExpiry(t *testing.T) {
+	store := miniredis.RunT(t)
+	handler := handlers.NewHandler(redis.NewClient(&redis.Options{Addr: store.Addr()}), "", "", "")
+	handler.Broker = models.NewMemoryBroker()
+	handler.AbandonedTimeout = 50 * time.Millisecond
+	ts := httptest.NewServer(server.NewRouter(handler))
+	t.Cleanup(ts.Close)
+	c := New(ts.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	empty, err := c.CreateTable(ctx)
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	seated, _ := c.CreateTable(ctx)
+	c.Join(ctx, seated, "alice")
+	if ttl := store.TTL(models.SessionKey(empty)); ttl != models.DefaultSessionTTL {
+		t.Errorf("Expected the table key to expire after %v, got %v", models.DefaultSessionTTL, ttl)
+	}
+	events, err := c.Subscribe(ctx, empty)
+	if err != nil {
+		t.Fatalf("Error subscribing: %v", err)
+	}
+	nextEvent(t, events)
+
+	go handler.RunJanitor(ctx, 20*time.Millisecond)
+	if event := nextEvent(t, events); event.Type != models.EventTableExpired {
+		t.Fatalf("Expected table_expired event, got %s", event.Type)
+	}
+	if _, ok := <-events; ok {
+		t.Errorf("Expected the stream to end after the table expired")
+	}
+	var apiErr *Error
+	if _, err := c.Table(ctx, empty); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an expired table, got %v", err)
+	}
+	if _, err := c.Table(ctx, seated); err != nil {
+		t.Errorf("Expected the table with a player to be kept, got %v", err)
+	}
+}
+
+// TestLobby tests listing, filtering and paging tables and the lobby stream
+func TestLobby(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := c.SubscribeLobby(ctx)
+	if err != nil {
+		t.Fatalf("Error subscribing to the lobby: %v", err)
+	}
+	nextEvent(t, events)
+	created, err := c.CreateTableWith(ctx, TableOptions{})
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	waiting := created.ID
+	if event := nextEvent(t, events); event.Type != models.EventTableCreated || event.TableID != waiting {
+		t.Errorf("Expected table_created for %s, got %s for %s", waiting, event.Type, event.TableID)
+	} else if event.Session != nil || event.Table == nil || event.Table.State != models.TableWaiting {
+		t.Errorf("Expected lobby events to carry the summary rather than the session, got %+v", event)
+	}
+	started, _ := c.CreateTableWith(ctx, TableOptions{})
+	playing := started.ID
+	nextEvent(t, events)
+	c.Join(ctx, playing, "alice")
+	c.Join(ctx, playing, "bob")
+	c.Start(ctx, playing)
+	for i := 0; i < 3; i++ {
+		if event := nextEvent(t, events); event.Type != models.EventTableUpdated {
+			t.Errorf("Expected table_updated, got %s", event.Type)
+		}
+	}
+	body, err := c.do(ctx, "GET", "/create?private=true", nil)
+	if err != nil {
+		t.Fatalf("Error creating private table: %v", err)
+	}
+	private := string(body)
+	c.Join(ctx, private, "carol")
+
+	lobby, err := c.Tables(ctx, models.LobbyFilter{})
+	if err != nil {
+		t.Fatalf("Error listing tables: %v", err)
+	}
+	if lobby.Total != 2 || lobby.Tables[0].ID != playing {
+		t.Errorf("Expected the 2 public tables, most recent first, got %+v", lobby)
+	}
+	lobby, _ = c.Tables(ctx, models.LobbyFilter{State: models.TableInProgress, Variant: "7x6"})
+	if lobby.Total != 1 || lobby.Tables[0].ID != playing || len(lobby.Tables[0].Players) != 2 {
+		t.Errorf("Expected only the game in progress, got %+v", lobby)
+	}
+	lobby, _ = c.Tables(ctx, models.LobbyFilter{State: models.TableWaiting})
+	if lobby.Total != 1 || lobby.Tables[0].ID != waiting {
+		t.Errorf("Expected only the public waiting table, got %+v", lobby)
+	}
+	lobby, _ = c.Tables(ctx, models.LobbyFilter{Offset: 1, Limit: 1})
+	if lobby.Total != 2 || len(lobby.Tables) != 1 || lobby.Tables[0].ID != waiting {
+		t.Errorf("Expected the second table on its own page, got %+v", lobby)
+	}
+	var apiErr *Error
+	if _, err := c.Tables(ctx, models.LobbyFilter{State: "open"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown state, got %v", err)
+	}
+
+	hidden := true
+	if _, err := c.UpdateSettings(ctx, waiting, created.HostToken, TableSettings{Private: &hidden}); err != nil {
+		t.Fatalf("Error making the table private: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventTableClosed || event.TableID != waiting || event.Table != nil {
+		t.Errorf("Expected the table made private to leave the lobby, got %s for %s", event.Type, event.TableID)
+	}
+	c.DeleteTable(ctx, playing, started.HostToken)
+	if event := nextEvent(t, events); event.Type != models.EventTableClosed || event.TableID != playing {
+		t.Errorf("Expected table_closed for %s, got %s for %s", playing, event.Type, event.TableID)
+	}
+	if lobby, _ := c.Tables(ctx, models.LobbyFilter{}); lobby.Total != 0 {
+		t.Errorf("Expected the lobby to be empty, got %+v", lobby)
+	}
+}
+
+// TestNamedTable tests names, join codes, invite links and passwords
+func TestNamedTable(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	table, err := c.CreateTableWith(ctx, TableOptions{Name: "Friday night", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	if len(table.Code) != models.CodeLength || table.Invite != "/join/"+table.Code || !table.Protected {
+		t.Errorf("Expected a protected table with a join code and invite link, got %+v", table)
+	}
+	var apiErr *Error
+	if _, err := c.CreateTableWith(ctx, TableOptions{Name: "friday NIGHT"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 reusing a table name, got %v", err)
+	}
+
+	invited, err := c.Invite(ctx, strings.ToLower(table.Code))
+	if err != nil {
+		t.Fatalf("Error resolving invite: %v", err)
+	}
+	if invited.ID != table.ID || invited.Name != "Friday night" || !invited.Protected {
+		t.Errorf("Expected the invite to lead to the table, got %+v", invited)
+	}
+	if _, err := c.Invite(ctx, "ZZZZZZ"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown code, got %v", err)
+	}
+
+	if err := c.Join(ctx, table.ID, "alice"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 joining without the password, got %v", err)
+	}
+	if err := c.JoinWithPassword(ctx, table.ID, "alice", "secret"); err != nil {
+		t.Errorf("Expected the password to seat alice, got %v", err)
+	}
+
+	if err := c.DeleteTable(ctx, table.ID, table.HostToken); err != nil {
+		t.Fatalf("Error deleting table: %v", err)
+	}
+	if _, err := c.Invite(ctx, table.Code); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected the code to be released with the table, got %v", err)
+	}
+	if _, err := c.CreateTableWith(ctx, TableOptions{Name: "Friday night"}); err != nil {
+		t.Errorf("Expected the name to be free again, got %v", err)
+	}
+}
+
+// TestHost tests the host-only controls of a table
+func TestHost(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	table, err := c.CreateTableWith(ctx, TableOptions{})
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	var apiErr *Error
+	if err := c.DeleteTable(ctx, table.ID, ""); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 deleting without the host token, got %v", err)
+	}
+
+	if err := c.Lock(ctx, table.ID, table.HostToken); err != nil {
+		t.Fatalf("Error locking table: %v", err)
+	}
+	if err := c.Join(ctx, table.ID, "bob"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 joining a locked table, got %v", err)
+	}
+	if err := c.JoinAsHost(ctx, table.ID, "alice", table.HostToken); err != nil {
+		t.Errorf("Expected the host to get past the lock, got %v", err)
+	}
+	c.Unlock(ctx, table.ID, table.HostToken)
+	if err := c.Join(ctx, table.ID, "bob"); err != nil {
+		t.Errorf("Expected bob to join once unlocked, got %v", err)
+	}
+
+	events, err := c.SubscribeAs(ctx, table.ID, "mallory")
+	if err != nil {
+		t.Fatalf("Error subscribing: %v", err)
+	}
+	nextEvent(t, events)
+	if err := c.Kick(ctx, table.ID, "mallory", table.HostToken); err != nil {
+		t.Fatalf("Error kicking viewer: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventPlayerKicked {
+		t.Errorf("Expected player_kicked, got %s", event.Type)
+	}
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Errorf("Expected the kicked viewer's stream to end")
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("Timed out waiting for the kicked viewer's stream to end")
+	}
+	if _, err := c.SubscribeAs(ctx, table.ID, "mallory"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a kicked viewer coming back, got %v", err)
+	}
+
+	name := "Sunday league"
+	state, err := c.UpdateSettings(ctx, table.ID, table.HostToken, TableSettings{Name: &name})
+	if err != nil {
+		t.Fatalf("Error changing settings: %v", err)
+	}
+	if state.Name != name || state.Host != "alice" {
+		t.Errorf("Expected the renamed table hosted by alice, got %q hosted by %q", state.Name, state.Host)
+	}
+
+	token, err := c.TransferHost(ctx, table.ID, "bob", table.HostToken)
+	if err != nil {
+		t.Fatalf("Error transferring host: %v", err)
+	}
+	if err := c.Lock(ctx, table.ID, table.HostToken); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected the old host token to stop working, got %v", err)
+	}
+	if err := c.Kick(ctx, table.ID, "alice", token); err != nil {
+		t.Errorf("Expected bob to act as host, got %v", err)
+	}
+	if err := c.Join(ctx, table.ID, "alice"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a kicked player rejoining, got %v", err)
+	}
+	if err := c.DeleteTable(ctx, table.ID, token); err != nil {
+		t.Errorf("Expected the new host to delete the table, got %v", err)
+	}
+}
+
+// TestReconnect tests that a player whose stream drops may come back within
+// the grace period and forfeits once it is over
+func TestReconnect(t *testing.T) {
+	store := miniredis.RunT(t)
+	handler := handlers.NewHandler(redis.NewClient(&redis.Options{Addr: store.Addr()}), "", "", "")
+	handler.Broker = models.NewMemoryBroker()
+	handler.ReconnectGrace = 300 * time.Millisecond
+	ts := httptest.NewServer(server.NewRouter(handler))
+	t.Cleanup(ts.Close)
+	c := New(ts.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tableID, err := c.CreateTable(ctx)
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	c.Join(ctx, tableID, "alice")
+	c.Join(ctx, tableID, "bob")
+	c.Start(ctx, tableID)
+	events, err := c.Subscribe(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error subscribing: %v", err)
+	}
+	nextEvent(t, events)
+
+	// connect streams as alice until the returned function is called
+	connect := func() func() {
+		aliceCtx, leave := context.WithCancel(ctx)
+		alice, err := c.SubscribeAs(aliceCtx, tableID, "alice")
+		if err != nil {
+			t.Fatalf("Error subscribing as alice: %v", err)
+		}
+		nextEvent(t, alice)
+		return leave
+	}
+	leave := connect()
+	leave()
+	if event := nextEvent(t, events); event.Type != models.EventPlayerDisconnected || event.Session.Players[0].Away == nil {
+		t.Errorf("Expected player_disconnected with alice away, got %s", event.Type)
+	}
+	leave = connect()
+	if event := nextEvent(t, events); event.Type != models.EventPlayerReconnected || !event.Session.Status {
+		t.Errorf("Expected player_reconnected with the game going on, got %s", event.Type)
+	}
+
+	leave()
+	nextEvent(t, events)
+	event := nextEvent(t, events)
+	if event.Type != models.EventGameForfeited || event.Session.Status || event.Session.Players[1].Wins != 1 {
+		t.Errorf("Expected alice to forfeit the game to bob, got %s", event.Type)
+	}
+}
+
+// TestEndGameEarly tests resigning, draw offers and claiming an abandoned game
+func TestEndGameEarly(t *testing.T) {
+	store := miniredis.RunT(t)
+	handler := handlers.NewHandler(redis.NewClient(&redis.Options{Addr: store.Addr()}), "", "", "")
+	handler.Broker = models.NewMemoryBroker()
+	handler.ReconnectGrace = 50 * time.Millisecond
+	handler.DisconnectAction = models.DisconnectPause
+	ts := httptest.NewServer(server.NewRouter(handler))
+	t.Cleanup(ts.Close)
+	c := New(ts.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tableID, err := c.CreateTable(ctx)
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	c.Join(ctx, tableID, "alice")
+	c.Join(ctx, tableID, "bob")
+	events, err := c.Subscribe(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error subscribing: %v", err)
+	}
+	nextEvent(t, events)
+
+	var apiErr *Error
+	if err := c.Resign(ctx, tableID, "alice"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 resigning without a game, got %v", err)
+	}
+	c.Start(ctx, tableID)
+	nextEvent(t, events)
+	if err := c.Resign(ctx, tableID, "alice"); err != nil {
+		t.Fatalf("Error resigning: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventPlayerResigned || event.Session.Status || event.Session.Players[1].Wins != 1 {
+		t.Errorf("Expected player_resigned with bob winning, got %s", event.Type)
+	}
+
+	c.Ready(ctx, tableID, "alice")
+	c.Ready(ctx, tableID, "bob")
+	for i := 0; i < 3; i++ {
+		nextEvent(t, events)
+	}
+	c.OfferDraw(ctx, tableID, "bob")
+	if event := nextEvent(t, events); event.Type != models.EventDrawOffered || event.Session.DrawOffer != "bob" {
+		t.Errorf("Expected draw_offered by bob, got %s", event.Type)
+	}
+	c.DeclineDraw(ctx, tableID, "alice")
+	if event := nextEvent(t, events); event.Type != models.EventDrawDeclined || !event.Session.Status {
+		t.Errorf("Expected draw_declined with the game going on, got %s", event.Type)
+	}
+	c.OfferDraw(ctx, tableID, "alice")
+	nextEvent(t, events)
+	if err := c.AcceptDraw(ctx, tableID, "alice"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 accepting your own offer, got %v", err)
+	}
+	c.AcceptDraw(ctx, tableID, "bob")
+	if event := nextEvent(t, events); event.Type != models.EventDrawAccepted || event.Session.Status {
+		t.Errorf("Expected draw_accepted ending the game, got %s", event.Type)
+	}
+
+	c.Ready(ctx, tableID, "alice")
+	c.Ready(ctx, tableID, "bob")
+	for i := 0; i < 3; i++ {
+		nextEvent(t, events)
+	}
+	aliceCtx, leave := context.WithCancel(ctx)
+	alice, err := c.SubscribeAs(aliceCtx, tableID, "alice")
+	if err != nil {
+		t.Fatalf("Error subscribing as alice: %v", err)
+	}
+	nextEvent(t, alice)
+	leave()
+	nextEvent(t, events)
+	if event := nextEvent(t, events); event.Type != models.EventGamePaused || !event.Session.Paused {
+		t.Fatalf("Expected game_paused, got %s", event.Type)
+	}
+	if err := c.ClaimAbandonment(ctx, tableID, "bob"); err != nil {
+		t.Fatalf("Error claiming the game: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventAbandonmentClaimed || event.Session.Paused || event.Session.Players[1].Wins != 2 {
+		t.Errorf("Expected abandonment_claimed with bob winning, got %s", event.Type)
+	}
+}
+
+// TestTeams plays a team game to a win for the team of alice and carol
+func TestTeams(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	var apiErr *Error
+	if _, err := c.CreateTableWith(ctx, TableOptions{Seats: 3, Teams: true}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for teams of three, got %v", err)
+	}
+	table, err := c.CreateTableWith(ctx, TableOptions{Teams: true})
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		c.Join(ctx, table.ID, name)
+	}
+	if err := c.Start(ctx, table.ID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 starting with an empty seat, got %v", err)
+	}
+	c.Join(ctx, table.ID, "dave")
+	if err := c.Join(ctx, table.ID, "erin"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 joining a full table, got %v", err)
+	}
+	if err := c.Start(ctx, table.ID); err != nil {
+		t.Fatalf("Error starting game: %v", err)
+	}
+
+	// Turns go round the table from bob; alice and carol stack column 1
+	moves := []struct {
+		name   string
+		column int
+	}{
+		{"bob", 0}, {"carol", 1}, {"dave", 0}, {"alice", 1},
+		{"bob", 0}, {"carol", 1}, {"dave", 2}, {"alice", 1},
+	}
+	for _, move := range moves {
+		if err := c.Drop(ctx, table.ID, move.name, move.column); err != nil {
+			t.Fatalf("Error dropping for %s: %v", move.name, err)
+		}
+	}
+	state, err := c.Table(ctx, table.ID)
+	if err != nil {
+		t.Fatalf("Error reading table: %v", err)
+	}
+	if state.Status {
+		t.Fatalf("Expected the game to be over")
+	}
+	for i, want := range []int{1, 0, 1, 0} {
+		if wins := state.Players[i].Wins; wins != want {
+			t.Errorf("Expected %s to have %d wins, got %d", state.Players[i].Name, want, wins)
+		}
+	}
+	game, err := c.Export(ctx, table.ID)
+	if err != nil {
+		t.Fatalf("Error exporting game: %v", err)
+	}
+	if !strings.Contains(game, `[X "alice & carol"]`) || !strings.Contains(game, "1-0") {
+		t.Errorf("Expected the game credited to the team of alice and carol, got %s", game)
+	}
+}
+
+// TestAppearance tests that players join with the symbol, color and avatar they pick
+func TestAppearance(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	tableID, err := c.CreateTable(ctx)
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	var apiErr *Error
+	for _, options := range []JoinOptions{{Symbol: "|"}, {Symbol: "井"}, {Color: "mauve"}, {Avatar: "javascript:alert(1)"}} {
+		if err := c.JoinWith(ctx, tableID, "alice", options); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected 400 joining with %+v, got %v", options, err)
+		}
+	}
+	alice := JoinOptions{Symbol: "*", Color: "green", Avatar: "https://example.com/alice.png"}
+	if err := c.JoinWith(ctx, tableID, "alice", alice); err != nil {
+		t.Fatalf("Error joining as alice: %v", err)
+	}
+	if err := c.JoinWith(ctx, tableID, "bob", JoinOptions{Symbol: "*", Color: "green"}); err != nil {
+		t.Fatalf("Error joining as bob: %v", err)
+	}
+	state, err := c.Table(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error reading table: %v", err)
+	}
+	if player := state.Players[0]; player.Symbol != "*" || player.Color != "green" || player.Avatar != alice.Avatar {
+		t.Errorf("Expected alice to keep the choices made, got %+v", player)
+	}
+	if player := state.Players[1]; player.Symbol != "O" || player.Color != "yellow" {
+		t.Errorf("Expected bob to fall back to the seat's O in yellow, got %+v", player)
+	}
+}
+
+// TestFirstMoveAndHandicap tests the host choosing the first player and giving a handicap
+func TestFirstMoveAndHandicap(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	var apiErr *Error
+	if _, err := c.CreateTableWith(ctx, TableOptions{FirstMove: "winner"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown first move policy, got %v", err)
+	}
+	table, err := c.CreateTableWith(ctx, TableOptions{FirstMove: models.FirstMoveHost})
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	c.Join(ctx, table.ID, "alice")
+	c.Join(ctx, table.ID, "bob")
+
+	first, column := "bob", 0
+	settings := TableSettings{
+		FirstPlayer: &first,
+		Handicap:    &models.Handicap{Player: "alice", Pieces: []int{3}, Column: &column},
+	}
+	if _, err := c.UpdateSettings(ctx, table.ID, table.HostToken, settings); err != nil {
+		t.Fatalf("Error updating settings: %v", err)
+	}
+	if err := c.Start(ctx, table.ID); err != nil {
+		t.Fatalf("Error starting game: %v", err)
+	}
+	state, err := c.Table(ctx, table.ID)
+	if err != nil {
+		t.Fatalf("Error reading table: %v", err)
+	}
+	if state.GetPlayersTurn() != "bob" {
+		t.Errorf("Expected bob to move first, got %s", state.GetPlayersTurn())
+	}
+	if state.Grid[5][3] != models.Player2Symbol {
+		t.Errorf("Expected bob to start with a piece in column 3")
+	}
+	c.Drop(ctx, table.ID, "bob", 1)
+	if err := c.Drop(ctx, table.ID, "alice", 3); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for alice ignoring the handicap, got %v", err)
+	}
+	if err := c.Drop(ctx, table.ID, "alice", 0); err != nil {
+		t.Errorf("Error playing the handicap column: %v", err)
+	}
+}
+
+// TestShutdown tests that streams end with a server_shutdown event, new
+// streams are turned away and players cut off are not marked away
+func TestShutdown(t *testing.T) {
+	store := miniredis.RunT(t)
+	handler := handlers.NewHandler(redis.NewClient(&redis.Options{Addr: store.Addr()}), "", "", "")
+	handler.Broker = models.NewMemoryBroker()
+	handler.ReconnectGrace = time.Minute
+	ts := httptest.NewServer(server.NewRouter(handler))
+	t.Cleanup(ts.Close)
+	c := New(ts.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tableID, err := c.CreateTable(ctx)
+	if err != nil {
+		t.Fatalf("Error creating table: %v", err)
+	}
+	c.Join(ctx, tableID, "alice")
+	c.Join(ctx, tableID, "bob")
+	c.Start(ctx, tableID)
+	alice, err := c.SubscribeAs(ctx, tableID, "alice")
+	if err != nil {
+		t.Fatalf("Error subscribing as alice: %v", err)
+	}
+	nextEvent(t, alice)
+	lobby, err := c.SubscribeLobby(ctx)
+	if err != nil {
+		t.Fatalf("Error subscribing to the lobby: %v", err)
+	}
+	nextEvent(t, lobby)
+
+	handler.StopStreams()
+	for name, events := range map[string]<-chan models.Event{"table": alice, "lobby": lobby} {
+		event := nextEvent(t, events)
+		if event.Type != models.EventServerShutdown || event.RetryMS != models.DefaultShutdownRetry.Milliseconds() {
+			t.Errorf("Expected server_shutdown with a reconnect hint on the %s stream, got %s after %dms", name, event.Type, event.RetryMS)
+		}
+		if _, ok := <-events; ok {
+			t.Errorf("Expected the %s stream to end after server_shutdown", name)
+		}
+	}
+
+	var apiErr *Error
+	if _, err := c.Subscribe(ctx, tableID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for a new stream while shutting down, got %v", err)
+	}
+	table, err := c.Table(ctx, tableID)
+	if err != nil {
+		t.Fatalf("Error getting table: %v", err)
+	}
+	if table.Players[0].Away != nil {
+		t.Errorf("Expected alice not to be marked away by the shutdown")
+	}
+	if err := handler.Shutdown(ctx); err != nil {
+		t.Errorf("Expected the shutdown to finish, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"blackjackapi/models"
+	"blackjackapi/tournament"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// TestTournament tests a Swiss tournament from registration to the result
+func TestTournament(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tour, err := c.CreateTournament(ctx, "Office cup", tournament.FormatSwiss, 1)
+	if err != nil {
+		t.Fatalf("Error creating tournament: %v", err)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		if err := c.RegisterTournament(ctx, tour.ID, name); err != nil {
+			t.Fatalf("Error registering %s: %v", name, err)
+		}
+	}
+	var apiErr *Error
+	if err := c.RegisterTournament(ctx, tour.ID, "bob"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected a conflict registering bob twice, got %v", err)
+	}
+	events, err := c.SubscribeTournament(ctx, tour.ID)
+	if err != nil {
+		t.Fatalf("Error subscribing: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventConnected {
+		t.Errorf("Expected connected event, got %s", event.Type)
+	}
+
+	tour, err = c.StartTournament(ctx, tour.ID)
+	if err != nil {
+		t.Fatalf("Error starting tournament: %v", err)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventRoundPaired {
+		t.Errorf("Expected round_paired event, got %s", event.Type)
+	}
+	if len(tour.Matches) != 1 || tour.Matches[0].TableID == "" {
+		t.Fatalf("Expected one match with a table, got %+v", tour.Matches)
+	}
+	tableID := tour.Matches[0].TableID
+	table, err := c.Table(ctx, tableID)
+	if err != nil || len(table.Players) != 2 || table.Tournament != tour.ID {
+		t.Fatalf("Expected a table with both players for the tournament, got %+v, %v", table, err)
+	}
+
+	// bob moves first and wins in column 0
+	if err := c.Start(ctx, tableID); err != nil {
+		t.Fatalf("Error starting game: %v", err)
+	}
+	for i := 0; i < 7; i++ {
+		name, column := "bob", 0
+		if i%2 == 1 {
+			name, column = "alice", 1
+		}
+		if err := c.Drop(ctx, tableID, name, column); err != nil {
+			t.Fatalf("Error dropping for %s: %v", name, err)
+		}
+	}
+	if event := nextEvent(t, events); event.Type != models.EventMatchResult {
+		t.Errorf("Expected match_result event, got %s", event.Type)
+	}
+	if event := nextEvent(t, events); event.Type != models.EventTournamentFinished {
+		t.Errorf("Expected tournament_finished event, got %s", event.Type)
+	}
+
+	standings, err := c.Standings(ctx, tour.ID)
+	if err != nil {
+		t.Fatalf("Error getting standings: %v", err)
+	}
+	if standings[0].Player != "bob" || standings[0].Points != 1 {
+		t.Errorf("Expected bob to lead with a point, got %+v", standings[0])
+	}
+	if tour, err = c.Tournament(ctx, tour.ID); err != nil || tour.Champion != "bob" || tour.Status != tournament.StatusFinished {
+		t.Errorf("Expected bob to win the finished tournament, got %+v, %v", tour, err)
+	}
+}
@@ -0,0 +1,206 @@
+// Command connect4-bot connects an engine that speaks a UCI-style protocol
+// over stdin and stdout to the Connect 4 bot API.
+//
+// The adapter starts the engine and exchanges one command per line:
+//
+//	adapter: uci
+//	engine:  uciok                         (after any id lines)
+//	adapter: isready
+//	engine:  readyok
+//	adapter: position fen 7/7/7/7/7/3X3 O  (position notation, symbol to move last)
+//	adapter: go movetime 9500              (milliseconds left until the deadline)
+//	engine:  bestmove 4                    (one-based column, as on the board)
+//	adapter: quit
+//
+// Lines the engine writes other than the ones expected, such as info lines,
+// are ignored.
+package main
+
+import (
+	"blackjackapi/client"
+	"blackjackapi/models"
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const usage = `usage: connect4-bot [flags] -register NAME
+       connect4-bot [flags] [-join TABLE,...] engine [arguments]
+`
+
+// moveMargin is kept back from each deadline for the request carrying the move
+const moveMargin = 200 * time.Millisecond
+
+func main() {
+	server := flag.String("server", defaultServer(), "Connect 4 API base URL (or set CONNECT4_SERVER)")
+	token := flag.String("token", os.Getenv("CONNECT4_BOT_TOKEN"), "bot token (or set CONNECT4_BOT_TOKEN)")
+	register := flag.String("register", "", "register a bot with this name, print its token and exit")
+	moveTime := flag.Duration("move-time", models.DefaultBotMoveTime, "time per move when registering")
+	join := flag.String("join", "", "comma separated tables to seat the bot at")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	c := client.New(*server)
+
+	if *register != "" {
+		token, err := c.RegisterBot(ctx, *register, *moveTime)
+		if err != nil {
+			log.Fatalf("connect4-bot: %v", err)
+		}
+		fmt.Println(token)
+		return
+	}
+	if flag.NArg() == 0 || *token == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	c.Token = *token
+
+	e, err := startEngine(flag.Arg(0), flag.Args()[1:]...)
+	if err != nil {
+		log.Fatalf("connect4-bot: %v", err)
+	}
+	defer e.quit()
+
+	if err := run(ctx, c, e, *join); err != nil && ctx.Err() == nil {
+		log.Fatalf("connect4-bot: %v", err)
+	}
+}
+
+func defaultServer() string {
+	if server := os.Getenv("CONNECT4_SERVER"); server != "" {
+		return server
+	}
+	return "http://localhost:8080"
+}
+
+// run seats the bot and plays the engine's moves until the stream ends
+func run(ctx context.Context, c *client.Client, e *engine, join string) error {
+	events, err := c.BotEvents(ctx)
+	if err != nil {
+		return err
+	}
+	if join != "" {
+		for _, tableID := range strings.Split(join, ",") {
+			if err := c.BotJoin(ctx, tableID); err != nil {
+				return fmt.Errorf("joining %s: %v", tableID, err)
+			}
+			log.Printf("joined table %s", tableID)
+		}
+	}
+
+	for event := range events {
+		switch event.Type {
+		case models.EventYourTurn:
+			left := time.Until(*event.Deadline) - moveMargin
+			column, err := e.bestMove(event.Position, max(left, time.Millisecond))
+			if err != nil {
+				return err
+			}
+			if err := c.BotMove(ctx, event.TableID, column); err != nil {
+				log.Printf("move in column %d at table %s rejected: %v", column+1, event.TableID, err)
+			}
+		case models.EventMoveTimeout:
+			log.Printf("table %s: %s", event.TableID, event.Message)
+		}
+	}
+	return errors.New("bot event stream closed")
+}
+
+// engine is a running engine process
+type engine struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines *bufio.Scanner
+}
+
+// startEngine runs the engine and waits for it to be ready
+func startEngine(name string, args ...string) (*engine, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	e := &engine{cmd: cmd, stdin: stdin, lines: bufio.NewScanner(stdout)}
+	if err := e.send("uci"); err != nil {
+		return nil, err
+	}
+	if _, err := e.expect("uciok"); err != nil {
+		return nil, err
+	}
+	if err := e.send("isready"); err != nil {
+		return nil, err
+	}
+	if _, err := e.expect("readyok"); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// bestMove asks the engine for a zero-based column
+func (e *engine) bestMove(position string, moveTime time.Duration) (int, error) {
+	if err := e.send("position fen " + position); err != nil {
+		return 0, err
+	}
+	if err := e.send(fmt.Sprintf("go movetime %d", moveTime.Milliseconds())); err != nil {
+		return 0, err
+	}
+	line, err := e.expect("bestmove")
+	if err != nil {
+		return 0, err
+	}
+	column, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "bestmove")))
+	if err != nil {
+		return 0, fmt.Errorf("engine answered %q", line)
+	}
+	return column - 1, nil
+}
+
+func (e *engine) send(command string) error {
+	_, err := fmt.Fprintln(e.stdin, command)
+	return err
+}
+
+// expect reads lines until one starts with the word
+func (e *engine) expect(word string) (string, error) {
+	for e.lines.Scan() {
+		line := strings.TrimSpace(e.lines.Text())
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == word {
+			return line, nil
+		}
+	}
+	if err := e.lines.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("engine exited waiting for %s", word)
+}
+
+// quit asks the engine to exit and waits for it
+func (e *engine) quit() {
+	e.send("quit")
+	e.stdin.Close()
+	e.cmd.Wait()
+}
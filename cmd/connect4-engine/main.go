@@ -0,0 +1,77 @@
+// Command connect4-engine is the built-in engine behind the UCI-style
+// protocol spoken by connect4-bot, for testing bots and as a sparring partner.
+package main
+
+import (
+	"blackjackapi/engine"
+	"blackjackapi/models"
+	"blackjackapi/notation"
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	searcher := engine.NewSearcher(20)
+	var board *engine.Board
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	in := bufio.NewScanner(os.Stdin)
+	for in.Scan() {
+		fields := strings.Fields(in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			fmt.Fprintln(out, "id name connect4-engine")
+			fmt.Fprintln(out, "uciok")
+		case "isready":
+			fmt.Fprintln(out, "readyok")
+		case "position":
+			if len(fields) != 4 || fields[1] != "fen" {
+				fmt.Fprintln(out, "info string expected position fen <rows> <symbol to move>")
+				continue
+			}
+			b, err := parsePosition(fields[2] + " " + fields[3])
+			if err != nil {
+				fmt.Fprintln(out, "info string", err)
+				continue
+			}
+			board = b
+		case "go":
+			if board == nil {
+				fmt.Fprintln(out, "info string no position")
+				continue
+			}
+			budget := engine.Budget{MaxTime: time.Second}
+			if len(fields) == 3 && fields[1] == "movetime" {
+				if ms, err := strconv.Atoi(fields[2]); err == nil && ms > 0 {
+					budget.MaxTime = time.Duration(ms) * time.Millisecond
+				}
+			}
+			analysis := searcher.Analyze(board, budget)
+			fmt.Fprintf(out, "info depth %d nodes %d\n", analysis.Depth, analysis.Nodes)
+			fmt.Fprintf(out, "bestmove %d\n", analysis.BestMove+1)
+		case "quit":
+			return
+		}
+		out.Flush()
+	}
+}
+
+// parsePosition reads position notation into a board
+func parsePosition(text string) (*engine.Board, error) {
+	position, toMove, err := notation.ParsePosition(text)
+	if err != nil {
+		return nil, err
+	}
+	if toMove == models.Player2Symbol {
+		position.Turn = 1
+	}
+	return engine.FromSession(position)
+}
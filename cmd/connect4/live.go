@@ -0,0 +1,223 @@
+package main
+
+import (
+	"blackjackapi/client"
+	"blackjackapi/models"
+	"blackjackapi/render"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const playHelp = "1-7 drop  ←/→ move  enter drop  s start/rematch  q quit"
+
+// Keys understood by play
+const (
+	keyNone = iota
+	keyLeft
+	keyRight
+	keyEnter
+	keyStart
+	keyQuit
+	keyColumn
+)
+
+// key is one keyboard action; column is set for keyColumn
+type key struct {
+	kind   int
+	column int
+}
+
+// screen redraws the terminal, translating newlines while stdin is in raw mode
+type screen struct {
+	raw bool
+}
+
+func (s *screen) draw(text string) {
+	text = "\033[H\033[2J" + text
+	if s.raw {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	fmt.Print(text)
+}
+
+// watch prints every table update until the stream ends or ctx is done. A
+// server shutting down ends the stream cleanly.
+func watch(ctx context.Context, c *client.Client, style render.Style, tableID string) error {
+	events, err := c.Subscribe(ctx, tableID)
+	if err != nil {
+		return err
+	}
+	s := &screen{}
+	for event := range events {
+		if event.Type == models.EventServerShutdown {
+			fmt.Println(event.Message)
+			return nil
+		}
+		if event.Type == models.EventTableExpired {
+			s.draw(render.Event(event, style))
+			return nil
+		}
+		if event.Session == nil {
+			continue
+		}
+		s.draw(render.Event(event, style))
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errors.New("stream closed by server")
+}
+
+// play follows the table like watch and sends the player's moves from the keyboard
+func play(ctx context.Context, c *client.Client, style render.Style, tableID, name string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := c.SubscribeAs(ctx, tableID, name)
+	if err != nil {
+		return err
+	}
+
+	s := &screen{}
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		defer term.Restore(fd, state)
+		s.raw = true
+	}
+	keys := make(chan key)
+	go readKeys(keys, s.raw)
+
+	var table *models.Session
+	board := ""
+	cursor := 0
+	status := ""
+	for {
+		if table != nil {
+			s.draw(board + cursorLine(cursor) + "\n" + status + "\n" + playHelp + "\n")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return errors.New("stream closed by server")
+			}
+			if event.Type == models.EventTableExpired {
+				return errors.New(event.Message)
+			}
+			if event.Type == models.EventServerShutdown {
+				s.draw(board + "\n" + event.Message + "\n")
+				return nil
+			}
+			// Only table updates change the board
+			if event.Session == nil {
+				continue
+			}
+			table = event.Session
+			board = render.Event(event, style)
+			status = turnStatus(table, name)
+		case k, ok := <-keys:
+			if !ok || k.kind == keyQuit {
+				return nil
+			}
+			if table == nil {
+				continue
+			}
+			columns := len(table.Grid[0])
+			switch k.kind {
+			case keyLeft:
+				cursor = (cursor + columns - 1) % columns
+			case keyRight:
+				cursor = (cursor + 1) % columns
+			case keyStart:
+				// After the first game both players have to ask for the rematch
+				if table.Starts > 0 {
+					status = resultStatus(c.Ready(ctx, tableID, name), "Waiting for opponent to be ready...")
+				} else {
+					status = resultStatus(c.Start(ctx, tableID), "Starting game...")
+				}
+			case keyColumn, keyEnter:
+				if k.kind == keyColumn {
+					if k.column >= columns {
+						continue
+					}
+					cursor = k.column
+				}
+				status = resultStatus(c.Drop(ctx, tableID, name, cursor), "Waiting for opponent...")
+			}
+		}
+	}
+}
+
+// readKeys decodes keyboard input into keys until stdin is closed
+func readKeys(keys chan<- key, raw bool) {
+	defer close(keys)
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		for i := 0; i < n; i++ {
+			k := key{}
+			switch b := buf[i]; {
+			case b >= '1' && b <= '9':
+				k = key{kind: keyColumn, column: int(b - '1')}
+			case b == 'a' || b == 'h':
+				k.kind = keyLeft
+			case b == 'd' || b == 'l':
+				k.kind = keyRight
+			case b == 's':
+				k.kind = keyStart
+			case b == 'q' || b == 3 || b == 4:
+				k.kind = keyQuit
+			case (b == '\r' || b == ' ') && raw:
+				k.kind = keyEnter
+			case b == 0x1b && i+2 < n && buf[i+1] == '[':
+				// Arrow keys arrive as ESC [ C and ESC [ D
+				if buf[i+2] == 'D' {
+					k.kind = keyLeft
+				} else if buf[i+2] == 'C' {
+					k.kind = keyRight
+				}
+				i += 2
+			}
+			if k.kind != keyNone {
+				keys <- k
+			}
+		}
+	}
+}
+
+// cursorLine points at the selected column under the board's column numbers
+func cursorLine(column int) string {
+	return strings.Repeat(" ", 2+4*column) + "^"
+}
+
+func turnStatus(table *models.Session, name string) string {
+	if !table.Status {
+		return "Waiting for the game to start..."
+	}
+	if table.GetPlayersTurn() == name {
+		return "Your turn"
+	}
+	return "Waiting for " + table.GetPlayersTurn() + "..."
+}
+
+func resultStatus(err error, ok string) string {
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Message
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ok
+}
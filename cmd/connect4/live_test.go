@@ -0,0 +1,69 @@
+package main
+
+import (
+	"blackjackapi/client"
+	"blackjackapi/models"
+	"blackjackapi/render"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// streamServer serves the events as the JSON event stream of every table
+func streamServer(t *testing.T, events ...models.Event) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			data, _ := json.Marshal(event)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// TestWatchShutdown tests that watch prints the table updates, skips events
+// without a table and ends cleanly when the server shuts down
+func TestWatchShutdown(t *testing.T) {
+	table := models.NewSession("table")
+	ts := streamServer(t,
+		models.NewEvent(models.EventConnected, table, "Watching table"),
+		models.Event{Type: models.EventTableUpdated, TableID: "table", Message: "Lobby summary"},
+		models.Event{Type: models.EventServerShutdown, TableID: "table", Message: "Server restarting", RetryMS: 1000},
+	)
+	style, _ := render.Lookup("unicode")
+
+	var err error
+	output := captureStdout(t, func() {
+		err = watch(context.Background(), client.New(ts.URL), style, "table")
+	})
+	if err != nil {
+		t.Errorf("Expected watch to end cleanly on server_shutdown, got %v", err)
+	}
+	if !strings.Contains(output, "Watching table") || !strings.Contains(output, "Server restarting") {
+		t.Errorf("Expected the table and the shutdown message to be printed, got\n%s", output)
+	}
+	if strings.Contains(output, "Lobby summary") {
+		t.Errorf("Expected the event without a table to be skipped, got\n%s", output)
+	}
+}
+
+// TestWatchStreamClosed tests that watch reports a stream the server ends
+// without saying why
+func TestWatchStreamClosed(t *testing.T) {
+	ts := streamServer(t, models.NewEvent(models.EventConnected, models.NewSession("table"), "Watching table"))
+	style, _ := render.Lookup("unicode")
+
+	var err error
+	captureStdout(t, func() {
+		err = watch(context.Background(), client.New(ts.URL), style, "table")
+	})
+	if err == nil || err.Error() != "stream closed by server" {
+		t.Errorf("Expected the closed stream to be reported, got %v", err)
+	}
+}
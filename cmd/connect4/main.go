@@ -0,0 +1,156 @@
+// Command connect4 plays and administers Connect 4 tables from the terminal.
+package main
+
+import (
+	"blackjackapi/client"
+	"blackjackapi/render"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+)
+
+const usage = `usage: connect4 [-server URL] [-style NAME] [-host-token TOKEN] [-symbol S] [-color C] [-avatar URL] <command> [arguments]
+
+commands:
+  create                          create a table, print its ID and its host token on stderr
+  show   <table>                  print the table once
+  join   <table> <name>           seat a player at the table, showing -symbol, -color and -avatar
+  leave  <table> <name>           remove a player from the table
+  start  <table>                  start a game
+  ready  <table> <name>           ask for a rematch, which starts once both players are ready
+  match  <table> <format> <games> start a first_to or best_of match, then start its first game (host only)
+  drop   <table> <name> <column>  drop a piece, columns numbered 1-7 as on the board
+  resign <table> <name>           give the game to the opponent
+  draw   <table> <name>           offer the opponent a draw
+  accept <table> <name>           accept the opponent's draw offer
+  decline <table> <name>          decline the opponent's draw offer
+  claim  <table> <name>           win the game once the opponent has been disconnected for too long
+  delete <table>                  delete the table (host only)
+  kick   <table> <name>           remove a player or viewer for good (host only)
+  lock   <table>                  stop anyone else from taking a seat (host only)
+  unlock <table>                  let players take seats again (host only)
+  watch  <table>                  follow the table live
+  play   <table> <name>           follow the table live and play with the keyboard
+`
+
+func main() {
+	server := flag.String("server", defaultServer(), "Connect 4 API base URL (or set CONNECT4_SERVER)")
+	styleName := flag.String("style", "unicode", "board style: "+strings.Join(render.Names(), ", "))
+	hostToken := flag.String("host-token", os.Getenv("CONNECT4_HOST_TOKEN"), "host token of the table for host-only commands (or set CONNECT4_HOST_TOKEN)")
+	var join client.JoinOptions
+	flag.StringVar(&join.Symbol, "symbol", "", "symbol shown for your pieces when joining")
+	flag.StringVar(&join.Color, "color", "", "color of your pieces when joining: red, yellow, purple, teal, orange, pink, green or black")
+	flag.StringVar(&join.Avatar, "avatar", "", "URL of your picture when joining")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	style, err := render.Lookup(*styleName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "connect4:", err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, client.New(*server), style, *hostToken, join, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "connect4:", err)
+		os.Exit(1)
+	}
+}
+
+func defaultServer() string {
+	if server := os.Getenv("CONNECT4_SERVER"); server != "" {
+		return server
+	}
+	return "http://localhost:8080"
+}
+
+// run executes one command with its arguments
+func run(ctx context.Context, c *client.Client, style render.Style, hostToken string, join client.JoinOptions, command string, args []string) error {
+	arity := map[string]int{
+		"create": 0, "show": 1, "join": 2, "leave": 2, "start": 1, "ready": 2,
+		"match": 3, "drop": 3, "resign": 2, "draw": 2, "accept": 2, "decline": 2, "claim": 2,
+		"delete": 1, "kick": 2, "lock": 1, "unlock": 1, "watch": 1, "play": 2,
+	}
+	n, ok := arity[command]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+	}
+	if len(args) != n {
+		return fmt.Errorf("%s takes %d argument(s)\n\n%s", command, n, usage)
+	}
+
+	switch command {
+	case "create":
+		table, err := c.CreateTableWith(ctx, client.TableOptions{})
+		if err != nil {
+			return err
+		}
+		fmt.Println(table.ID)
+		fmt.Fprintln(os.Stderr, "host token:", table.HostToken)
+	case "show":
+		table, err := c.Table(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Print(render.Table(table, "", style))
+	case "join":
+		return c.JoinWith(ctx, args[0], args[1], join)
+	case "leave":
+		return c.Leave(ctx, args[0], args[1])
+	case "start":
+		return c.Start(ctx, args[0])
+	case "ready":
+		return c.Ready(ctx, args[0], args[1])
+	case "match":
+		games, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("invalid number of games %q", args[2])
+		}
+		if _, err := c.SetMatch(ctx, args[0], hostToken, args[1], games); err != nil {
+			return err
+		}
+		return c.Start(ctx, args[0])
+	case "drop":
+		column, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("invalid column %q", args[2])
+		}
+		return c.Drop(ctx, args[0], args[1], column-1)
+	case "resign":
+		return c.Resign(ctx, args[0], args[1])
+	case "draw":
+		return c.OfferDraw(ctx, args[0], args[1])
+	case "accept":
+		return c.AcceptDraw(ctx, args[0], args[1])
+	case "decline":
+		return c.DeclineDraw(ctx, args[0], args[1])
+	case "claim":
+		return c.ClaimAbandonment(ctx, args[0], args[1])
+	case "delete":
+		return c.DeleteTable(ctx, args[0], hostToken)
+	case "kick":
+		return c.Kick(ctx, args[0], args[1], hostToken)
+	case "lock":
+		return c.Lock(ctx, args[0], hostToken)
+	case "unlock":
+		return c.Unlock(ctx, args[0], hostToken)
+	case "watch":
+		return watch(ctx, c, style, args[0])
+	case "play":
+		return play(ctx, c, style, args[0], args[1])
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"blackjackapi/client"
+	"blackjackapi/render"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what f prints to standard output
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Error creating pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+	f()
+	w.Close()
+	return <-output
+}
+
+// TestRunArguments tests that commands are checked for their arguments before
+// anything is sent to the server
+func TestRunArguments(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected no request, got %s %s", r.Method, r.URL)
+	}))
+	defer ts.Close()
+	style, _ := render.Lookup("unicode")
+
+	for _, test := range []struct {
+		command string
+		args    []string
+		want    string
+	}{
+		{"fly", nil, `unknown command "fly"`},
+		{"join", []string{"table"}, "join takes 2 argument(s)"},
+		{"create", []string{"extra"}, "create takes 0 argument(s)"},
+		{"drop", []string{"table", "alice", "left"}, `invalid column "left"`},
+		{"match", []string{"table", "first_to", "three"}, `invalid number of games "three"`},
+	} {
+		err := run(context.Background(), client.New(ts.URL), style, "", client.JoinOptions{}, test.command, test.args)
+		if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+			t.Errorf("Expected %s %v to fail with %q, got %v", test.command, test.args, test.want, err)
+		}
+	}
+}
+
+// TestRunDrop tests that columns are given numbered from 1, as on the board
+func TestRunDrop(t *testing.T) {
+	var path string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+	defer ts.Close()
+	style, _ := render.Lookup("unicode")
+
+	if err := run(context.Background(), client.New(ts.URL), style, "", client.JoinOptions{}, "drop", []string{"table", "alice", "4"}); err != nil {
+		t.Fatalf("Error dropping: %v", err)
+	}
+	if path != "/table/alice/3/drop" {
+		t.Errorf("Expected column 4 to be sent as 3, got %s", path)
+	}
+}
@@ -0,0 +1,374 @@
+// Package config loads the settings of the Connect 4 server. Settings start
+// from their defaults and are overridden in turn by an optional YAML or TOML
+// file, by environment variables and by command line flags.
+//
+// A file is read from the path given with -config or CONFIG_FILE. Its keys
+// follow the field tags of Config:
+//
+//	listen: ":8080"
+//	redis_url: redis://localhost:6379/0
+//	event_bus: memory
+//	tables:
+//	  idle_timeout: 1h
+//	  disconnect_action: pause
+//	features:
+//	  analysis: false
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"blackjackapi/engine"
+	"blackjackapi/models"
+
+	"github.com/pelletier/go-toml"
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/yaml.v3"
+)
+
+// Event bus backends
+const (
+	EventBusMemory = "memory" // Events stay in the process, for a single server
+	EventBusKafka  = "kafka"  // Events go through Upstash Kafka, shared between servers
+)
+
+// DefaultShutdownTimeout is how long the server waits for requests in
+// flight when it shuts down
+const DefaultShutdownTimeout = 15 * time.Second
+
+// Config holds every setting of the server
+type Config struct {
+	// Listen is the address the HTTP server listens on
+	Listen string `yaml:"listen" toml:"listen"`
+	// ShutdownTimeout is how long the server waits for requests and moves
+	// in flight when it shuts down
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// ShutdownRetry is how long stream subscribers are told to wait before
+	// reconnecting when the server shuts down
+	ShutdownRetry Duration `yaml:"shutdown_retry" toml:"shutdown_retry"`
+	// RedisURL locates the Redis database holding the tables
+	RedisURL string `yaml:"redis_url" toml:"redis_url"`
+	// EventBus is EventBusMemory or EventBusKafka. Left empty it is Kafka
+	// when a Kafka URL is set and memory otherwise.
+	EventBus string `yaml:"event_bus" toml:"event_bus"`
+	Kafka    Kafka  `yaml:"kafka" toml:"kafka"`
+	// OpeningBook is a book file for the built-in AI, replacing the
+	// default book
+	OpeningBook string   `yaml:"opening_book" toml:"opening_book"`
+	Tables      Tables   `yaml:"tables" toml:"tables"`
+	Limits      Limits   `yaml:"limits" toml:"limits"`
+	Features    Features `yaml:"features" toml:"features"`
+}
+
+// Kafka holds the credentials of the Upstash Kafka REST API
+type Kafka struct {
+	URL      string `yaml:"url" toml:"url"`
+	Username string `yaml:"username" toml:"username"`
+	Password string `yaml:"password" toml:"password"`
+}
+
+// Tables holds the timeouts of tables and their players. Zero turns off
+// the timeout, except for JanitorInterval.
+type Tables struct {
+	TTL              Duration `yaml:"ttl" toml:"ttl"`
+	IdleTimeout      Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	AbandonedTimeout Duration `yaml:"abandoned_timeout" toml:"abandoned_timeout"`
+	JanitorInterval  Duration `yaml:"janitor_interval" toml:"janitor_interval"`
+	ReconnectGrace   Duration `yaml:"reconnect_grace" toml:"reconnect_grace"`
+	DisconnectAction string   `yaml:"disconnect_action" toml:"disconnect_action"`
+}
+
+// Limits bound the work a single request may ask for
+type Limits struct {
+	MaxAnalysisTime  Duration `yaml:"max_analysis_time" toml:"max_analysis_time"`
+	MaxAnalysisNodes int64    `yaml:"max_analysis_nodes" toml:"max_analysis_nodes"`
+}
+
+// Features turn parts of the server on and off
+type Features struct {
+	// Analysis serves the analysis and review endpoints
+	Analysis bool `yaml:"analysis" toml:"analysis"`
+	// OpeningBook lets the built-in AI play from an opening book
+	OpeningBook bool `yaml:"opening_book" toml:"opening_book"`
+}
+
+// Duration is a time.Duration written like "90s" or "1h30m"
+type Duration time.Duration
+
+// UnmarshalText reads a duration from a file
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText writes a duration as time.Duration does
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Default returns the settings used where nothing overrides them
+func Default() Config {
+	return Config{
+		Listen:          ":8080",
+		ShutdownTimeout: Duration(DefaultShutdownTimeout),
+		ShutdownRetry:   Duration(models.DefaultShutdownRetry),
+		Tables: Tables{
+			TTL:              Duration(models.DefaultSessionTTL),
+			IdleTimeout:      Duration(models.DefaultIdleTimeout),
+			AbandonedTimeout: Duration(models.DefaultAbandonedTimeout),
+			JanitorInterval:  Duration(models.DefaultJanitorInterval),
+			ReconnectGrace:   Duration(models.DefaultReconnectGrace),
+			DisconnectAction: models.DisconnectForfeit,
+		},
+		Limits: Limits{
+			MaxAnalysisTime:  Duration(engine.DefaultMaxAnalysisTime),
+			MaxAnalysisNodes: engine.DefaultMaxAnalysisNodes,
+		},
+		Features: Features{Analysis: true, OpeningBook: true},
+	}
+}
+
+// setting is one setting that can be given as a flag and an environment variable
+type setting struct {
+	flag, env, usage string
+	set              func(c *Config, value string) error
+	// boolean flags may be given without a value
+	boolean bool
+}
+
+// settings lists the settings that can be given as flags and environment
+// variables. The environment variables of older releases keep their names.
+var settings = []setting{
+	stringSetting("listen", "LISTEN_ADDR", "address to listen on", func(c *Config) *string { return &c.Listen }),
+	durationSetting("shutdown-timeout", "SHUTDOWN_TIMEOUT", "how long to wait for requests in flight when shutting down", func(c *Config) *Duration { return &c.ShutdownTimeout }),
+	durationSetting("shutdown-retry", "SHUTDOWN_RETRY", "how long streams are told to wait before reconnecting when shutting down", func(c *Config) *Duration { return &c.ShutdownRetry }),
+	stringSetting("redis", "REDIS", "Redis URL, such as redis://localhost:6379/0", func(c *Config) *string { return &c.RedisURL }),
+	stringSetting("event-bus", "EVENT_BUS", "event bus backend: memory or kafka", func(c *Config) *string { return &c.EventBus }),
+	stringSetting("kafka-url", "UPSTASH_KAFKA_REST_URL", "Upstash Kafka REST URL", func(c *Config) *string { return &c.Kafka.URL }),
+	stringSetting("kafka-username", "UPSTASH_KAFKA_REST_USERNAME", "Upstash Kafka REST username", func(c *Config) *string { return &c.Kafka.Username }),
+	stringSetting("kafka-password", "UPSTASH_KAFKA_REST_PASSWORD", "Upstash Kafka REST password", func(c *Config) *string { return &c.Kafka.Password }),
+	stringSetting("opening-book", "OPENING_BOOK", "opening book file for the built-in AI", func(c *Config) *string { return &c.OpeningBook }),
+	durationSetting("table-ttl", "TABLE_TTL", "how long Redis keeps an untouched table, 0 to keep tables", func(c *Config) *Duration { return &c.Tables.TTL }),
+	durationSetting("idle-timeout", "TABLE_IDLE_TIMEOUT", "close tables after this long without activity, 0 to keep them", func(c *Config) *Duration { return &c.Tables.IdleTimeout }),
+	durationSetting("abandoned-timeout", "TABLE_ABANDONED_TIMEOUT", "close tables after this long with nobody seated, 0 to keep them", func(c *Config) *Duration { return &c.Tables.AbandonedTimeout }),
+	durationSetting("janitor-interval", "TABLE_JANITOR_INTERVAL", "how often to look for tables to close", func(c *Config) *Duration { return &c.Tables.JanitorInterval }),
+	durationSetting("reconnect-grace", "TABLE_RECONNECT_GRACE", "how long a disconnected player has to come back, 0 to stop tracking", func(c *Config) *Duration { return &c.Tables.ReconnectGrace }),
+	stringSetting("disconnect-action", "TABLE_DISCONNECT_ACTION", "what happens once the grace runs out: forfeit or pause", func(c *Config) *string { return &c.Tables.DisconnectAction }),
+	durationSetting("max-analysis-time", "MAX_ANALYSIS_TIME", "longest search an analysis request may ask for", func(c *Config) *Duration { return &c.Limits.MaxAnalysisTime }),
+	intSetting("max-analysis-nodes", "MAX_ANALYSIS_NODES", "most nodes an analysis request may ask for", func(c *Config) *int64 { return &c.Limits.MaxAnalysisNodes }),
+	boolSetting("analysis", "FEATURE_ANALYSIS", "serve the analysis and review endpoints", func(c *Config) *bool { return &c.Features.Analysis }),
+	boolSetting("book", "FEATURE_OPENING_BOOK", "let the built-in AI play from an opening book", func(c *Config) *bool { return &c.Features.OpeningBook }),
+}
+
+// stringSetting is a setting taking any text
+func stringSetting(flag, env, usage string, field func(*Config) *string) setting {
+	set := func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+	return setting{flag: flag, env: env, usage: usage, set: set}
+}
+
+// durationSetting is a setting taking a duration such as "90s"
+func durationSetting(flag, env, usage string, field func(*Config) *Duration) setting {
+	set := func(c *Config, value string) error {
+		return field(c).UnmarshalText([]byte(value))
+	}
+	return setting{flag: flag, env: env, usage: usage, set: set}
+}
+
+// intSetting is a setting taking a whole number
+func intSetting(flag, env, usage string, field func(*Config) *int64) setting {
+	set := func(c *Config, value string) error {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("not a whole number")
+		}
+		*field(c) = n
+		return nil
+	}
+	return setting{flag: flag, env: env, usage: usage, set: set}
+}
+
+// boolSetting is a setting turned on by giving its flag alone
+func boolSetting(flag, env, usage string, field func(*Config) *bool) setting {
+	set := func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("not true or false")
+		}
+		*field(c) = b
+		return nil
+	}
+	return setting{flag: flag, env: env, usage: usage, set: set, boolean: true}
+}
+
+// Load reads the settings from the defaults, the file named by -config or
+// CONFIG_FILE, the environment as seen through lookupEnv and the command
+// line arguments, in that order, and validates them. Every problem found
+// is reported in the error.
+func Load(name string, args []string, lookupEnv func(string) (string, bool), output io.Writer) (*Config, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(output)
+	path := flags.String("config", "", "YAML or TOML file of settings (or set CONFIG_FILE)")
+	var given []func(*Config) error
+	for _, s := range settings {
+		s := s
+		usage := fmt.Sprintf("%s (or set %s)", s.usage, s.env)
+		register := flags.Func
+		if s.boolean {
+			register = flags.BoolFunc
+		}
+		register(s.flag, usage, func(value string) error {
+			given = append(given, func(c *Config) error {
+				if err := s.set(c, value); err != nil {
+					return fmt.Errorf("-%s: %v", s.flag, err)
+				}
+				return nil
+			})
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	config := Default()
+	if *path == "" {
+		*path, _ = lookupEnv("CONFIG_FILE")
+	}
+	if *path != "" {
+		if err := config.readFile(*path); err != nil {
+			return nil, err
+		}
+	}
+	var problems []error
+	for _, s := range settings {
+		if value, ok := lookupEnv(s.env); ok && value != "" {
+			if err := s.set(&config, value); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %v", s.env, err))
+			}
+		}
+	}
+	for _, set := range given {
+		if err := set(&config); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// readFile reads settings from a YAML or TOML file, told apart by its extension
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %v", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("config file %s: %v", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.Strict(true)
+		if err := decoder.Decode(c); err != nil {
+			return fmt.Errorf("config file %s: %v", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s: use a .yaml, .yml or .toml file", path)
+	}
+	return nil
+}
+
+// Validate checks every setting, settling the event bus when it was left
+// empty. Every problem found is reported in the error.
+func (c *Config) Validate() error {
+	var problems []error
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+		problem("listen: %q is not a host:port address", c.Listen)
+	}
+	if c.ShutdownTimeout <= 0 {
+		problem("shutdown_timeout: must be positive")
+	}
+	if c.ShutdownRetry <= 0 {
+		problem("shutdown_retry: must be positive")
+	}
+	if c.RedisURL == "" {
+		problem("redis_url: required, set REDIS or -redis")
+	} else if _, err := redis.ParseURL(c.RedisURL); err != nil {
+		problem("redis_url: %v", err)
+	}
+	if c.EventBus == "" {
+		c.EventBus = EventBusMemory
+		if c.Kafka.URL != "" {
+			c.EventBus = EventBusKafka
+		}
+	}
+	switch c.EventBus {
+	case EventBusMemory:
+	case EventBusKafka:
+		if c.Kafka.URL == "" || c.Kafka.Username == "" || c.Kafka.Password == "" {
+			problem("kafka: the kafka event bus needs a URL, username and password")
+		}
+	default:
+		problem("event_bus: %q is not %s or %s", c.EventBus, EventBusMemory, EventBusKafka)
+	}
+
+	timeouts := []struct {
+		name  string
+		value Duration
+	}{
+		{"tables.ttl", c.Tables.TTL},
+		{"tables.idle_timeout", c.Tables.IdleTimeout},
+		{"tables.abandoned_timeout", c.Tables.AbandonedTimeout},
+		{"tables.reconnect_grace", c.Tables.ReconnectGrace},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value < 0 {
+			problem("%s: must not be negative", timeout.name)
+		}
+	}
+	if c.Tables.JanitorInterval <= 0 {
+		problem("tables.janitor_interval: must be positive")
+	}
+	if err := models.ValidateDisconnectAction(c.Tables.DisconnectAction); err != nil {
+		problem("tables.disconnect_action: %v", err)
+	}
+	if c.Limits.MaxAnalysisTime <= 0 {
+		problem("limits.max_analysis_time: must be positive")
+	}
+	if c.Limits.MaxAnalysisNodes <= 0 {
+		problem("limits.max_analysis_nodes: must be positive")
+	}
+	return errors.Join(problems...)
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"blackjackapi/models"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env returns a lookup over the given environment variables
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+// writeFile writes a config file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Error writing %s: %v", name, err)
+	}
+	return path
+}
+
+// TestDefaults tests the settings used when only Redis is given
+func TestDefaults(t *testing.T) {
+	config, err := Load("server", nil, env(map[string]string{"REDIS": "redis://localhost:6379/0"}), io.Discard)
+	if err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+	if config.Listen != ":8080" || config.EventBus != EventBusMemory || !config.Features.Analysis {
+		t.Errorf("Expected the defaults, got %+v", config)
+	}
+	if time.Duration(config.Tables.IdleTimeout) != time.Hour {
+		t.Errorf("Expected an idle timeout of an hour, got %v", time.Duration(config.Tables.IdleTimeout))
+	}
+	if time.Duration(config.ShutdownTimeout) != DefaultShutdownTimeout {
+		t.Errorf("Expected a shutdown timeout of %v, got %v", DefaultShutdownTimeout, time.Duration(config.ShutdownTimeout))
+	}
+	if time.Duration(config.ShutdownRetry) != models.DefaultShutdownRetry {
+		t.Errorf("Expected a shutdown retry of %v, got %v", models.DefaultShutdownRetry, time.Duration(config.ShutdownRetry))
+	}
+}
+
+// TestPrecedence tests that the environment overrides the file and flags override both
+func TestPrecedence(t *testing.T) {
+	path := writeFile(t, "server.yaml", `
+listen: ":9000"
+redis_url: redis://file:6379/0
+tables:
+  idle_timeout: 5m
+  reconnect_grace: 10s
+shutdown_retry: 1s
+`)
+	vars := map[string]string{"CONFIG_FILE": path, "TABLE_IDLE_TIMEOUT": "2m", "LISTEN_ADDR": ":9001", "SHUTDOWN_RETRY": "5s"}
+	config, err := Load("server", []string{"-listen", ":9002", "-analysis=false", "-shutdown-retry", "7s"}, env(vars), io.Discard)
+	if err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+	if config.Listen != ":9002" {
+		t.Errorf("Expected the flag to win, got %s", config.Listen)
+	}
+	if time.Duration(config.Tables.IdleTimeout) != 2*time.Minute {
+		t.Errorf("Expected the environment to override the file, got %v", time.Duration(config.Tables.IdleTimeout))
+	}
+	if time.Duration(config.Tables.ReconnectGrace) != 10*time.Second || config.RedisURL != "redis://file:6379/0" {
+		t.Errorf("Expected settings only in the file to be kept, got %+v", config)
+	}
+	if config.Features.Analysis {
+		t.Errorf("Expected -analysis=false to turn analysis off")
+	}
+	if time.Duration(config.ShutdownRetry) != 7*time.Second {
+		t.Errorf("Expected the -shutdown-retry flag to win, got %v", time.Duration(config.ShutdownRetry))
+	}
+}
+
+// TestTOML tests reading a TOML file
+func TestTOML(t *testing.T) {
+	path := writeFile(t, "server.toml", `
+redis_url = "redis://localhost:6379/0"
+event_bus = "kafka"
+
+[kafka]
+url = "https://kafka.example.com"
+username = "user"
+password = "secret"
+
+[limits]
+max_analysis_time = "2s"
+`)
+	config, err := Load("server", []string{"-config", path}, env(nil), io.Discard)
+	if err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+	if config.EventBus != EventBusKafka || config.Kafka.Password != "secret" {
+		t.Errorf("Expected the Kafka event bus, got %+v", config)
+	}
+	if time.Duration(config.Limits.MaxAnalysisTime) != 2*time.Second {
+		t.Errorf("Expected a 2s analysis limit, got %v", time.Duration(config.Limits.MaxAnalysisTime))
+	}
+}
+
+// TestInvalid tests that every problem with the settings is reported
+func TestInvalid(t *testing.T) {
+	vars := map[string]string{"EVENT_BUS": "kafka", "TABLE_DISCONNECT_ACTION": "quit"}
+	_, err := Load("server", []string{"-listen", "8080", "-shutdown-timeout", "0s", "-shutdown-retry", "-1s"}, env(vars), io.Discard)
+	if err == nil {
+		t.Fatalf("Expected the config to be refused")
+	}
+	for _, want := range []string{"listen", "shutdown_timeout", "shutdown_retry", "redis_url", "kafka", "disconnect_action"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected a problem with %s, got\n%v", want, err)
+		}
+	}
+
+	path := writeFile(t, "server.yaml", "redis_url: redis://localhost:6379/0\nidle_timeout: 5m\n")
+	if _, err := Load("server", []string{"-config", path}, env(nil), io.Discard); err == nil {
+		t.Errorf("Expected an unknown key in the file to be refused")
+	}
+	if _, err := Load("server", []string{"-redis", "redis://localhost:6379/0", "-table-ttl", "soon"}, env(nil), io.Discard); err == nil {
+		t.Errorf("Expected an invalid duration to be refused")
+	}
+	if _, err := Load("server", []string{"-config", "server.ini"}, env(nil), io.Discard); err == nil {
+		t.Errorf("Expected an unknown file type to be refused")
+	}
+}
@@ -0,0 +1,49 @@
+package engine
+
+import "time"
+
+// Strength limits of the built-in AI
+const (
+	MinLevel = 1
+	MaxLevel = 5
+)
+
+// levelBudgets is the search done for a move at each level
+var levelBudgets = [MaxLevel + 1]Budget{
+	1: {MaxDepth: 1},
+	2: {MaxDepth: 3},
+	3: {MaxDepth: 6},
+	4: {MaxTime: 500 * time.Millisecond},
+	5: {MaxTime: 2 * time.Second},
+}
+
+// bookLevel is the lowest level that plays from the opening book
+const bookLevel = 3
+
+// AI is the built-in computer opponent
+type AI struct {
+	Level    int
+	Book     *Book
+	searcher *Searcher
+}
+
+// NewAI returns an AI of the given level, clamped to MinLevel-MaxLevel. A nil
+// book makes it search every move.
+func NewAI(level int, book *Book) *AI {
+	level = max(MinLevel, min(MaxLevel, level))
+	return &AI{Level: level, Book: book, searcher: NewSearcher(18)}
+}
+
+// Move chooses a column for the side to move. History lists the moves that
+// led to the position and is only used to play from the book, which applies
+// when the game began on an empty standard board.
+func (a *AI) Move(b *Board, history []int) int {
+	if a.Book != nil && a.Level >= bookLevel && b.width == BookWidth && b.height == BookHeight && len(history) == b.moves {
+		for _, column := range a.Book.Next(history) {
+			if b.CanPlay(column) {
+				return column
+			}
+		}
+	}
+	return a.searcher.Analyze(b, levelBudgets[a.Level]).BestMove
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"blackjackapi/models"
+	"math/rand"
+	"testing"
+)
+
+// benchmarkPositions collects mid-game positions from random games
+func benchmarkPositions() ([]*models.Session, []*Board) {
+	rng := rand.New(rand.NewSource(3))
+	var sessions []*models.Session
+	var boards []*Board
+	for len(sessions) < 256 {
+		randomGame(rng, func(s *models.Session, b *Board, symbol string) {
+			if b.Moves() == 20 && len(sessions) < 256 {
+				copied := *b
+				grid := make([][]string, len(s.Grid))
+				for i := range s.Grid {
+					grid[i] = append([]string(nil), s.Grid[i]...)
+				}
+				sessions = append(sessions, &models.Session{Grid: grid})
+				boards = append(boards, &copied)
+			}
+		})
+	}
+	return sessions, boards
+}
+
+// BenchmarkSessionCheckWin measures the grid scan used by models.Session
+func BenchmarkSessionCheckWin(b *testing.B) {
+	sessions, _ := benchmarkPositions()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sessions[i%len(sessions)].CheckWin(models.Player1Symbol)
+	}
+}
+
+// BenchmarkBoardIsWin measures the bitboard win check on the same positions
+func BenchmarkBoardIsWin(b *testing.B) {
+	_, boards := benchmarkPositions()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		boards[i%len(boards)].IsWin(X)
+	}
+}
+
+// BenchmarkBoardPlayUndo measures making and unmaking a move
+func BenchmarkBoardPlayUndo(b *testing.B) {
+	_, boards := benchmarkPositions()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		board := boards[i%len(boards)]
+		for column := 0; column < board.Width(); column++ {
+			if board.CanPlay(column) {
+				board.play(column)
+				board.Undo(column)
+				break
+			}
+		}
+	}
+}
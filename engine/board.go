@@ -0,0 +1,199 @@
+// Package engine is a fast Connect 4 game engine for bots and analysis.
+//
+// A Board keeps one uint64 mask of pieces per side plus the height of every
+// column. Column c owns bits c*(height+1) to c*(height+1)+height, bottom row
+// first; the extra bit on top of each column is always clear so lines cannot
+// wrap from one column into the next. That limits boards to
+// width*(height+1) <= 64, which includes the standard 7x6.
+package engine
+
+import (
+	"blackjackapi/models"
+	"errors"
+	"fmt"
+)
+
+// Sides of a game. X moves with the pieces of the player in the first seat.
+const (
+	X = 0
+	O = 1
+)
+
+// MaxWidth is the widest board a Board can hold
+const MaxWidth = 12
+
+// Board is a Connect 4 position
+type Board struct {
+	width, height int
+	pieces        [2]uint64
+	heights       [MaxWidth]int
+	toMove        int
+	moves         int
+	hash          uint64
+}
+
+// Symbols maps the sides to the symbols used in models.Session grids
+var Symbols = [2]string{models.Player1Symbol, models.Player2Symbol}
+
+// NewBoard returns an empty board with X to move
+func NewBoard(width, height int) (*Board, error) {
+	if width < 1 || height < 1 || width > MaxWidth || width*(height+1) > 64 {
+		return nil, fmt.Errorf("a %dx%d board does not fit in a bitboard", width, height)
+	}
+	return &Board{width: width, height: height}, nil
+}
+
+// FromSession converts the grid of a session into a board. The side to move
+// is taken from the session's turn.
+func FromSession(s *models.Session) (*Board, error) {
+	if len(s.Grid) == 0 {
+		return nil, errors.New("session has no grid")
+	}
+	b, err := NewBoard(len(s.Grid[0]), len(s.Grid))
+	if err != nil {
+		return nil, err
+	}
+	for c := 0; c < b.width; c++ {
+		// Rows of the grid count from the top, heights from the bottom
+		for r := b.height - 1; r >= 0; r-- {
+			slot := s.Grid[r][c]
+			if slot == models.EmptySlot {
+				for above := r - 1; above >= 0; above-- {
+					if s.Grid[above][c] != models.EmptySlot {
+						return nil, fmt.Errorf("piece in column %d is floating", c+1)
+					}
+				}
+				break
+			}
+			side := X
+			if slot == Symbols[O] {
+				side = O
+			} else if slot != Symbols[X] {
+				return nil, fmt.Errorf("unknown symbol %q", slot)
+			}
+			b.place(c, side)
+		}
+	}
+	b.toMove = O
+	if s.Turn%2 == 0 {
+		b.toMove = X
+	}
+	b.hash ^= b.sideKey()
+	return b, nil
+}
+
+// Grid converts the board into the grid of a models.Session
+func (b *Board) Grid() [][]string {
+	grid := make([][]string, b.height)
+	for r := range grid {
+		grid[r] = make([]string, b.width)
+		for c := range grid[r] {
+			grid[r][c] = models.EmptySlot
+			bit := b.bit(c, b.height-1-r)
+			if b.pieces[X]&bit != 0 {
+				grid[r][c] = Symbols[X]
+			} else if b.pieces[O]&bit != 0 {
+				grid[r][c] = Symbols[O]
+			}
+		}
+	}
+	return grid
+}
+
+// Width returns the number of columns
+func (b *Board) Width() int { return b.width }
+
+// Height returns the number of rows
+func (b *Board) Height() int { return b.height }
+
+// ToMove returns the side to move
+func (b *Board) ToMove() int { return b.toMove }
+
+// Moves returns the number of pieces on the board
+func (b *Board) Moves() int { return b.moves }
+
+// Hash returns the Zobrist hash of the position, including the side to move
+func (b *Board) Hash() uint64 { return b.hash }
+
+// ColumnHeight returns the number of pieces in a column
+func (b *Board) ColumnHeight(column int) int { return b.heights[column] }
+
+// CanPlay reports whether a piece can be dropped into column
+func (b *Board) CanPlay(column int) bool {
+	return column >= 0 && column < b.width && b.heights[column] < b.height
+}
+
+// Play drops a piece for the side to move into column
+func (b *Board) Play(column int) error {
+	if column < 0 || column >= b.width {
+		return errors.New("column index out of range")
+	}
+	if !b.CanPlay(column) {
+		return errors.New("no more slots available in the column")
+	}
+	b.play(column)
+	return nil
+}
+
+// play drops a piece without checking the column
+func (b *Board) play(column int) {
+	b.hash ^= b.sideKey()
+	b.place(column, b.toMove)
+	b.toMove ^= 1
+	b.hash ^= b.sideKey()
+}
+
+// Undo removes the top piece of column, which must have been the last move
+func (b *Board) Undo(column int) {
+	b.hash ^= b.sideKey()
+	b.toMove ^= 1
+	b.heights[column]--
+	b.moves--
+	bit := b.bit(column, b.heights[column])
+	b.pieces[b.toMove] &^= bit
+	b.hash ^= zobristKeys[b.toMove][bitIndex(bit)]
+	b.hash ^= b.sideKey()
+}
+
+func (b *Board) place(column, side int) {
+	bit := b.bit(column, b.heights[column])
+	b.pieces[side] |= bit
+	b.heights[column]++
+	b.moves++
+	b.hash ^= zobristKeys[side][bitIndex(bit)]
+}
+
+// bit returns the mask of one slot, rows counted from the bottom
+func (b *Board) bit(column, row int) uint64 {
+	return 1 << uint(column*(b.height+1)+row)
+}
+
+// IsWin reports whether side has four in a row
+func (b *Board) IsWin(side int) bool {
+	return hasFour(b.pieces[side], b.height)
+}
+
+// IsWinningMove reports whether dropping into column wins for the side to move
+func (b *Board) IsWinningMove(column int) bool {
+	if !b.CanPlay(column) {
+		return false
+	}
+	return hasFour(b.pieces[b.toMove]|b.bit(column, b.heights[column]), b.height)
+}
+
+// IsFull reports whether every slot is taken
+func (b *Board) IsFull() bool {
+	return b.moves == b.width*b.height
+}
+
+// hasFour checks the four directions of a mask with two shifts each
+func hasFour(m uint64, height int) bool {
+	// vertical, horizontal and both diagonals
+	for _, shift := range [4]uint{1, uint(height + 1), uint(height), uint(height + 2)} {
+		pairs := m & (m >> shift)
+		if pairs&(pairs>>(2*shift)) != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"blackjackapi/models"
+	"math/rand"
+	"testing"
+)
+
+// randomGame plays random moves on both a session and a board until the game ends,
+// calling check after every move
+func randomGame(rng *rand.Rand, check func(s *models.Session, b *Board, symbol string)) {
+	s := models.NewSession("random")
+	b, _ := NewBoard(models.DefaultWidth, models.DefaultHeight)
+	for !b.IsFull() {
+		column := rng.Intn(b.Width())
+		if !b.CanPlay(column) {
+			continue
+		}
+		symbol := Symbols[b.ToMove()]
+		s.DropPiece(column, symbol)
+		b.Play(column)
+		check(s, b, symbol)
+		if s.CheckWin(symbol) {
+			return
+		}
+	}
+}
+
+// TestWinMatchesCheckWin tests the bitboard against Session.CheckWin on random games
+func TestWinMatchesCheckWin(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		randomGame(rng, func(s *models.Session, b *Board, symbol string) {
+			side := X
+			if symbol == Symbols[O] {
+				side = O
+			}
+			if got, want := b.IsWin(side), s.CheckWin(symbol); got != want {
+				t.Fatalf("IsWin = %v, CheckWin = %v on\n%s", got, want, s.StringBoard())
+			}
+		})
+	}
+}
+
+// TestSessionRoundTrip tests conversion to and from the JSON grid
+func TestSessionRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 200; i++ {
+		randomGame(rng, func(s *models.Session, b *Board, symbol string) {
+			s.Turn = b.ToMove()
+			converted, err := FromSession(s)
+			if err != nil {
+				t.Fatalf("Error converting session: %v", err)
+			}
+			if *converted != *b {
+				t.Fatalf("Expected the converted board to equal the played board on\n%s", s.StringBoard())
+			}
+			for r, row := range b.Grid() {
+				for c, slot := range row {
+					if slot != s.Grid[r][c] {
+						t.Fatalf("Grid mismatch at row %d column %d", r, c)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestHash tests that the incremental hash depends only on the position
+func TestHash(t *testing.T) {
+	a, _ := NewBoard(7, 6)
+	b, _ := NewBoard(7, 6)
+	for _, column := range []int{3, 4, 2} {
+		a.Play(column)
+	}
+	for _, column := range []int{2, 4, 3} {
+		b.Play(column)
+	}
+	if a.Hash() != b.Hash() {
+		t.Errorf("Expected transposed move orders to hash the same")
+	}
+	before := a.Hash()
+	a.Play(0)
+	if a.Hash() == before {
+		t.Errorf("Expected a move to change the hash")
+	}
+	a.Undo(0)
+	if a.Hash() != before {
+		t.Errorf("Expected undo to restore the hash")
+	}
+}
+
+// TestInvalidBoards tests sizes and grids that cannot be converted
+func TestInvalidBoards(t *testing.T) {
+	if _, err := NewBoard(8, 8); err == nil {
+		t.Errorf("Expected an 8x8 board to be rejected")
+	}
+	s := models.NewSession("floating")
+	s.Grid[2][0] = models.Player1Symbol
+	if _, err := FromSession(s); err == nil {
+		t.Errorf("Expected a floating piece to be rejected")
+	}
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Book boards are the standard size
+const (
+	BookWidth  = 7
+	BookHeight = 6
+)
+
+//go:embed openings.book
+var defaultBook string
+
+// Opening is a named line of an opening book
+type Opening struct {
+	Name string `json:"name"`
+	// Moves are zero-based columns
+	Moves []int `json:"moves"`
+}
+
+// Book is an opening book of strong sequences on an empty 7x6 board, kept in
+// file order
+type Book struct {
+	lines []Opening
+}
+
+// DefaultBook returns the book built into the engine
+func DefaultBook() *Book {
+	book, err := ParseBook(strings.NewReader(defaultBook))
+	if err != nil {
+		panic(fmt.Sprintf("engine: built-in opening book: %v", err))
+	}
+	return book
+}
+
+// LoadBook reads an opening book from a file
+func LoadBook(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseBook(f)
+}
+
+// ParseBook reads an opening book. Each line holds a sequence of one-based
+// columns optionally followed by a name; blank lines and lines starting with
+// # are skipped. Every sequence must be playable on an empty board.
+func ParseBook(r io.Reader) (*Book, error) {
+	book := &Book{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sequence, name, _ := strings.Cut(line, " ")
+		b, _ := NewBoard(BookWidth, BookHeight)
+		opening := Opening{Name: strings.TrimSpace(name)}
+		for _, ch := range sequence {
+			column := int(ch - '1')
+			if ch < '1' || column >= BookWidth {
+				return nil, fmt.Errorf("line %d: %q is not a column between 1 and %d", n, ch, BookWidth)
+			}
+			if b.IsWin(X) || b.IsWin(O) || b.Play(column) != nil {
+				return nil, fmt.Errorf("line %d: %s cannot be played", n, sequence)
+			}
+			opening.Moves = append(opening.Moves, column)
+		}
+		book.lines = append(book.lines, opening)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// Openings returns the named lines of the book
+func (b *Book) Openings() []Opening {
+	openings := []Opening{}
+	for _, line := range b.lines {
+		if line.Name != "" {
+			openings = append(openings, line)
+		}
+	}
+	return openings
+}
+
+// Name returns the longest named line the moves begin with
+func (b *Book) Name(moves []int) (Opening, bool) {
+	var found Opening
+	for _, line := range b.lines {
+		if line.Name != "" && len(line.Moves) > len(found.Moves) && hasPrefix(moves, line.Moves) {
+			found = line
+		}
+	}
+	return found, found.Name != ""
+}
+
+// Contains reports whether the moves follow a line of the book
+func (b *Book) Contains(moves []int) bool {
+	if len(moves) == 0 {
+		return true
+	}
+	for _, line := range b.lines {
+		if hasPrefix(line.Moves, moves) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the book continuations after the moves, best first
+func (b *Book) Next(moves []int) []int {
+	next := []int{}
+	seen := map[int]bool{}
+	for _, line := range b.lines {
+		if len(line.Moves) > len(moves) && hasPrefix(line.Moves, moves) && !seen[line.Moves[len(moves)]] {
+			seen[line.Moves[len(moves)]] = true
+			next = append(next, line.Moves[len(moves)])
+		}
+	}
+	return next
+}
+
+// hasPrefix reports whether moves begins with prefix
+func hasPrefix(moves, prefix []int) bool {
+	if len(prefix) > len(moves) {
+		return false
+	}
+	for i := range prefix {
+		if moves[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
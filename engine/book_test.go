@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDefaultBook tests that the built-in book loads and names the center opening
+func TestDefaultBook(t *testing.T) {
+	book := DefaultBook()
+	if len(book.Openings()) == 0 {
+		t.Fatalf("Expected the built-in book to have named openings")
+	}
+	if next := book.Next(nil); len(next) == 0 || next[0] != 3 {
+		t.Errorf("Expected the book to open in the center column, got %v", next)
+	}
+	opening, ok := book.Name([]int{3, 3, 0, 6})
+	if !ok || opening.Name != "Center, Stacked" {
+		t.Errorf("Expected the moves to be named Center, Stacked, got %+v", opening)
+	}
+}
+
+// TestParseBook tests book lines, names and continuations
+func TestParseBook(t *testing.T) {
+	book, err := ParseBook(strings.NewReader("# comment\n\n4 Center\n44\n43 Flank\n"))
+	if err != nil {
+		t.Fatalf("Error parsing book: %v", err)
+	}
+	if openings := book.Openings(); len(openings) != 2 {
+		t.Errorf("Expected 2 named openings, got %+v", openings)
+	}
+	if next := book.Next([]int{3}); len(next) != 2 || next[0] != 3 || next[1] != 2 {
+		t.Errorf("Expected continuations [3 2], got %v", next)
+	}
+	if opening, ok := book.Name([]int{3, 3}); !ok || opening.Name != "Center" {
+		t.Errorf("Expected an unnamed line to keep the name Center, got %+v", opening)
+	}
+	if !book.Contains([]int{3, 2}) || book.Contains([]int{3, 1}) {
+		t.Errorf("Expected only the moves of a line to be in the book")
+	}
+	for _, text := range []string{"8 Off the board", "4x Letters", "1111111 Overflow", "12121213 After a win"} {
+		if _, err := ParseBook(strings.NewReader(text)); err == nil {
+			t.Errorf("Expected an error for %q", text)
+		}
+	}
+}
+
+// TestAIPlaysBook tests that the AI follows the book and searches outside it
+func TestAIPlaysBook(t *testing.T) {
+	book, err := ParseBook(strings.NewReader("1 Edge\n"))
+	if err != nil {
+		t.Fatalf("Error parsing book: %v", err)
+	}
+	b := boardFromMoves(t, 7, 6)
+	if column := NewAI(MaxLevel, book).Move(b, nil); column != 0 {
+		t.Errorf("Expected the AI to play the book move 0, got %d", column)
+	}
+	if column := NewAI(MinLevel, book).Move(b, nil); column == 0 {
+		t.Errorf("Expected the weakest AI to search rather than play the book")
+	}
+	// X threatens to complete column 0 and O is out of book
+	b = boardFromMoves(t, 7, 6, 0, 1, 0, 1, 0)
+	if column := NewAI(3, book).Move(b, []int{0, 1, 0, 1, 0}); column != 0 {
+		t.Errorf("Expected the AI to block column 0, got %d", column)
+	}
+}
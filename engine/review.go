@@ -0,0 +1,112 @@
+package engine
+
+import "errors"
+
+// Labels given to moves by Review
+const (
+	LabelBest       = "best"
+	LabelGood       = "good"
+	LabelInaccuracy = "inaccuracy"
+	LabelMistake    = "mistake"
+	LabelBlunder    = "blunder"
+	LabelMissedWin  = "missed win"
+)
+
+// Heuristic score drops separating the labels of moves whose outcome is unknown
+const (
+	goodLoss       = 8
+	inaccuracyLoss = 32
+	mistakeLoss    = 96
+)
+
+// labelAccuracy is the accuracy credited for each label, in percent
+var labelAccuracy = map[string]float64{
+	LabelBest:       100,
+	LabelGood:       85,
+	LabelInaccuracy: 60,
+	LabelMistake:    30,
+	LabelBlunder:    0,
+	LabelMissedWin:  0,
+}
+
+// MoveReview is the verdict on one move of a game
+type MoveReview struct {
+	Number   int        `json:"number"`
+	Symbol   string     `json:"symbol"`
+	Column   int        `json:"column"`
+	Label    string     `json:"label"`
+	BestMove int        `json:"best_move"`
+	Played   Evaluation `json:"played"`
+	Best     Evaluation `json:"best"`
+}
+
+// Review is an annotated game
+type Review struct {
+	Moves []MoveReview `json:"moves"`
+	// Accuracy is the average accuracy of each symbol's moves, in percent
+	Accuracy map[string]float64 `json:"accuracy"`
+}
+
+// Review replays moves from start, analyzing the position before each one
+// with the given budget and labelling the move against the best available.
+func (s *Searcher) Review(start *Board, moves []int, budget Budget) (Review, error) {
+	b := *start
+	review := Review{Moves: []MoveReview{}, Accuracy: map[string]float64{}}
+	counts := map[string]int{}
+	for i, column := range moves {
+		if b.IsWin(X) || b.IsWin(O) {
+			return Review{}, errors.New("moves continue after the game was won")
+		}
+		if !b.CanPlay(column) {
+			return Review{}, errors.New("moves do not fit the board")
+		}
+		analysis := s.Analyze(&b, budget)
+		played := analysis.Evaluation(column)
+		best := analysis.Evaluation(analysis.BestMove)
+		move := MoveReview{
+			Number:   i + 1,
+			Symbol:   Symbols[b.toMove],
+			Column:   column,
+			Label:    label(*played, *best),
+			BestMove: analysis.BestMove,
+			Played:   *played,
+			Best:     *best,
+		}
+		review.Moves = append(review.Moves, move)
+		review.Accuracy[move.Symbol] += labelAccuracy[move.Label]
+		counts[move.Symbol]++
+		b.play(column)
+	}
+	for symbol, count := range counts {
+		review.Accuracy[symbol] /= float64(count)
+	}
+	return review, nil
+}
+
+// label compares the evaluation of the played column to the best one
+func label(played, best Evaluation) string {
+	if played.Score >= best.Score {
+		return LabelBest
+	}
+	switch {
+	case best.Outcome == OutcomeWin && played.Outcome != OutcomeWin:
+		return LabelMissedWin
+	case best.Outcome == OutcomeWin:
+		// Still winning, only more slowly
+		return LabelGood
+	case played.Outcome == OutcomeLoss && best.Outcome != OutcomeLoss:
+		return LabelBlunder
+	case played.Outcome == OutcomeLoss:
+		// Every move loses, this one sooner
+		return LabelInaccuracy
+	}
+	switch loss := best.Score - played.Score; {
+	case loss <= goodLoss:
+		return LabelGood
+	case loss <= inaccuracyLoss:
+		return LabelInaccuracy
+	case loss <= mistakeLoss:
+		return LabelMistake
+	}
+	return LabelBlunder
+}
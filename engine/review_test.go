@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+// TestReviewMissedWin tests that passing up a win in one is labelled and costs accuracy
+func TestReviewMissedWin(t *testing.T) {
+	start := boardFromMoves(t, 7, 6)
+	// X ignores the win in column 0 and O takes column 1
+	moves := []int{0, 1, 0, 1, 0, 1, 3, 1}
+	review, err := NewSearcher(16).Review(start, moves, Budget{MaxDepth: 4, MaxTime: time.Second})
+	if err != nil {
+		t.Fatalf("Error reviewing game: %v", err)
+	}
+	if len(review.Moves) != len(moves) {
+		t.Fatalf("Expected %d reviewed moves, got %d", len(moves), len(review.Moves))
+	}
+	if move := review.Moves[6]; move.Label != LabelMissedWin || move.BestMove != 0 || move.Symbol != Symbols[X] {
+		t.Errorf("Expected move 7 to be a missed win in column 0, got %+v", move)
+	}
+	if move := review.Moves[7]; move.Label != LabelBest {
+		t.Errorf("Expected the winning move to be best, got %s", move.Label)
+	}
+	if review.Accuracy[Symbols[X]] >= review.Accuracy[Symbols[O]] {
+		t.Errorf("Expected X to be less accurate than O, got %v", review.Accuracy)
+	}
+}
+
+// TestReviewInvalidMoves tests that moves after a win are rejected
+func TestReviewInvalidMoves(t *testing.T) {
+	start := boardFromMoves(t, 7, 6)
+	moves := []int{0, 1, 0, 1, 0, 1, 0, 1}
+	if _, err := NewSearcher(16).Review(start, moves, Budget{MaxDepth: 2}); err == nil {
+		t.Errorf("Expected an error for moves after the game was won")
+	}
+}
+
+// TestLabel tests the labels given for losses of outcome and score
+func TestLabel(t *testing.T) {
+	win := Evaluation{Outcome: OutcomeWin, Score: WinScore + 30}
+	slowerWin := Evaluation{Outcome: OutcomeWin, Score: WinScore + 20}
+	loss := Evaluation{Outcome: OutcomeLoss, Score: -WinScore - 30}
+	even := Evaluation{Outcome: OutcomeUnknown, Score: 0}
+	tests := []struct {
+		played, best Evaluation
+		expected     string
+	}{
+		{win, win, LabelBest},
+		{slowerWin, win, LabelGood},
+		{even, win, LabelMissedWin},
+		{loss, even, LabelBlunder},
+		{Evaluation{Score: -4}, even, LabelGood},
+		{Evaluation{Score: -20}, even, LabelInaccuracy},
+		{Evaluation{Score: -60}, even, LabelMistake},
+		{Evaluation{Score: -200}, even, LabelBlunder},
+	}
+	for _, test := range tests {
+		if got := label(test.played, test.best); got != test.expected {
+			t.Errorf("Expected %s for %+v against %+v, got %s", test.expected, test.played, test.best, got)
+		}
+	}
+}
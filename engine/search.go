@@ -0,0 +1,451 @@
+package engine
+
+import (
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// Scores are from the point of view of the side to move. A forced win scores
+// above WinScore and a forced loss below -WinScore; the further the win is
+// from the end of the board, the larger the score. Because the distance is
+// measured from the end of the board rather than from the root of a search,
+// the score of a position never depends on how it was reached and can be
+// shared through the transposition table. Heuristic scores stay well inside
+// (-WinScore, WinScore).
+const (
+	WinScore = 1000000
+	infinity = 2 * WinScore
+)
+
+// Outcomes of a move
+const (
+	OutcomeWin     = "win"
+	OutcomeLoss    = "loss"
+	OutcomeDraw    = "draw"
+	OutcomeUnknown = "unknown"
+)
+
+// Budget bounds a search. Zero fields are unlimited, but at least one of
+// MaxNodes and MaxTime should be set for positions far from the end.
+type Budget struct {
+	MaxNodes int64
+	MaxTime  time.Duration
+	MaxDepth int
+}
+
+// Defaults for the most a server lets a single analysis request search
+const (
+	DefaultMaxAnalysisTime  = 10 * time.Second
+	DefaultMaxAnalysisNodes = 200000000
+)
+
+// Evaluation is the value of dropping into one column
+type Evaluation struct {
+	Column  int    `json:"column"`
+	Outcome string `json:"outcome"`
+	// Moves counts the moves of both sides until the game ends, including
+	// this one, when the outcome is known
+	Moves int `json:"moves,omitempty"`
+	Score int `json:"score"`
+}
+
+// Analysis is the result of searching a position
+type Analysis struct {
+	ToMove             string       `json:"to_move"`
+	Evaluations        []Evaluation `json:"evaluations"`
+	BestMove           int          `json:"best_move"`
+	PrincipalVariation []int        `json:"principal_variation"`
+	Depth              int          `json:"depth"`
+	Nodes              int64        `json:"nodes"`
+	Solved             bool         `json:"solved"`
+	ElapsedMS          int64        `json:"elapsed_ms"`
+}
+
+// Evaluation returns the evaluation of a column, or nil if it cannot be played
+func (a Analysis) Evaluation(column int) *Evaluation {
+	for i := range a.Evaluations {
+		if a.Evaluations[i].Column == column {
+			return &a.Evaluations[i]
+		}
+	}
+	return nil
+}
+
+// Transposition table bounds
+const (
+	boundExact = iota + 1
+	boundLower
+	boundUpper
+)
+
+type ttEntry struct {
+	key   uint64
+	score int32
+	depth int8
+	bound uint8
+	best  int8
+	age   uint8
+}
+
+// Searcher runs iterative-deepening negamax searches with a transposition
+// table that is kept between searches. A Searcher is not safe for
+// concurrent use.
+type Searcher struct {
+	table []ttEntry
+	mask  uint64
+	age   uint8
+
+	nodes    int64
+	budget   Budget
+	deadline time.Time
+	aborted  bool
+	// windows are the lines of four of the board being searched, looked
+	// up once per search rather than at every leaf
+	windows []uint64
+}
+
+// NewSearcher returns a searcher with a transposition table of 2^tableBits entries
+func NewSearcher(tableBits int) *Searcher {
+	return &Searcher{
+		table: make([]ttEntry, 1<<tableBits),
+		mask:  1<<tableBits - 1,
+	}
+}
+
+// Analyze searches a position with a fresh searcher
+func Analyze(b *Board, budget Budget) Analysis {
+	return NewSearcher(20).Analyze(b, budget)
+}
+
+// Analyze evaluates every playable column of the position by iterative
+// deepening until every outcome is known or the budget runs out. The
+// evaluations of the last completed depth are returned.
+func (s *Searcher) Analyze(root *Board, budget Budget) Analysis {
+	start := time.Now()
+	s.nodes = 0
+	s.budget = budget
+	s.aborted = false
+	s.deadline = time.Time{}
+	s.age++
+	s.windows = windows(root.width, root.height)
+	if budget.MaxTime > 0 {
+		s.deadline = start.Add(budget.MaxTime)
+	}
+
+	b := *root
+	analysis := Analysis{ToMove: Symbols[b.toMove], BestMove: -1, Evaluations: []Evaluation{}}
+	if b.IsWin(X) || b.IsWin(O) || b.IsFull() {
+		analysis.Solved = true
+		return analysis
+	}
+
+	remaining := b.width*b.height - b.moves
+	maxDepth := remaining
+	if budget.MaxDepth > 0 && budget.MaxDepth < maxDepth {
+		maxDepth = budget.MaxDepth
+	}
+	for depth := 1; depth <= maxDepth; depth++ {
+		evaluations, solved, ok := s.searchRoot(&b, depth)
+		if !ok {
+			break
+		}
+		analysis.Evaluations = evaluations
+		analysis.Depth = depth
+		analysis.Solved = solved
+		if solved {
+			break
+		}
+	}
+	if len(analysis.Evaluations) == 0 {
+		// Not even depth one finished, so fall back to the static evaluation
+		s.aborted = false
+		for _, column := range b.order() {
+			if b.CanPlay(column) {
+				analysis.Evaluations = append(analysis.Evaluations, b.describe(column, -b.afterMove(column).heuristic(s.windows), 0))
+			}
+		}
+	}
+
+	best := analysis.Evaluations[0]
+	for _, evaluation := range analysis.Evaluations[1:] {
+		if evaluation.Score > best.Score {
+			best = evaluation
+		}
+	}
+	analysis.BestMove = best.Column
+	analysis.PrincipalVariation = s.principalVariation(&b, best.Column, analysis.Depth)
+	analysis.Nodes = s.nodes
+	analysis.ElapsedMS = time.Since(start).Milliseconds()
+	return analysis
+}
+
+// searchRoot searches every column with a full window so each gets an exact
+// value. It reports false if the budget ran out before it finished.
+func (s *Searcher) searchRoot(b *Board, depth int) ([]Evaluation, bool, bool) {
+	var evaluations []Evaluation
+	solved := true
+	for _, column := range b.order() {
+		if !b.CanPlay(column) {
+			continue
+		}
+		var score int
+		if b.IsWinningMove(column) {
+			score = b.winScore()
+		} else {
+			b.play(column)
+			score = -s.negamax(b, depth-1, -infinity, infinity)
+			b.Undo(column)
+		}
+		if s.aborted {
+			return nil, false, false
+		}
+		evaluation := b.describe(column, score, depth)
+		if evaluation.Outcome == OutcomeUnknown {
+			solved = false
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	return evaluations, solved, true
+}
+
+// describe turns the score of dropping into column, found with a search of
+// depth plies, into an evaluation
+func (b *Board) describe(column, score, depth int) Evaluation {
+	evaluation := Evaluation{Column: column, Score: score, Outcome: OutcomeUnknown}
+	size := b.width * b.height
+	switch {
+	case score > WinScore:
+		// The winning piece is dropped on top of size-(score-WinScore) pieces
+		evaluation.Outcome = OutcomeWin
+		evaluation.Moves = size - (score - WinScore) + 1 - b.moves
+	case score < -WinScore:
+		evaluation.Outcome = OutcomeLoss
+		evaluation.Moves = size - (-score - WinScore) + 1 - b.moves
+	case score == 0 && depth >= size-b.moves:
+		evaluation.Outcome = OutcomeDraw
+		evaluation.Moves = size - b.moves
+	}
+	return evaluation
+}
+
+// negamax returns the score of the position for the side to move
+func (s *Searcher) negamax(b *Board, depth, alpha, beta int) int {
+	s.nodes++
+	if s.outOfBudget() {
+		return 0
+	}
+	if b.IsFull() {
+		return 0
+	}
+	for column := 0; column < b.width; column++ {
+		if b.IsWinningMove(column) {
+			return b.winScore()
+		}
+	}
+	if depth == 0 {
+		return b.heuristic(s.windows)
+	}
+
+	originalAlpha := alpha
+	entry := &s.table[b.hash&s.mask]
+	best := -1
+	if entry.key == b.hash && entry.bound != 0 {
+		best = int(entry.best)
+		if int(entry.depth) >= depth {
+			score := int(entry.score)
+			switch {
+			case entry.bound == boundExact:
+				return score
+			case entry.bound == boundLower && score >= beta:
+				return score
+			case entry.bound == boundUpper && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	value := -infinity
+	bestColumn := -1
+	try := func(column int) bool {
+		b.play(column)
+		score := -s.negamax(b, depth-1, -beta, -alpha)
+		b.Undo(column)
+		if s.aborted {
+			return false
+		}
+		if score > value {
+			value = score
+			bestColumn = column
+		}
+		if value > alpha {
+			alpha = value
+		}
+		return alpha < beta
+	}
+	if best >= 0 && b.CanPlay(best) {
+		if !try(best) {
+			return s.store(b, depth, value, originalAlpha, beta, bestColumn)
+		}
+	}
+	for _, column := range b.order() {
+		if column == best || !b.CanPlay(column) {
+			continue
+		}
+		if !try(column) {
+			break
+		}
+	}
+	return s.store(b, depth, value, originalAlpha, beta, bestColumn)
+}
+
+// store records a search result in the transposition table and returns it
+func (s *Searcher) store(b *Board, depth, value, alpha, beta, best int) int {
+	if s.aborted || best < 0 {
+		return value
+	}
+	bound := uint8(boundExact)
+	if value <= alpha {
+		bound = boundUpper
+	} else if value >= beta {
+		bound = boundLower
+	}
+	// Prefer keeping deep results of the current search, which the principal variation is read from
+	entry := &s.table[b.hash&s.mask]
+	if entry.key == b.hash || entry.age != s.age || depth >= int(entry.depth) {
+		*entry = ttEntry{key: b.hash, score: int32(value), depth: int8(depth), bound: bound, best: int8(best), age: s.age}
+	}
+	return value
+}
+
+func (s *Searcher) outOfBudget() bool {
+	if s.aborted {
+		return true
+	}
+	if s.budget.MaxNodes > 0 && s.nodes > s.budget.MaxNodes {
+		s.aborted = true
+	} else if !s.deadline.IsZero() && s.nodes&1023 == 0 && time.Now().After(s.deadline) {
+		s.aborted = true
+	}
+	return s.aborted
+}
+
+// principalVariation follows the best moves stored in the transposition table
+func (s *Searcher) principalVariation(root *Board, first, depth int) []int {
+	b := *root
+	line := []int{first}
+	won := b.IsWinningMove(first)
+	b.play(first)
+	for len(line) < depth && !won && !b.IsFull() {
+		next := -1
+		for column := 0; column < b.width; column++ {
+			if b.IsWinningMove(column) {
+				next = column
+				break
+			}
+		}
+		if next < 0 {
+			entry := s.table[b.hash&s.mask]
+			if entry.key != b.hash || entry.bound == 0 || !b.CanPlay(int(entry.best)) {
+				break
+			}
+			next = int(entry.best)
+		}
+		won = b.IsWinningMove(next)
+		b.play(next)
+		line = append(line, next)
+	}
+	return line
+}
+
+// winScore is the score of the side to move winning with its next piece
+func (b *Board) winScore() int {
+	return WinScore + b.width*b.height - b.moves
+}
+
+// order returns the columns from the center outwards, where moves tend to be strongest
+func (b *Board) order() []int {
+	columns := make([]int, 0, b.width)
+	center := (b.width - 1) / 2
+	columns = append(columns, center)
+	for offset := 1; len(columns) < b.width; offset++ {
+		if b.width%2 == 0 && center+offset < b.width {
+			columns = append(columns, center+offset)
+			if center-offset >= 0 {
+				columns = append(columns, center-offset)
+			}
+			continue
+		}
+		if center-offset >= 0 {
+			columns = append(columns, center-offset)
+		}
+		if center+offset < b.width {
+			columns = append(columns, center+offset)
+		}
+	}
+	return columns
+}
+
+// afterMove returns a copy of the board with a piece dropped into column
+func (b *Board) afterMove(column int) *Board {
+	next := *b
+	next.play(column)
+	return &next
+}
+
+// windowWeights scores a line of four holding only one side's pieces
+var windowWeights = [4]int{0, 1, 8, 64}
+
+// heuristic scores the position for the side to move by the open lines of four
+// each side still has, favouring lines that are closer to complete. windows
+// are the lines of four of the board's size.
+func (b *Board) heuristic(windows []uint64) int {
+	mine, theirs := b.pieces[b.toMove], b.pieces[b.toMove^1]
+	score := 0
+	for _, window := range windows {
+		m, t := mine&window, theirs&window
+		if t == 0 && m != 0 {
+			score += windowWeights[bits.OnesCount64(m)]
+		} else if m == 0 && t != 0 {
+			score -= windowWeights[bits.OnesCount64(t)]
+		}
+	}
+	return score
+}
+
+// windowCache holds the lines of four of every board size seen
+var (
+	windowCache   = map[[2]int][]uint64{}
+	windowCacheMu sync.Mutex
+)
+
+// windows returns the masks of every line of four on a board of the given size
+func windows(width, height int) []uint64 {
+	windowCacheMu.Lock()
+	defer windowCacheMu.Unlock()
+	if cached, ok := windowCache[[2]int{width, height}]; ok {
+		return cached
+	}
+	b := Board{width: width, height: height}
+	var masks []uint64
+	for c := 0; c < width; c++ {
+		for r := 0; r < height; r++ {
+			for _, d := range [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}} {
+				var mask uint64
+				n := 0
+				for ; n < 4; n++ {
+					cc, rr := c+d[0]*n, r+d[1]*n
+					if cc >= width || rr < 0 || rr >= height {
+						break
+					}
+					mask |= b.bit(cc, rr)
+				}
+				if n == 4 {
+					masks = append(masks, mask)
+				}
+			}
+		}
+	}
+	windowCache[[2]int{width, height}] = masks
+	return masks
+}
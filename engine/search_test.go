@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+// boardFromMoves plays zero-based columns on an empty board
+func boardFromMoves(t *testing.T, width, height int, moves ...int) *Board {
+	t.Helper()
+	b, err := NewBoard(width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, column := range moves {
+		if err := b.Play(column); err != nil {
+			t.Fatalf("Error playing column %d: %v", column, err)
+		}
+	}
+	return b
+}
+
+// TestImmediateWin tests that a win in one is found and reported
+func TestImmediateWin(t *testing.T) {
+	// X has three in column 0 and O three in column 1
+	b := boardFromMoves(t, 7, 6, 0, 1, 0, 1, 0, 1)
+	analysis := Analyze(b, Budget{MaxDepth: 4, MaxTime: time.Second})
+	if analysis.BestMove != 0 {
+		t.Errorf("Expected the best move to be column 0, got %d", analysis.BestMove)
+	}
+	if e := analysis.Evaluation(0); e == nil || e.Outcome != OutcomeWin || e.Moves != 1 {
+		t.Errorf("Expected column 0 to win in 1 move, got %+v", e)
+	}
+	// Any other move lets O win next
+	if e := analysis.Evaluation(3); e == nil || e.Outcome != OutcomeLoss || e.Moves != 2 {
+		t.Errorf("Expected column 3 to lose in 2 moves, got %+v", e)
+	}
+}
+
+// TestForcedWin tests that a win needing a threat sequence is found
+func TestForcedWin(t *testing.T) {
+	// X holds columns 2 and 3 on the bottom row with both ends open
+	b := boardFromMoves(t, 7, 6, 2, 2, 3, 3)
+	analysis := Analyze(b, Budget{MaxDepth: 7, MaxTime: 5 * time.Second})
+	for _, column := range []int{1, 4} {
+		if e := analysis.Evaluation(column); e == nil || e.Outcome != OutcomeWin || e.Moves != 3 {
+			t.Errorf("Expected column %d to win in 3 moves, got %+v", column, e)
+		}
+	}
+	if len(analysis.PrincipalVariation) != 3 {
+		t.Errorf("Expected a principal variation of 3 moves, got %v", analysis.PrincipalVariation)
+	}
+}
+
+// TestSolveSmallBoard tests solving a board to the end
+func TestSolveSmallBoard(t *testing.T) {
+	b := boardFromMoves(t, 4, 4)
+	analysis := Analyze(b, Budget{})
+	if !analysis.Solved {
+		t.Fatalf("Expected the 4x4 board to be solved, reached depth %d", analysis.Depth)
+	}
+	for _, e := range analysis.Evaluations {
+		if e.Outcome != OutcomeDraw {
+			t.Errorf("Expected every first move on 4x4 to draw, got %+v", e)
+		}
+	}
+}
+
+// TestBudget tests that the node budget stops the search
+func TestBudget(t *testing.T) {
+	b := boardFromMoves(t, 7, 6)
+	analysis := Analyze(b, Budget{MaxNodes: 5000})
+	if analysis.Solved {
+		t.Errorf("Expected the empty board not to be solved in 5000 nodes")
+	}
+	if analysis.Nodes > 5001 {
+		t.Errorf("Expected at most 5001 nodes, got %d", analysis.Nodes)
+	}
+	if len(analysis.Evaluations) != 7 || analysis.BestMove < 0 {
+		t.Errorf("Expected heuristic evaluations of all 7 columns, got %+v", analysis)
+	}
+}
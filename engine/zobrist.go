@@ -0,0 +1,40 @@
+package engine
+
+import "math/bits"
+
+// Zobrist keys for every side and bit, plus one for O to move. They come
+// from a fixed seed so hashes are stable across runs and processes.
+var (
+	zobristKeys [2][64]uint64
+	zobristSide uint64
+)
+
+func init() {
+	seed := uint64(0x9e3779b97f4a7c15)
+	next := func() uint64 {
+		// splitmix64
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+	for side := range zobristKeys {
+		for i := range zobristKeys[side] {
+			zobristKeys[side][i] = next()
+		}
+	}
+	zobristSide = next()
+}
+
+// sideKey returns the key for the side to move
+func (b *Board) sideKey() uint64 {
+	if b.toMove == O {
+		return zobristSide
+	}
+	return 0
+}
+
+func bitIndex(bit uint64) int {
+	return bits.TrailingZeros64(bit)
+}
@@ -1,26 +1,107 @@
 package main
 
 import (
+	"blackjackapi/config"
+	"blackjackapi/engine"
+	"blackjackapi/models"
 	"blackjackapi/server"
 	"blackjackapi/server/handlers"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/redis/go-redis/v9"
+	"io/fs"
+	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		fmt.Println("Error loading .env file:", err)
+	// A .env file is optional; its variables count as the environment
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "Error loading .env file:", err)
+		os.Exit(2)
+	}
+	cfg, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
 		return
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+
+	handler, err := newHandler(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	ConnectString := os.Getenv("REDIS")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go handler.RunJanitor(ctx, time.Duration(cfg.Tables.JanitorInterval))
 
-	opt, _ := redis.ParseURL(ConnectString)
-	client := redis.NewClient(opt)
-	handler := handlers.NewHandler(client, os.Getenv("UPSTASH_KAFKA_REST_USERNAME"), os.Getenv("UPSTASH_KAFKA_REST_PASSWORD"), os.Getenv("UPSTASH_KAFKA_REST_URL"))
 	Router := server.NewRouter(handler)
-	http.ListenAndServe(":8080", Router)
+	srv := &http.Server{Addr: cfg.Listen, Handler: Router}
+	// Streams never finish on their own, so they are ended as soon as the
+	// server stops accepting connections
+	srv.RegisterOnShutdown(handler.StopStreams)
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %s", cfg.Listen)
+		serveErr <- srv.ListenAndServe()
+	}()
 
+	select {
+	case err := <-serveErr:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	stop() // A second signal kills the server right away
+	log.Printf("Shutting down, waiting up to %v", time.Duration(cfg.ShutdownTimeout))
+	deadline, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancel()
+	if err := srv.Shutdown(deadline); err != nil {
+		log.Printf("Error shutting down the HTTP server: %v", err)
+	}
+	if err := handler.Shutdown(deadline); err != nil {
+		log.Printf("Error shutting down: %v", err)
+		os.Exit(1)
+	}
+	log.Printf("Shut down")
+}
+
+// newHandler builds the request handler from a validated configuration
+func newHandler(cfg *config.Config) (*handlers.Handler, error) {
+	opt, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing the Redis URL: %v", err)
+	}
+	handler := handlers.NewHandler(redis.NewClient(opt), cfg.Kafka.Username, cfg.Kafka.Password, cfg.Kafka.URL)
+	if cfg.EventBus == config.EventBusMemory {
+		handler.Broker = models.NewMemoryBroker()
+	}
+	switch {
+	case !cfg.Features.OpeningBook:
+		handler.Book = nil
+	case cfg.OpeningBook != "":
+		book, err := engine.LoadBook(cfg.OpeningBook)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading opening book: %v", err)
+		}
+		handler.Book = book
+	}
+	models.SessionTTL = time.Duration(cfg.Tables.TTL)
+	handler.IdleTimeout = time.Duration(cfg.Tables.IdleTimeout)
+	handler.AbandonedTimeout = time.Duration(cfg.Tables.AbandonedTimeout)
+	handler.ReconnectGrace = time.Duration(cfg.Tables.ReconnectGrace)
+	handler.DisconnectAction = cfg.Tables.DisconnectAction
+	handler.ShutdownRetry = time.Duration(cfg.ShutdownRetry)
+	handler.Analysis = cfg.Features.Analysis
+	handler.MaxAnalysisTime = time.Duration(cfg.Limits.MaxAnalysisTime)
+	handler.MaxAnalysisNodes = cfg.Limits.MaxAnalysisNodes
+	return handler, nil
 }
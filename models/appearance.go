@@ -0,0 +1,139 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Colors players may pick for their pieces
+const (
+	ColorRed    = "red"
+	ColorYellow = "yellow"
+	ColorPurple = "purple"
+	ColorTeal   = "teal"
+	ColorOrange = "orange"
+	ColorPink   = "pink"
+	ColorGreen  = "green"
+	ColorBlack  = "black"
+)
+
+// Colors lists the colors players may pick. The first four are the
+// defaults of the four seats, in order.
+var Colors = []string{ColorRed, ColorYellow, ColorPurple, ColorTeal, ColorOrange, ColorPink, ColorGreen, ColorBlack}
+
+// seatSymbols are the pieces of the four seats, which double as the
+// default symbols shown for them
+var seatSymbols = []string{Player1Symbol, Player2Symbol, Player3Symbol, Player4Symbol}
+
+// spareSymbols are handed out once the default symbols are taken
+var spareSymbols = []string{"*", "%", "&", "$"}
+
+// reservedSymbols draw the board and its highlights
+const reservedSymbols = "[]()|-+"
+
+// MaxAvatarLength bounds the length of an avatar URL
+const MaxAvatarLength = 512
+
+// ValidateSymbol checks symbol is a single visible character that is not
+// used to draw the board
+func ValidateSymbol(symbol string) error {
+	r, size := utf8.DecodeRuneInString(symbol)
+	if size == 0 || size != len(symbol) || r == utf8.RuneError {
+		return errors.New("A symbol is a single character")
+	}
+	if !unicode.IsGraphic(r) || unicode.IsSpace(r) || strings.ContainsRune(reservedSymbols, r) {
+		return fmt.Errorf("Symbol %q cannot be used", symbol)
+	}
+	return nil
+}
+
+// ValidateColor checks color is one of Colors
+func ValidateColor(color string) error {
+	if !slices.Contains(Colors, color) {
+		return fmt.Errorf("Unknown color %q. Use one of %s.", color, strings.Join(Colors, ", "))
+	}
+	return nil
+}
+
+// ValidateAvatar checks avatar is an http or https URL
+func ValidateAvatar(avatar string) error {
+	u, err := url.Parse(avatar)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(avatar) > MaxAvatarLength {
+		return fmt.Errorf("An avatar is an http or https URL of at most %d characters", MaxAvatarLength)
+	}
+	return nil
+}
+
+// Appearance returns the symbol and color shown for the player seated at
+// index, falling back to the defaults of the seat for players seated
+// before they could choose
+func (s *Session) Appearance(index int) (symbol, color string) {
+	player := s.Players[index]
+	symbol, color = player.Symbol, player.Color
+	if symbol == "" {
+		symbol = s.Symbol(index)
+	}
+	if color == "" {
+		color = Colors[s.side(index)]
+	}
+	return symbol, color
+}
+
+// PieceAppearance returns the symbol and color shown for a piece on the
+// grid: those of the player playing it, or the piece's own defaults when
+// nobody seated plays it
+func (s *Session) PieceAppearance(piece string) (symbol, color string) {
+	for i := range s.Players {
+		if s.Symbol(i) == piece {
+			return s.Appearance(i)
+		}
+	}
+	if i := slices.Index(seatSymbols, piece); i != -1 {
+		return piece, Colors[i]
+	}
+	return piece, ""
+}
+
+// settleAppearance settles the appearance of the player just seated at
+// index against the other sides of the table. A symbol or color another
+// side shows is replaced by the first free one, starting with the seat's
+// defaults. In team play a player joining a seated teammate takes on
+// their appearance.
+func (s *Session) settleAppearance(index int) {
+	player := s.Players[index]
+	for i := range s.Players {
+		if i != index && s.side(i) == s.side(index) {
+			player.Symbol, player.Color = s.Appearance(i)
+			return
+		}
+	}
+	symbols, colors := map[string]bool{}, map[string]bool{}
+	for i := range s.Players {
+		if s.side(i) != s.side(index) {
+			symbol, color := s.Appearance(i)
+			symbols[symbol], colors[color] = true, true
+		}
+	}
+	player.Symbol = firstFree(symbols, player.Symbol, s.Symbol(index), seatSymbols, spareSymbols)
+	player.Color = firstFree(colors, player.Color, Colors[s.side(index)], Colors)
+}
+
+// firstFree returns the first of the choice, the fallback and the spares
+// that is not taken
+func firstFree(taken map[string]bool, choice, fallback string, spares ...[]string) string {
+	candidates := []string{choice, fallback}
+	for _, list := range spares {
+		candidates = append(candidates, list...)
+	}
+	for _, candidate := range candidates {
+		if candidate != "" && !taken[candidate] {
+			return candidate
+		}
+	}
+	return fallback
+}
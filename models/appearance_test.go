@@ -0,0 +1,81 @@
+package models
+
+import "testing"
+
+// TestValidateAppearance tests the symbols, colors and avatars players may pick
+func TestValidateAppearance(t *testing.T) {
+	for _, symbol := range []string{"*", "♥", "Z"} {
+		if err := ValidateSymbol(symbol); err != nil {
+			t.Errorf("Expected symbol %q to be accepted, got %v", symbol, err)
+		}
+	}
+	for _, symbol := range []string{"", "ab", " ", "|", "[", "\t"} {
+		if err := ValidateSymbol(symbol); err == nil {
+			t.Errorf("Expected symbol %q to be refused", symbol)
+		}
+	}
+	if err := ValidateColor(ColorPink); err != nil {
+		t.Errorf("Expected pink to be accepted, got %v", err)
+	}
+	if err := ValidateColor("mauve"); err == nil {
+		t.Errorf("Expected mauve to be refused")
+	}
+	if err := ValidateAvatar("https://example.com/alice.png"); err != nil {
+		t.Errorf("Expected an https avatar to be accepted, got %v", err)
+	}
+	for _, avatar := range []string{"javascript:alert(1)", "/alice.png", "ftp://example.com/alice.png"} {
+		if err := ValidateAvatar(avatar); err == nil {
+			t.Errorf("Expected avatar %q to be refused", avatar)
+		}
+	}
+}
+
+// TestAppearanceConflicts tests that a choice another player shows is replaced
+func TestAppearanceConflicts(t *testing.T) {
+	session := NewSession("testSession")
+	session.AddPlayer(&Player{Name: "alice", Symbol: "O", Color: ColorYellow})
+	session.AddPlayer(&Player{Name: "bob", Symbol: "O", Color: ColorGreen})
+
+	if symbol, color := session.Appearance(0); symbol != "O" || color != ColorYellow {
+		t.Errorf("Expected alice to keep O in yellow, got %s in %s", symbol, color)
+	}
+	if symbol, color := session.Appearance(1); symbol != "X" || color != ColorGreen {
+		t.Errorf("Expected bob to fall back to X in green, got %s in %s", symbol, color)
+	}
+
+	session.DropPiece(0, session.Symbol(1))
+	if symbol, color := session.PieceAppearance(session.Grid[5][0]); symbol != "X" || color != ColorGreen {
+		t.Errorf("Expected bob's piece to show as X in green, got %s in %s", symbol, color)
+	}
+}
+
+// TestDefaultAppearance tests that players without a choice show their seat's piece
+func TestDefaultAppearance(t *testing.T) {
+	session := NewSession("testSession")
+	session.SetSeats(3, false)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		session.AddPlayer(NewPlayer(name))
+	}
+	for i, want := range []string{ColorRed, ColorYellow, ColorPurple} {
+		if symbol, color := session.Appearance(i); symbol != session.Symbol(i) || color != want {
+			t.Errorf("Expected seat %d to show %s in %s, got %s in %s", i, session.Symbol(i), want, symbol, color)
+		}
+	}
+}
+
+// TestTeamAppearance tests that teammates share the appearance of their side
+func TestTeamAppearance(t *testing.T) {
+	session := NewSession("testSession")
+	session.SetSeats(0, true)
+	session.AddPlayer(&Player{Name: "alice", Symbol: "*", Color: ColorPink})
+	session.AddPlayer(NewPlayer("bob"))
+	session.AddPlayer(&Player{Name: "carol", Symbol: "%", Color: ColorGreen})
+	session.AddPlayer(NewPlayer("dave"))
+
+	if symbol, color := session.Appearance(2); symbol != "*" || color != ColorPink {
+		t.Errorf("Expected carol to show their team's * in pink, got %s in %s", symbol, color)
+	}
+	if symbol, color := session.Appearance(3); symbol != "O" || color != ColorYellow {
+		t.Errorf("Expected dave to show their team's O in yellow, got %s in %s", symbol, color)
+	}
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Move time limits of a bot
+const (
+	DefaultBotMoveTime = 10 * time.Second
+	MinBotMoveTime     = 100 * time.Millisecond
+	MaxBotMoveTime     = time.Minute
+)
+
+// ErrBotExists is returned when registering a name that is already taken
+var ErrBotExists = errors.New("A bot with that name is already registered")
+
+// Bot names are also used in broker stream names and tokens
+var botNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Bot is an external engine registered to play through the bot API
+type Bot struct {
+	Name string `json:"name"`
+	// TokenHash is the SHA-256 of the bot's token, which is never stored
+	TokenHash string `json:"token_hash"`
+	// MoveTime is how long the bot has for each move
+	MoveTime time.Duration `json:"move_time"`
+}
+
+// NewBot creates a bot and the token it authenticates with
+func NewBot(name string, moveTime time.Duration) (*Bot, string, error) {
+	if !botNamePattern.MatchString(name) {
+		return nil, "", errors.New("Bot names are 1 to 32 letters, digits, _ or -")
+	}
+	if moveTime < MinBotMoveTime || moveTime > MaxBotMoveTime {
+		return nil, "", errors.New("Move time must be between 100ms and 1 minute")
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	// The name lets the token be checked without a second index
+	token := name + "." + hex.EncodeToString(secret)
+	return &Bot{Name: name, TokenHash: hashToken(token), MoveTime: moveTime}, token, nil
+}
+
+// CheckToken reports whether token is the bot's token
+func (b *Bot) CheckToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(b.TokenHash)) == 1
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// BotStream is the broker stream a bot receives its events on
+func BotStream(name string) string {
+	return "bot-" + name
+}
+
+func botKey(name string) string {
+	return "bot:" + name
+}
+
+// RegisterBot saves a new bot, failing with ErrBotExists if the name is taken
+func RegisterBot(ctx context.Context, bot *Bot, client *redis.Client) error {
+	data, err := json.Marshal(bot)
+	if err != nil {
+		return err
+	}
+	created, err := client.SetNX(ctx, botKey(bot.Name), data, 0).Result()
+	if err != nil {
+		return err
+	}
+	if !created {
+		return ErrBotExists
+	}
+	return nil
+}
+
+// GetBot retrieves a registered bot
+func GetBot(ctx context.Context, name string, client *redis.Client) (*Bot, error) {
+	val, err := client.Get(ctx, botKey(name)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var bot Bot
+	if err := json.Unmarshal([]byte(val), &bot); err != nil {
+		return nil, err
+	}
+	return &bot, nil
+}
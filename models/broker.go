@@ -0,0 +1,105 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBrokerClosed is returned when publishing or subscribing to a closed broker
+var ErrBrokerClosed = errors.New("The event broker is closed")
+
+// Broker carries table events from the handlers to stream subscribers.
+// Every table has a stream named by its ID; other streams, such as those of
+// bots, have names that cannot be table IDs.
+type Broker interface {
+	// Publish sends an event to everyone subscribed to its table
+	Publish(ctx context.Context, event Event) error
+	// PublishTo sends an event to everyone subscribed to a stream
+	PublishTo(ctx context.Context, stream string, event Event) error
+	// Subscribe returns a channel of events for a stream. The channel is
+	// closed once ctx is done or the underlying connection fails.
+	Subscribe(ctx context.Context, stream string) (<-chan Event, error)
+	// Close ends every subscription and releases the broker's connections
+	Close() error
+}
+
+// MemoryBroker is an in-process Broker used for local runs and tests
+type MemoryBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan Event]struct{}
+	closed      bool
+	done        chan struct{}
+}
+
+// NewMemoryBroker initializes and returns an empty MemoryBroker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subscribers: make(map[string]map[chan Event]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Publish delivers the event to every current subscriber of the table
+func (b *MemoryBroker) Publish(ctx context.Context, event Event) error {
+	return b.PublishTo(ctx, event.TableID, event)
+}
+
+// PublishTo delivers the event to every current subscriber of the stream.
+// Subscribers that are not keeping up miss the event rather than block the game.
+func (b *MemoryBroker) PublishTo(ctx context.Context, stream string, event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBrokerClosed
+	}
+	for ch := range b.subscribers[stream] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a subscriber for the stream until ctx is done or the
+// broker is closed
+func (b *MemoryBroker) Subscribe(ctx context.Context, stream string) (<-chan Event, error) {
+	ch := make(chan Event, 64)
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrBrokerClosed
+	}
+	if b.subscribers[stream] == nil {
+		b.subscribers[stream] = make(map[chan Event]struct{})
+	}
+	b.subscribers[stream][ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-b.done:
+		}
+		b.mu.Lock()
+		delete(b.subscribers[stream], ch)
+		if len(b.subscribers[stream]) == 0 {
+			delete(b.subscribers, stream)
+		}
+		close(ch)
+		b.mu.Unlock()
+	}()
+	return ch, nil
+}
+
+// Close ends every subscription, closing the subscribers' channels
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	return nil
+}
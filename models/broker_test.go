@@ -0,0 +1,35 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestMemoryBrokerClose tests that closing the broker ends its subscriptions
+// and refuses new events
+func TestMemoryBrokerClose(t *testing.T) {
+	broker := NewMemoryBroker()
+	events, err := broker.Subscribe(context.Background(), "table")
+	if err != nil {
+		t.Fatalf("Error subscribing: %v", err)
+	}
+	if err := broker.Close(); err != nil {
+		t.Fatalf("Error closing: %v", err)
+	}
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Errorf("Expected no events after closing")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the subscription to end when the broker closed")
+	}
+	if err := broker.PublishTo(context.Background(), "table", Event{Type: EventConnected}); !errors.Is(err, ErrBrokerClosed) {
+		t.Errorf("Expected ErrBrokerClosed publishing after close, got %v", err)
+	}
+	if err := broker.Close(); err != nil {
+		t.Errorf("Expected closing twice to be harmless, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// Event types broadcast on a table stream
+const (
+	EventConnected    = "connected"
+	EventPlayerJoined = "player_joined"
+	EventPlayerLeft   = "player_left"
+	EventPlayerReady  = "player_ready"
+	EventGameStarted  = "game_started"
+	EventCoinFlipped  = "coin_flipped"
+	EventPieceDropped = "piece_dropped"
+	EventMoveTimeout  = "move_timeout"
+	EventMatchStarted = "match_started"
+	EventMatchDecided = "match_decided"
+	EventTableExpired = "table_expired"
+	// Host events
+	EventPlayerKicked    = "player_kicked"
+	EventTableLocked     = "table_locked"
+	EventTableUnlocked   = "table_unlocked"
+	EventSettingsChanged = "settings_changed"
+	EventHostChanged     = "host_changed"
+	// Presence events
+	EventPlayerDisconnected = "player_disconnected"
+	EventPlayerReconnected  = "player_reconnected"
+	EventGamePaused         = "game_paused"
+	EventGameForfeited      = "game_forfeited"
+	// Events of players ending a game early
+	EventPlayerResigned     = "player_resigned"
+	EventDrawOffered        = "draw_offered"
+	EventDrawAccepted       = "draw_accepted"
+	EventDrawDeclined       = "draw_declined"
+	EventAbandonmentClaimed = "abandonment_claimed"
+	// Lobby events are sent on LobbyStream, table_closed also on the table's stream
+	EventTableCreated = "table_created"
+	EventTableUpdated = "table_updated"
+	EventTableClosed  = "table_closed"
+	// EventYourTurn is sent on a bot's own stream when it has to move
+	EventYourTurn = "your_turn"
+	// Tournament events are sent on a tournament's own stream
+	EventRoundPaired        = "round_paired"
+	EventMatchResult        = "match_result"
+	EventTournamentFinished = "tournament_finished"
+	// EventServerShutdown ends every stream when the server shuts down
+	EventServerShutdown = "server_shutdown"
+)
+
+// DefaultShutdownRetry is how long stream subscribers are told to wait
+// before reconnecting when the server shuts down
+const DefaultShutdownRetry = 3 * time.Second
+
+// Event is a message broadcast to everyone connected to a table
+type Event struct {
+	Type    string   `json:"type"`
+	TableID string   `json:"table_id"`
+	Message string   `json:"message"`
+	Session *Session `json:"session,omitempty"`
+	// Table summarizes the table on lobby events, which carry no session
+	Table *TableSummary `json:"table,omitempty"`
+	// Position and Deadline are set on your_turn events
+	Position string     `json:"position,omitempty"`
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// RetryMS is set on server_shutdown events to how long to wait
+	// before reconnecting
+	RetryMS int64 `json:"retry_ms,omitempty"`
+}
+
+// NewEvent creates an event carrying a snapshot of the session, so the
+// session can keep changing while subscribers read the event
+func NewEvent(eventType string, session *Session, message string) Event {
+	return Event{
+		Type:    eventType,
+		TableID: session.ID,
+		Message: message,
+		Session: session.Snapshot(),
+	}
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+)
+
+// Policies choosing who moves first in each game of a table
+const (
+	FirstMoveAlternate = "alternate" // Seats take turns moving first, the default
+	FirstMoveRandom    = "random"    // A random seat moves first
+	FirstMoveLoser     = "loser"     // The losing side of the last game moves first
+	FirstMoveHost      = "host"      // The player the host chose moves first
+	FirstMoveCoinFlip  = "coin_flip" // A random seat moves first, announced with a coin_flipped event
+)
+
+// FirstMoves lists the first move policies
+var FirstMoves = []string{FirstMoveAlternate, FirstMoveRandom, FirstMoveLoser, FirstMoveHost, FirstMoveCoinFlip}
+
+// ValidateFirstMove checks policy is one of FirstMoves, or empty for the default
+func ValidateFirstMove(policy string) error {
+	if policy != "" && !slices.Contains(FirstMoves, policy) {
+		return fmt.Errorf("Unknown first move policy %q. Use one of %s.", policy, strings.Join(FirstMoves, ", "))
+	}
+	return nil
+}
+
+// FirstSeat picks the seat moving first in the game about to start, after
+// Starts has counted it. Seats alternate whenever the policy cannot decide:
+// after a draw or a game between more than two sides under FirstMoveLoser,
+// and while the host has chosen nobody seated under FirstMoveHost.
+func (s *Session) FirstSeat() int {
+	switch s.FirstMove {
+	case FirstMoveRandom, FirstMoveCoinFlip:
+		return rand.Intn(len(s.Players))
+	case FirstMoveLoser:
+		if index := s.PlayerIndex(s.Loser); index != -1 {
+			return index
+		}
+	case FirstMoveHost:
+		if index := s.PlayerIndex(s.FirstPlayer); index != -1 {
+			return index
+		}
+	}
+	return s.Starts % len(s.Players)
+}
+
+// recordLoser remembers a player on the losing side of a game won by the
+// player seated at winner, for FirstMoveLoser
+func (s *Session) recordLoser(winner int) {
+	s.Loser = ""
+	if winner < 0 {
+		return
+	}
+	if loser, err := s.opponent(winner); err == nil {
+		s.Loser = s.Players[loser].Name
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import "testing"
+
+// newFirstMoveTable returns a two player table between alice and bob
+// under the given first move policy
+func newFirstMoveTable(policy string) *Session {
+	session := NewSession("testSession")
+	session.AddPlayer(NewPlayer("alice"))
+	session.AddPlayer(NewPlayer("bob"))
+	session.FirstMove = policy
+	return session
+}
+
+// TestValidateFirstMove tests the first move policies a table accepts
+func TestValidateFirstMove(t *testing.T) {
+	for _, policy := range append(FirstMoves, "") {
+		if err := ValidateFirstMove(policy); err != nil {
+			t.Errorf("Expected policy %q to be accepted, got %v", policy, err)
+		}
+	}
+	if err := ValidateFirstMove("winner"); err == nil {
+		t.Errorf("Expected an unknown policy to be refused")
+	}
+}
+
+// TestFirstSeat tests the seat each policy picks to move first
+func TestFirstSeat(t *testing.T) {
+	session := newFirstMoveTable("")
+	for starts, want := range []int{0, 1, 0} {
+		session.Starts = starts
+		if seat := session.FirstSeat(); seat != want {
+			t.Errorf("Expected seat %d to move first in game %d, got %d", want, starts, seat)
+		}
+	}
+
+	session = newFirstMoveTable(FirstMoveLoser)
+	session.Starts = 2
+	session.Status = true
+	session.FinishGame(1)
+	if seat := session.FirstSeat(); seat != 0 {
+		t.Errorf("Expected alice to move first after losing, got seat %d", seat)
+	}
+	session.Starts = 3
+	session.Status = true
+	session.FinishGame(-1)
+	if seat := session.FirstSeat(); seat != 1 {
+		t.Errorf("Expected seats to alternate after a draw, got seat %d", seat)
+	}
+
+	session = newFirstMoveTable(FirstMoveHost)
+	session.Starts = 2
+	session.FirstPlayer = "bob"
+	if seat := session.FirstSeat(); seat != 1 {
+		t.Errorf("Expected the host's choice to move first, got seat %d", seat)
+	}
+
+	session = newFirstMoveTable(FirstMoveCoinFlip)
+	for i := 0; i < 20; i++ {
+		if seat := session.FirstSeat(); seat < 0 || seat > 1 {
+			t.Fatalf("Expected a coin flip to pick a seat, got %d", seat)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxHandicapPieces bounds the pieces a handicap places, keeping them short
+// of a line of four
+const MaxHandicapPieces = 3
+
+// Errors returned by SetHandicap and Play
+var (
+	ErrHandicapSides  = errors.New("Handicaps are only given in games between two sides")
+	ErrHandicapPlayer = errors.New("The player giving the handicap must be seated at the table")
+	ErrHandicapColumn = errors.New("The handicap decides the column of this move")
+)
+
+// Handicap evens the games of a stronger player against a weaker side. It
+// applies to every game of the table until the host removes it.
+type Handicap struct {
+	// Player is the stronger player, who gives the handicap
+	Player string `json:"player"`
+	// Pieces are the columns where the other side gets a piece before
+	// the first move
+	Pieces []int `json:"pieces,omitempty"`
+	// Column, when set, is the column the stronger player must open with
+	Column *int `json:"column,omitempty"`
+}
+
+// SetHandicap gives the table a handicap, or removes it when handicap is
+// nil or names no player
+func (s *Session) SetHandicap(handicap *Handicap) error {
+	if handicap == nil || handicap.Player == "" {
+		s.Handicap = nil
+		return nil
+	}
+	if !s.TwoSided() {
+		return ErrHandicapSides
+	}
+	if s.PlayerIndex(handicap.Player) == -1 {
+		return ErrHandicapPlayer
+	}
+	if len(handicap.Pieces) > MaxHandicapPieces {
+		return fmt.Errorf("A handicap places at most %d pieces", MaxHandicapPieces)
+	}
+	columns := handicap.Pieces
+	if handicap.Column != nil {
+		columns = append(columns[:len(columns):len(columns)], *handicap.Column)
+	}
+	for _, column := range columns {
+		if column < 0 || column >= len(s.Grid[0]) {
+			return fmt.Errorf("Handicap column %d is off the board", column)
+		}
+	}
+	s.Handicap = handicap
+	return nil
+}
+
+// handicapped returns the seat of the player giving the handicap, or -1
+// when the table has no handicap that applies
+func (s *Session) handicapped() int {
+	if s.Handicap == nil || !s.TwoSided() {
+		return -1
+	}
+	return s.PlayerIndex(s.Handicap.Player)
+}
+
+// PlaceHandicap places the pieces of the handicap on a cleared board. They
+// are not moves: the game starts from the position they make.
+func (s *Session) PlaceHandicap() {
+	index := s.handicapped()
+	if index == -1 || len(s.Handicap.Pieces) == 0 {
+		return
+	}
+	other, err := s.opponent(index)
+	if err != nil {
+		return
+	}
+	for _, column := range s.Handicap.Pieces {
+		s.DropPiece(column, s.Symbol(other))
+	}
+	s.Moves = nil
+}
+
+// ForcedColumn returns the column the player seated at index must play,
+// which the handicap decides for the stronger player's first move
+func (s *Session) ForcedColumn(index int) (int, bool) {
+	if s.handicapped() != index || s.Handicap.Column == nil {
+		return 0, false
+	}
+	for _, move := range s.Moves {
+		if move.Symbol == s.Symbol(index) {
+			return 0, false
+		}
+	}
+	return *s.Handicap.Column, true
+}
@@ -0,0 +1,65 @@
+package models
+
+import "testing"
+
+// TestSetHandicap tests the handicaps a table accepts
+func TestSetHandicap(t *testing.T) {
+	session := newFirstMoveTable("")
+	column := 7
+	cases := []*Handicap{
+		{Player: "carol"},
+		{Player: "alice", Pieces: []int{0, 1, 2, 3}},
+		{Player: "alice", Pieces: []int{-1}},
+		{Player: "alice", Column: &column},
+	}
+	for _, handicap := range cases {
+		if err := session.SetHandicap(handicap); err == nil {
+			t.Errorf("Expected handicap %+v to be refused", handicap)
+		}
+	}
+	if err := session.SetHandicap(&Handicap{Player: "alice", Pieces: []int{3}}); err != nil || session.Handicap == nil {
+		t.Errorf("Expected the handicap to be set, got %v", err)
+	}
+	if err := session.SetHandicap(&Handicap{}); err != nil || session.Handicap != nil {
+		t.Errorf("Expected an empty handicap to remove it, got %v", err)
+	}
+
+	session = NewSession("testSession")
+	session.SetSeats(3, false)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		session.AddPlayer(NewPlayer(name))
+	}
+	if err := session.SetHandicap(&Handicap{Player: "alice"}); err != ErrHandicapSides {
+		t.Errorf("Expected ErrHandicapSides at a three player table, got %v", err)
+	}
+}
+
+// TestHandicap tests that handicap pieces are placed and the first move is forced
+func TestHandicap(t *testing.T) {
+	session := newFirstMoveTable("")
+	column := 0
+	session.SetHandicap(&Handicap{Player: "alice", Pieces: []int{3, 3}, Column: &column})
+	session.ClearBoard()
+	session.PlaceHandicap()
+	session.Status = true
+
+	if session.Grid[5][3] != Player2Symbol || session.Grid[4][3] != Player2Symbol {
+		t.Errorf("Expected bob's pieces in column 3, got\n%s", session.StringBoard())
+	}
+	if len(session.Moves) != 0 || session.OccupiedSlots != 2 {
+		t.Errorf("Expected the pieces not to count as moves, got %d moves", len(session.Moves))
+	}
+	if _, err := session.Play("alice", 3); err != ErrHandicapColumn {
+		t.Errorf("Expected ErrHandicapColumn, got %v", err)
+	}
+	if _, err := session.Play("alice", 0); err != nil {
+		t.Fatalf("Expected alice to open in column 0, got %v", err)
+	}
+	session.Play("bob", 1)
+	if _, err := session.Play("alice", 3); err != nil {
+		t.Errorf("Expected alice to play freely after the first move, got %v", err)
+	}
+	if start := session.StartingBoard(); start.OccupiedSlots != 2 || start.Turn != 0 {
+		t.Errorf("Expected the game to start from the handicap pieces, got %d pieces", start.OccupiedSlots)
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"slices"
+)
+
+// ErrNotSeated is returned when the host role is handed to someone not at the table
+var ErrNotSeated = errors.New("The new host must be seated at the table")
+
+// NewHostToken makes the holder of the returned token the table's host,
+// revoking any earlier token. Only its hash is kept.
+func (s *Session) NewHostToken() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(secret)
+	s.hostTokenHash = hashToken(token)
+	return token, nil
+}
+
+// CheckHostToken reports whether token belongs to the table's host. Tables
+// created for arenas and tournaments have no host.
+func (s *Session) CheckHostToken(token string) bool {
+	if s.hostTokenHash == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(s.hostTokenHash)) == 1
+}
+
+// TransferHost hands the host role to a seated player, returning the token
+// that player should be given
+func (s *Session) TransferHost(name string) (string, error) {
+	if s.PlayerIndex(name) == -1 {
+		return "", ErrNotSeated
+	}
+	token, err := s.NewHostToken()
+	if err != nil {
+		return "", err
+	}
+	s.Host = name
+	return token, nil
+}
+
+// IsBanned reports whether the host kicked name from the table
+func (s *Session) IsBanned(name string) bool {
+	return slices.Contains(s.Banned, name)
+}
+
+// Kick removes name from the table and keeps them from coming back, whether
+// they are seated or only watching. A seated player kicked during a game
+// forfeits it; Kick reports whether they did.
+func (s *Session) Kick(name string) bool {
+	if !s.IsBanned(name) {
+		s.Banned = append(s.Banned, name)
+	}
+	index := s.PlayerIndex(name)
+	if index == -1 {
+		return false
+	}
+	forfeit := s.Status
+	if forfeit {
+		s.Forfeit(index)
+	}
+	s.RemovePlayer(index)
+	return forfeit
+}
+
+// RemovePlayer empties the seat at index, abandoning any match. In team
+// play everyone else stays in their team.
+func (s *Session) RemovePlayer(index int) {
+	if s.Players[index].Name == s.Host {
+		s.Host = ""
+	}
+	s.Players = append(s.Players[:index], s.Players[index+1:]...)
+	s.Match = nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestHostToken tests that only the latest host token is accepted
+func TestHostToken(t *testing.T) {
+	session := NewSession("testSession")
+	if session.CheckHostToken("") {
+		t.Errorf("Expected a table without a host to refuse every token")
+	}
+	first, err := session.NewHostToken()
+	if err != nil {
+		t.Fatalf("Error making host token: %v", err)
+	}
+	if !session.CheckHostToken(first) || session.CheckHostToken(first+"0") {
+		t.Errorf("Expected only the host token to be accepted")
+	}
+	data, _ := json.Marshal(session)
+	if strings.Contains(string(data), first) || strings.Contains(string(data), session.hostTokenHash) {
+		t.Errorf("Expected the host token to stay out of the table's JSON, got %s", data)
+	}
+
+	if _, err := session.TransferHost("alice"); err != ErrNotSeated {
+		t.Errorf("Expected ErrNotSeated handing the table to a stranger, got %v", err)
+	}
+	session.AddPlayer(NewPlayer("alice"))
+	second, err := session.TransferHost("alice")
+	if err != nil {
+		t.Fatalf("Error transferring host: %v", err)
+	}
+	if session.Host != "alice" || session.CheckHostToken(first) || !session.CheckHostToken(second) {
+		t.Errorf("Expected alice to hold the only valid token")
+	}
+	session.RemovePlayer(0)
+	if session.Host != "" {
+		t.Errorf("Expected the host role to go when alice leaves, got %q", session.Host)
+	}
+}
+
+// TestKick tests that a player kicked mid-game forfeits and cannot return
+func TestKick(t *testing.T) {
+	session := NewSession("testSession")
+	session.AddPlayer(NewPlayer("alice"))
+	session.AddPlayer(NewPlayer("bob"))
+	if session.Kick("carol") || !session.IsBanned("carol") {
+		t.Errorf("Expected a viewer to be banned without a forfeit")
+	}
+	session.Status = true
+	if !session.Kick("alice") {
+		t.Errorf("Expected alice to forfeit the game")
+	}
+	if session.Status || len(session.Players) != 1 || session.Players[0].Wins != 1 {
+		t.Errorf("Expected bob alone at the table with the win, got %+v", session.Players)
+	}
+	if !session.IsBanned("alice") {
+		t.Errorf("Expected alice to be banned")
+	}
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Join codes are CodeLength letters, leaving out I and O which read like digits
+const (
+	CodeLength   = 6
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	// codeAttempts bounds the codes tried before giving up on collisions
+	codeAttempts = 10
+)
+
+// Errors returned when reserving a table's name and code
+var (
+	ErrTableNameTaken = errors.New("A table with that name already exists")
+	ErrNoCode         = errors.New("Could not find a free join code. Please try again.")
+	ErrCodeNotFound   = errors.New("No table has that join code")
+	ErrPasswordLength = fmt.Errorf("Table passwords are at most %d bytes", MaxPasswordLength)
+)
+
+// Table names are shown to people, so they are kept short and printable
+var tableNamePattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} _'.-]{0,39}$`)
+
+// ValidateTableName checks a name given to a table
+func ValidateTableName(name string) error {
+	if !tableNamePattern.MatchString(name) {
+		return errors.New("Table names are 1 to 40 letters, digits, spaces or _'.- and start with a letter or digit")
+	}
+	return nil
+}
+
+func codeKey(code string) string {
+	return "code:" + code
+}
+
+func tableNameKey(name string) string {
+	return "tablename:" + strings.ToLower(name)
+}
+
+// NormalizeCode turns a join code as typed by someone into its stored form
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// newCode draws a random join code
+func newCode() (string, error) {
+	code := make([]byte, CodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(codeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = codeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// ReserveTable claims the session's name, if it has one, and a fresh join
+// code for it in the store, failing with ErrTableNameTaken when another
+// table has the name. Both expire along with the table.
+func ReserveTable(ctx context.Context, session *Session, client *redis.Client) error {
+	if session.Name != "" {
+		claimed, err := client.SetNX(ctx, tableNameKey(session.Name), session.ID, SessionTTL).Result()
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return ErrTableNameTaken
+		}
+	}
+	for i := 0; i < codeAttempts; i++ {
+		code, err := newCode()
+		if err != nil {
+			break
+		}
+		claimed, err := client.SetNX(ctx, codeKey(code), session.ID, SessionTTL).Result()
+		if err != nil {
+			break
+		}
+		if claimed {
+			session.Code = code
+			return nil
+		}
+	}
+	if session.Name != "" {
+		client.Del(ctx, tableNameKey(session.Name))
+	}
+	return ErrNoCode
+}
+
+// RenameTable gives the table a new name, or none when name is empty,
+// releasing its old name. It fails with ErrTableNameTaken when another
+// table has the name; a name the table already claimed, as when a rename
+// is retried, is kept.
+func RenameTable(ctx context.Context, session *Session, name string, client *redis.Client) error {
+	if strings.EqualFold(name, session.Name) {
+		session.Name = name
+		return nil
+	}
+	if name != "" {
+		claimed, err := client.SetNX(ctx, tableNameKey(name), session.ID, SessionTTL).Result()
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			owner, err := client.Get(ctx, tableNameKey(name)).Result()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				return err
+			}
+			if owner != session.ID {
+				return ErrTableNameTaken
+			}
+		}
+	}
+	if session.Name != "" {
+		if err := client.Del(ctx, tableNameKey(session.Name)).Err(); err != nil {
+			return err
+		}
+	}
+	session.Name = name
+	return nil
+}
+
+// ResolveCode returns the ID of the table with the join code
+func ResolveCode(ctx context.Context, code string, client *redis.Client) (string, error) {
+	id, err := client.Get(ctx, codeKey(NormalizeCode(code))).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrCodeNotFound
+	}
+	return id, err
+}
+
+// refreshAliases queues keeping the table's name and code alive as long as the table
+func refreshAliases(ctx context.Context, pipe redis.Pipeliner, session *Session, ttl time.Duration) {
+	for _, key := range session.aliasKeys() {
+		if ttl > 0 {
+			pipe.Expire(ctx, key, ttl)
+		} else {
+			pipe.Persist(ctx, key)
+		}
+	}
+}
+
+// aliasKeys are the keys pointing at the table by name and code
+func (s *Session) aliasKeys() []string {
+	var keys []string
+	if s.Name != "" {
+		keys = append(keys, tableNameKey(s.Name))
+	}
+	if s.Code != "" {
+		keys = append(keys, codeKey(s.Code))
+	}
+	return keys
+}
+
+// MaxPasswordLength is the longest password bcrypt hashes in full
+const MaxPasswordLength = 72
+
+// SetPassword protects the table with a password, or removes the protection
+// when password is empty. Only a bcrypt hash of the password is kept.
+func (s *Session) SetPassword(password string) error {
+	if password == "" {
+		s.passwordHash = ""
+		s.Protected = false
+		return nil
+	}
+	if len(password) > MaxPasswordLength {
+		return ErrPasswordLength
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	s.passwordHash = string(hash)
+	s.Protected = true
+	return nil
+}
+
+// CheckPassword reports whether password lets someone join the table.
+// Any password is accepted at a table without one.
+func (s *Session) CheckPassword(password string) bool {
+	if s.passwordHash == "" {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(s.passwordHash), []byte(password)) == nil
+}
+
+// Label names the table and its join code for status displays, or returns
+// "" when it has neither
+func (s *Session) Label() string {
+	switch {
+	case s.Name != "" && s.Code != "":
+		return fmt.Sprintf("Table: %s - Join Code: %s", s.Name, s.Code)
+	case s.Code != "":
+		return "Join Code: " + s.Code
+	case s.Name != "":
+		return "Table: " + s.Name
+	}
+	return ""
+}
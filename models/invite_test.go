@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestPassword tests that only the right password opens a protected table
+func TestPassword(t *testing.T) {
+	session := NewSession("testSession")
+	if !session.CheckPassword("anything") {
+		t.Errorf("Expected any password to open a table without one")
+	}
+	if err := session.SetPassword("secret"); err != nil || !session.Protected {
+		t.Fatalf("Expected the table to be protected, got %v", err)
+	}
+	if session.CheckPassword("Secret") || session.CheckPassword("") {
+		t.Errorf("Expected wrong passwords to be refused")
+	}
+	if !session.CheckPassword("secret") {
+		t.Errorf("Expected the password to open the table")
+	}
+	if !strings.HasPrefix(session.passwordHash, "$2") {
+		t.Errorf("Expected a bcrypt hash of the password, got %q", session.passwordHash)
+	}
+	if err := session.SetPassword(strings.Repeat("x", MaxPasswordLength+1)); !errors.Is(err, ErrPasswordLength) {
+		t.Errorf("Expected ErrPasswordLength for a long password, got %v", err)
+	}
+	data, _ := json.Marshal(session)
+	if strings.Contains(string(data), "password_hash") || strings.Contains(string(data), "secret") {
+		t.Errorf("Expected the password to stay out of the table's JSON, got %s", data)
+	}
+}
+
+// TestValidateTableName tests the names tables may be given
+func TestValidateTableName(t *testing.T) {
+	for _, name := range []string{"Friday night", "Café 4", "a"} {
+		if err := ValidateTableName(name); err != nil {
+			t.Errorf("Expected %q to be a valid name, got %v", name, err)
+		}
+	}
+	for _, name := range []string{" padded", "<script>", strings.Repeat("x", 41)} {
+		if err := ValidateTableName(name); err == nil {
+			t.Errorf("Expected %q to be refused", name)
+		}
+	}
+}
+
+// TestNewCode tests the shape of join codes
+func TestNewCode(t *testing.T) {
+	code, err := newCode()
+	if err != nil {
+		t.Fatalf("Error drawing a code: %v", err)
+	}
+	if len(code) != CodeLength || strings.Trim(code, codeAlphabet) != "" {
+		t.Errorf("Expected %d letters from the code alphabet, got %q", CodeLength, code)
+	}
+}
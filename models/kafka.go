@@ -1,32 +1,44 @@
 package models
 
 import (
-	"net/url"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"log"
+	"sync"
 
 	"fmt"
 
 	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/scram"
 )
 
 // documentation from upstash kafka.
 
-func ProduceMessage(address, key, message, user, pass string) error {
-	// Encode the message for inclusion in the URL
-	messageEncoded := url.QueryEscape(message)
-	keyEncoded := url.QueryEscape(key)
-
-	// Construct the URL with properly encoded components
-	url := fmt.Sprintf("https://%s/produce/broadcast/%s?key=%s", address, messageEncoded, keyEncoded)
+// ProduceMessage sends message to the broadcast topic through the Upstash
+// REST API, in the body of a POST so events of any size and content fit
+func ProduceMessage(client *http.Client, address, key, message, user, pass string) error {
+	body, err := json.Marshal(struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}{key, message})
+	if err != nil {
+		return fmt.Errorf("error encoding message: %v", err)
+	}
+	url := fmt.Sprintf("https://%s/produce/broadcast", address)
 
-	// Create a new HTTP GET request (since you're using "GET" method)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("error creating HTTP request: %v", err)
 	}
+	req.Header.Set("Content-Type", "application/json")
 	req.SetBasicAuth(user, pass)
 
 	// Send the HTTP request
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error sending HTTP request: %v", err)
@@ -35,8 +47,146 @@ func ProduceMessage(address, key, message, user, pass string) error {
 
 	// Check the response status code
 	if resp.StatusCode != http.StatusOK {
-		fmt.Println(resp.StatusCode)
 		return fmt.Errorf("non-OK status code received: %s", resp.Status)
 	}
 	return nil
 }
+
+// KafkaBroker publishes events through the Upstash Kafka REST API and
+// streams them back with a Kafka reader on the broadcast topic
+type KafkaBroker struct {
+	Address  string
+	Username string
+	Password string
+	// HTTPClient sends the produce requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+
+	// readers counts the open Kafka readers, which stop once done is closed
+	mu      sync.Mutex
+	closed  bool
+	done    chan struct{}
+	readers sync.WaitGroup
+}
+
+// closing returns a channel closed when the broker is
+func (b *KafkaBroker) closing() chan struct{} {
+	if b.done == nil {
+		b.done = make(chan struct{})
+	}
+	return b.done
+}
+
+// Publish produces the JSON-encoded event keyed by its table ID
+func (b *KafkaBroker) Publish(ctx context.Context, event Event) error {
+	return b.PublishTo(ctx, event.TableID, event)
+}
+
+// PublishTo produces the JSON-encoded event keyed by the stream name
+func (b *KafkaBroker) PublishTo(ctx context.Context, stream string, event Event) error {
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed {
+		return ErrBrokerClosed
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	client := b.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return ProduceMessage(client, b.Address, stream, string(data), b.Username, b.Password)
+}
+
+// readerConfig configures a Kafka reader for one subscriber of stream. Each
+// subscriber reads in a consumer group of its own, since subscribers sharing
+// a group would split the events of the topic between them.
+func (b *KafkaBroker) readerConfig(stream string) (kafka.ReaderConfig, error) {
+	mechanism, err := scram.Mechanism(scram.SHA512, b.Username, b.Password)
+	if err != nil {
+		return kafka.ReaderConfig{}, err
+	}
+	return kafka.ReaderConfig{
+		Brokers:     []string{b.Address},
+		GroupID:     stream + "-" + uuid.New().String(),
+		Topic:       "broadcast",
+		Dialer:      &kafka.Dialer{SASLMechanism: mechanism, TLS: &tls.Config{}},
+		StartOffset: kafka.LastOffset,
+	}, nil
+}
+
+// Subscribe reads the broadcast topic and forwards the events of one stream
+// until ctx is done or the broker is closed
+func (b *KafkaBroker) Subscribe(ctx context.Context, stream string) (<-chan Event, error) {
+	config, err := b.readerConfig(stream)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrBrokerClosed
+	}
+	done := b.closing()
+	b.readers.Add(1)
+	b.mu.Unlock()
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+			cancel()
+		}
+	}()
+
+	reader := kafka.NewReader(config)
+
+	events := make(chan Event)
+	go func() {
+		defer b.readers.Done()
+		defer cancel()
+		defer close(events)
+		defer reader.Close()
+		for {
+			// The group is this subscriber's alone, so there is no offset
+			// to commit for anyone to resume from
+			message, err := reader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Error reading message from Kafka: %v", err)
+				}
+				return
+			}
+			// Check if the message key matches the desired stream
+			if string(message.Key) != stream {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal(message.Value, &event); err != nil {
+				log.Printf("Error decoding event from Kafka: %v", err)
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// Close stops every Kafka reader and waits until they are closed.
+// Publishing afterwards fails with ErrBrokerClosed.
+func (b *KafkaBroker) Close() error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.closing())
+	}
+	b.mu.Unlock()
+	b.readers.Wait()
+	return nil
+}
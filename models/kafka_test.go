@@ -0,0 +1,54 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestKafkaPublish tests that events are produced in the body of a POST, so
+// messages with characters special to URLs arrive intact
+func TestKafkaPublish(t *testing.T) {
+	var produced struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); r.Method != http.MethodPost || r.URL.Path != "/produce/broadcast" || user != "user" || pass != "secret" {
+			t.Errorf("Expected an authenticated POST to /produce/broadcast, got %s %s", r.Method, r.URL)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&produced); err != nil {
+			t.Errorf("Error decoding the produced message: %v", err)
+		}
+	}))
+	defer ts.Close()
+	broker := &KafkaBroker{Address: ts.Listener.Addr().String(), Username: "user", Password: "secret", HTTPClient: ts.Client()}
+
+	sent := Event{Type: EventConnected, TableID: "table", Message: "50% off? a/b #1 & more"}
+	if err := broker.Publish(context.Background(), sent); err != nil {
+		t.Fatalf("Error publishing: %v", err)
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(produced.Value), &event); err != nil {
+		t.Fatalf("Error decoding the produced event: %v", err)
+	}
+	if produced.Key != "table" || event.Message != sent.Message {
+		t.Errorf("Expected the event keyed by its table, got %q: %+v", produced.Key, event)
+	}
+}
+
+// TestKafkaSubscribersOwnGroups tests that every subscriber to a stream reads
+// in its own consumer group, so each one is sent every event
+func TestKafkaSubscribersOwnGroups(t *testing.T) {
+	broker := &KafkaBroker{Address: "kafka.example.com:9092", Username: "user", Password: "secret"}
+	first, err := broker.readerConfig("table")
+	if err != nil {
+		t.Fatalf("Error configuring reader: %v", err)
+	}
+	second, _ := broker.readerConfig("table")
+	if first.GroupID == second.GroupID {
+		t.Errorf("Expected two viewers of a table to read in different consumer groups, both got %q", first.GroupID)
+	}
+}
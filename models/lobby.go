@@ -0,0 +1,247 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// States of a table in the lobby
+const (
+	TableWaiting    = "waiting"     // A seat is free
+	TableFull       = "full"        // Both seats are taken and no game is in progress
+	TableInProgress = "in_progress" // A game is being played
+)
+
+// LobbyStream is the stream of table_created, table_updated and table_closed events
+const LobbyStream = "lobby"
+
+// Lobby index keys, maintained by SaveSession and DeleteSession. Only
+// public tables are indexed.
+const (
+	lobbyIndexKey     = "lobby:tables"    // Sorted set of table IDs scored by last activity
+	lobbySummariesKey = "lobby:summaries" // Hash of table ID to JSON TableSummary
+)
+
+// tableStates lists every lobby state, for clearing a table from the state indexes
+var tableStates = []string{TableWaiting, TableFull, TableInProgress}
+
+// MaxLobbyLimit bounds the tables listed at once
+const MaxLobbyLimit = 100
+
+// TableSummary describes a table in the lobby
+type TableSummary struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name,omitempty"`
+	State        string    `json:"state"`
+	Players      []string  `json:"players"`
+	Variant      string    `json:"variant"`
+	Private      bool      `json:"private,omitempty"`
+	Protected    bool      `json:"protected,omitempty"`
+	Seats        int       `json:"seats"`
+	Teams        bool      `json:"teams,omitempty"`
+	Created      time.Time `json:"created"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// LobbyFilter selects and pages the tables listed in the lobby. Empty
+// fields match every table.
+type LobbyFilter struct {
+	State   string
+	Variant string
+	Offset  int
+	Limit   int
+}
+
+// State is the lobby state of the table
+func (s *Session) State() string {
+	switch {
+	case s.Status:
+		return TableInProgress
+	case !s.Full():
+		return TableWaiting
+	}
+	return TableFull
+}
+
+// Variant names the board size of the table, as columns x rows
+func (s *Session) Variant() string {
+	if len(s.Grid) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", len(s.Grid[0]), len(s.Grid))
+}
+
+// Summary describes the table for the lobby
+func (s *Session) Summary() TableSummary {
+	players := make([]string, len(s.Players))
+	for i, player := range s.Players {
+		players[i] = player.Name
+	}
+	return TableSummary{
+		ID:           s.ID,
+		Name:         s.Name,
+		State:        s.State(),
+		Players:      players,
+		Variant:      s.Variant(),
+		Private:      s.Private,
+		Protected:    s.Protected,
+		Seats:        s.MaxPlayers(),
+		Teams:        s.Teams,
+		Created:      s.Created,
+		LastActivity: s.LastActivity,
+	}
+}
+
+// key is the sorted set of the tables passing the filter
+func (f LobbyFilter) key() string {
+	key := lobbyIndexKey
+	if f.State != "" {
+		key += ":state:" + f.State
+	}
+	if f.Variant != "" {
+		key += ":variant:" + f.Variant
+	}
+	return key
+}
+
+// lobbyKeys are the sorted sets listing a table of the given state and
+// variant, one for each combination of filters
+func lobbyKeys(state, variant string) []string {
+	return []string{
+		LobbyFilter{}.key(),
+		LobbyFilter{State: state}.key(),
+		LobbyFilter{Variant: variant}.key(),
+		LobbyFilter{State: state, Variant: variant}.key(),
+	}
+}
+
+// indexSession queues adding a public session to the lobby index, or
+// removing it when it is private
+func indexSession(ctx context.Context, pipe redis.Pipeliner, session *Session) error {
+	unindexSession(ctx, pipe, session.ID, session.Variant())
+	if session.Private {
+		return nil
+	}
+	summary, err := json.Marshal(session.Summary())
+	if err != nil {
+		return err
+	}
+	score := float64(session.LastActivity.UnixMicro())
+	for _, key := range lobbyKeys(session.State(), session.Variant()) {
+		pipe.ZAdd(ctx, key, redis.Z{Score: score, Member: session.ID})
+	}
+	pipe.HSet(ctx, lobbySummariesKey, session.ID, summary)
+	return nil
+}
+
+// unindexSession queues removing a session of the given variant from
+// every lobby index
+func unindexSession(ctx context.Context, pipe redis.Pipeliner, id, variant string) {
+	for _, state := range tableStates {
+		for _, key := range lobbyKeys(state, variant) {
+			pipe.ZRem(ctx, key, id)
+		}
+	}
+	pipe.HDel(ctx, lobbySummariesKey, id)
+}
+
+// QueueDeleteSession queues deleting a session, its lobby entry, name and
+// join code on pipe, for callers deleting inside their own transaction
+func QueueDeleteSession(ctx context.Context, pipe redis.Pipeliner, session *Session) {
+	pipe.Del(ctx, SessionKey(session.ID))
+	unindexSession(ctx, pipe, session.ID, session.Variant())
+	for _, key := range session.aliasKeys() {
+		pipe.Del(ctx, key)
+	}
+}
+
+// ListTables lists the public tables passing the filter, most recently
+// active first, along with how many tables passed it in all. The page is
+// read from the index of the filter, so only the tables listed are loaded.
+// Tables Redis has expired are skipped until the janitor prunes them.
+func ListTables(ctx context.Context, client *redis.Client, filter LobbyFilter) ([]TableSummary, int, error) {
+	tables := []TableSummary{}
+	if filter.Limit < 1 {
+		return tables, 0, nil
+	}
+	key := filter.key()
+	live := liveSince()
+	total, err := client.ZCount(ctx, key, live, "+inf").Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	ids, err := client.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:    live,
+		Max:    "+inf",
+		Offset: int64(filter.Offset),
+		Count:  int64(filter.Limit),
+	}).Result()
+	if err != nil || len(ids) == 0 {
+		return tables, int(total), err
+	}
+	values, err := client.HMGet(ctx, lobbySummariesKey, ids...).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var summary TableSummary
+		if err := json.Unmarshal([]byte(data), &summary); err == nil {
+			tables = append(tables, summary)
+		}
+	}
+	return tables, int(total), nil
+}
+
+// liveSince is the lowest lobby score of a table Redis still holds, as
+// tables are scored by their last save
+func liveSince() string {
+	if SessionTTL <= 0 {
+		return "-inf"
+	}
+	return strconv.FormatInt(time.Now().Add(-SessionTTL).UnixMicro(), 10)
+}
+
+// PruneLobby drops the tables Redis has expired from the lobby index. A
+// table is only dropped once its key is gone, watching it so one saved
+// meanwhile keeps its entry.
+func PruneLobby(ctx context.Context, client *redis.Client) error {
+	if SessionTTL <= 0 {
+		return nil
+	}
+	ids, err := client.ZRangeByScore(ctx, lobbyIndexKey, &redis.ZRangeBy{Min: "-inf", Max: "(" + liveSince()}).Result()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err := client.Watch(ctx, func(tx *redis.Tx) error {
+			exists, err := tx.Exists(ctx, SessionKey(id)).Result()
+			if err != nil || exists > 0 {
+				return err
+			}
+			// The summary tells which variant indexes hold the table
+			var summary TableSummary
+			if data, err := tx.HGet(ctx, lobbySummariesKey, id).Result(); err == nil {
+				json.Unmarshal([]byte(data), &summary)
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				unindexSession(ctx, pipe, id, summary.Variant)
+				return nil
+			})
+			return err
+		}, SessionKey(id))
+		if err != nil && !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return nil
+}
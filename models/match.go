@@ -0,0 +1,109 @@
+package models
+
+import "fmt"
+
+// Match formats of a table
+const (
+	// MatchFirstTo is won by the first player to win Games games
+	MatchFirstTo = "first_to"
+	// MatchBestOf is won by the player who wins most of at most Games games
+	MatchBestOf = "best_of"
+)
+
+// MaxMatchGames bounds the length of a match
+const MaxMatchGames = 99
+
+// Match is a series of games between the players of a table
+type Match struct {
+	Format string `json:"format"`
+	Games  int    `json:"games"`
+	// Score counts the games each player, or team in team play, has won in
+	// the match, by the name they compete under
+	Score  map[string]int `json:"score"`
+	Played int            `json:"played"`
+	Draws  int            `json:"draws"`
+	// Decided is set once the match is over; Winner stays empty for a drawn match
+	Decided bool   `json:"decided"`
+	Winner  string `json:"winner,omitempty"`
+}
+
+// NewMatch creates a match in the given format
+func NewMatch(format string, games int) (*Match, error) {
+	switch format {
+	case MatchFirstTo, MatchBestOf:
+	default:
+		return nil, fmt.Errorf("Unknown match format %q. Use %s or %s.", format, MatchFirstTo, MatchBestOf)
+	}
+	if games < 1 || games > MaxMatchGames {
+		return nil, fmt.Errorf("A match is 1 to %d games", MaxMatchGames)
+	}
+	return &Match{Format: format, Games: games, Score: map[string]int{}}, nil
+}
+
+// SetMatch starts a new match at the table, which must not have a game in progress
+func (s *Session) SetMatch(match *Match) error {
+	if s.Status {
+		return ErrGameInProgress
+	}
+	s.Match = match
+	return nil
+}
+
+// record adds a finished game to the match. Winner is the index of the
+// player who won, or -1 for a draw.
+func (m *Match) record(s *Session, winner int) {
+	if m.Decided {
+		return
+	}
+	m.Played++
+	if winner < 0 {
+		m.Draws++
+	} else {
+		m.Score[s.sideName(winner)]++
+	}
+
+	leader, best, tied := "", -1, false
+	for _, side := range s.Sides() {
+		switch score := m.Score[side]; {
+		case score > best:
+			leader, best, tied = side, score, false
+		case score == best:
+			tied = true
+		}
+	}
+	switch m.Format {
+	case MatchFirstTo:
+		m.Decided = best >= m.Games
+	case MatchBestOf:
+		m.Decided = best > m.Games/2 || m.Played >= m.Games
+	}
+	if m.Decided && !tied {
+		m.Winner = leader
+	}
+}
+
+// MatchScore describes the match at the table, or returns "" when there is none
+func (s *Session) MatchScore() string {
+	m := s.Match
+	if m == nil {
+		return ""
+	}
+	format := fmt.Sprintf("first to %d", m.Games)
+	if m.Format == MatchBestOf {
+		format = fmt.Sprintf("best of %d", m.Games)
+	}
+	score := ""
+	for i, side := range s.Sides() {
+		if i > 0 {
+			score += " - "
+		}
+		score += fmt.Sprintf("%s %d", side, m.Score[side])
+	}
+	switch {
+	case m.Decided && m.Winner != "":
+		return fmt.Sprintf("Match (%s): %s, won by %s", format, score, m.Winner)
+	case m.Decided:
+		return fmt.Sprintf("Match (%s): %s, drawn", format, score)
+	}
+	return fmt.Sprintf("Match (%s): %s", format, score)
+}
@@ -0,0 +1,75 @@
+package models
+
+import "testing"
+
+// TestNewMatch tests that unknown formats and lengths are refused
+func TestNewMatch(t *testing.T) {
+	if _, err := NewMatch("first_to_five", 5); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+	if _, err := NewMatch(MatchBestOf, 0); err == nil {
+		t.Errorf("Expected an error for a match without games")
+	}
+	if _, err := NewMatch(MatchFirstTo, 3); err != nil {
+		t.Errorf("Expected a first to 3 match, got %v", err)
+	}
+}
+
+// TestMatchDecided tests when each format decides the match. Results list
+// the winning seat of each game, -1 for a draw.
+func TestMatchDecided(t *testing.T) {
+	tests := []struct {
+		format  string
+		games   int
+		results []int
+		decided bool
+		winner  string
+	}{
+		{MatchFirstTo, 2, []int{0, 1, -1}, false, ""},
+		{MatchFirstTo, 2, []int{0, 1, -1, 1}, true, "bob"},
+		{MatchBestOf, 3, []int{0, 0}, true, "alice"},
+		{MatchBestOf, 3, []int{0, 1}, false, ""},
+		{MatchBestOf, 3, []int{0, 1, -1}, true, ""},
+		{MatchBestOf, 4, []int{1, 0, 1, -1}, true, "bob"},
+	}
+	for _, test := range tests {
+		session := NewSession("testSession")
+		session.AddPlayer(NewPlayer("alice"))
+		session.AddPlayer(NewPlayer("bob"))
+		match, _ := NewMatch(test.format, test.games)
+		if err := session.SetMatch(match); err != nil {
+			t.Fatalf("Error setting match: %v", err)
+		}
+		for _, winner := range test.results {
+			session.Status = true
+			session.FinishGame(winner)
+		}
+		if match.Decided != test.decided || match.Winner != test.winner {
+			t.Errorf("Expected %s %d after %v to be decided %v for %q, got %v for %q",
+				test.format, test.games, test.results, test.decided, test.winner, match.Decided, match.Winner)
+		}
+	}
+}
+
+// TestMatchScore tests that the match score is described and counts wins
+func TestMatchScore(t *testing.T) {
+	session := NewSession("testSession")
+	session.AddPlayer(NewPlayer("alice"))
+	session.AddPlayer(NewPlayer("bob"))
+	if session.MatchScore() != "" {
+		t.Errorf("Expected no match score without a match")
+	}
+	match, _ := NewMatch(MatchBestOf, 3)
+	session.SetMatch(match)
+	session.Status = true
+	if err := session.SetMatch(match); err == nil {
+		t.Errorf("Expected an error setting a match during a game")
+	}
+	session.FinishGame(1)
+	if score := session.MatchScore(); score != "Match (best of 3): alice 0 - bob 1" {
+		t.Errorf("Expected the score after one game, got %q", score)
+	}
+	if session.Players[1].Wins != 1 {
+		t.Errorf("Expected bob to be credited with the win")
+	}
+}
@@ -1,8 +1,27 @@
 package models
 
+import "time"
+
 type Player struct {
 	Name string `json:"name"`
 	Wins int    `json:"wins"`
+	// Bot is set for players seated through the bot API
+	Bot bool `json:"bot,omitempty"`
+	// AI is the level of the built-in AI playing the seat, 0 for everyone else
+	AI int `json:"ai,omitempty"`
+	// Ready is set once the player asks for the next game at the table
+	Ready bool `json:"ready,omitempty"`
+	// Away is when the player's last connection to the table dropped, nil
+	// while they are connected or have never connected
+	Away *time.Time `json:"away_since,omitempty"`
+	// Symbol and Color are shown for the player's pieces in place of the
+	// seat's piece. They are settled when the player is seated.
+	Symbol string `json:"symbol,omitempty"`
+	Color  string `json:"color,omitempty"`
+	// Avatar is the URL of the player's picture
+	Avatar string `json:"avatar,omitempty"`
+	// Team is the team the player plays for in team play, 1 or 2
+	Team int `json:"team,omitempty"`
 }
 
 func NewPlayer(name string) *Player {
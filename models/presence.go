@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// What happens to a game when a disconnected player's grace period runs out
+const (
+	DisconnectForfeit = "forfeit" // The player loses the game
+	DisconnectPause   = "pause"   // The game waits until they reconnect
+)
+
+// DefaultReconnectGrace is how long a seated player whose connection drops
+// has to come back before the game goes on without them
+const DefaultReconnectGrace = 30 * time.Second
+
+// ErrGamePaused is returned by Play while a disconnected player holds up the game
+var ErrGamePaused = errors.New("The game is paused until every player reconnects")
+
+// ValidateDisconnectAction checks action is one of the disconnect actions
+func ValidateDisconnectAction(action string) error {
+	if action != DisconnectForfeit && action != DisconnectPause {
+		return errors.New("Unknown disconnect action. Use forfeit or pause.")
+	}
+	return nil
+}
+
+// Disconnect records that a seated player lost their connection at now. It
+// reports false for anyone else, including bots, whose move deadlines
+// already cover a lost connection.
+func (s *Session) Disconnect(name string, now time.Time) bool {
+	index := s.PlayerIndex(name)
+	if index == -1 || s.Players[index].Bot || s.Players[index].AI > 0 {
+		return false
+	}
+	s.Players[index].Away = &now
+	return true
+}
+
+// Reconnect records that a disconnected player is back, resuming a paused
+// game once nobody is missing. It reports whether the player was away.
+func (s *Session) Reconnect(name string) bool {
+	index := s.PlayerIndex(name)
+	if index == -1 || s.Players[index].Away == nil {
+		return false
+	}
+	s.Players[index].Away = nil
+	if len(s.Absent()) == 0 {
+		s.Paused = false
+	}
+	return true
+}
+
+// Absent returns the names of the seated players who are disconnected
+func (s *Session) Absent() []string {
+	var names []string
+	for _, player := range s.Players {
+		if player.Away != nil {
+			names = append(names, player.Name)
+		}
+	}
+	return names
+}
+
+// Abandon applies action to the game in progress once name's grace period
+// is over: the player forfeits, or the game is paused until they return
+func (s *Session) Abandon(name, action string) {
+	index := s.PlayerIndex(name)
+	if index == -1 || !s.Status {
+		return
+	}
+	if action == DisconnectPause {
+		s.Paused = true
+		return
+	}
+	s.Forfeit(index)
+}
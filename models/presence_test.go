@@ -0,0 +1,50 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDisconnect tests that a paused game waits for every missing player
+func TestDisconnect(t *testing.T) {
+	session := NewSession("testSession")
+	session.AddPlayer(NewPlayer("alice"))
+	session.AddPlayer(NewPlayer("bob"))
+	bot := NewPlayer("robot")
+	bot.Bot = true
+	session.AddPlayer(bot)
+	if session.Disconnect("robot", time.Now()) || session.Disconnect("carol", time.Now()) {
+		t.Errorf("Expected only seated people to be tracked")
+	}
+	session.Players = session.Players[:2]
+	session.Status = true
+	session.Disconnect("alice", time.Now())
+	session.Disconnect("bob", time.Now())
+	session.Abandon("alice", DisconnectPause)
+	if _, err := session.Play("bob", 0); err != ErrGamePaused {
+		t.Errorf("Expected ErrGamePaused, got %v", err)
+	}
+	if !session.Reconnect("alice") || !session.Paused {
+		t.Errorf("Expected the game to stay paused while bob is away")
+	}
+	if session.Reconnect("alice") {
+		t.Errorf("Expected alice to no longer be away")
+	}
+	session.Reconnect("bob")
+	if session.Paused || len(session.Absent()) != 0 {
+		t.Errorf("Expected the game to resume once everyone is back")
+	}
+}
+
+// TestAbandonForfeit tests that a player who does not come back loses the game
+func TestAbandonForfeit(t *testing.T) {
+	session := NewSession("testSession")
+	session.AddPlayer(NewPlayer("alice"))
+	session.AddPlayer(NewPlayer("bob"))
+	session.Status = true
+	session.Disconnect("bob", time.Now())
+	session.Abandon("bob", DisconnectForfeit)
+	if session.Status || session.Players[0].Wins != 1 {
+		t.Errorf("Expected alice to win the forfeited game")
+	}
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Errors returned when a player ends a game early
+var (
+	ErrNoOpponent   = errors.New("Only a game between two sides can be drawn or claimed")
+	ErrDrawOffered  = errors.New("A draw has already been offered")
+	ErrNoDrawOffer  = errors.New("No draw has been offered")
+	ErrOwnDrawOffer = errors.New("You cannot answer your own draw offer")
+	ErrNotAbandoned = errors.New("Your opponent has not been away long enough to claim the game")
+)
+
+// opponent returns the seat of an opponent of the player at index in a
+// game between two sides: two players, or two teams
+func (s *Session) opponent(index int) (int, error) {
+	switch {
+	case s.Teams:
+		return (index + 1) % len(s.Players), nil
+	case len(s.Players) == 2:
+		return 1 - index, nil
+	}
+	return -1, ErrNoOpponent
+}
+
+// Forfeit ends the game in progress with a win for the other side of the
+// player seated at index. A game between more than two sides ends without
+// a winner.
+func (s *Session) Forfeit(index int) {
+	winner, err := s.opponent(index)
+	if err != nil {
+		winner = -1
+	}
+	s.FinishGame(winner)
+}
+
+// seatInGame returns the seat of a player of the game in progress
+func (s *Session) seatInGame(name string) (int, error) {
+	if !s.Status {
+		return -1, ErrGameNotInProgress
+	}
+	index := s.PlayerIndex(name)
+	if index == -1 {
+		return -1, ErrPlayerNotFound
+	}
+	return index, nil
+}
+
+// Resign ends the game in progress with a win for the named player's
+// opponent, or their opposing team
+func (s *Session) Resign(name string) error {
+	index, err := s.seatInGame(name)
+	if err != nil {
+		return err
+	}
+	s.Forfeit(index)
+	return nil
+}
+
+// OfferDraw offers the opponent a draw. The offer stands until the opponent
+// answers it or makes a move; in team play either opponent may answer.
+func (s *Session) OfferDraw(name string) error {
+	index, err := s.seatInGame(name)
+	if err != nil {
+		return err
+	}
+	if _, err := s.opponent(index); err != nil {
+		return err
+	}
+	if s.DrawOffer != "" {
+		return ErrDrawOffered
+	}
+	s.DrawOffer = name
+	return nil
+}
+
+// answerDraw checks the named player may answer the standing draw offer
+func (s *Session) answerDraw(name string) error {
+	index, err := s.seatInGame(name)
+	if err != nil {
+		return err
+	}
+	if s.DrawOffer == "" {
+		return ErrNoDrawOffer
+	}
+	if s.side(index) == s.side(s.PlayerIndex(s.DrawOffer)) {
+		return ErrOwnDrawOffer
+	}
+	return nil
+}
+
+// AcceptDraw accepts the opponent's draw offer, ending the game without a winner
+func (s *Session) AcceptDraw(name string) error {
+	if err := s.answerDraw(name); err != nil {
+		return err
+	}
+	s.FinishGame(-1)
+	return nil
+}
+
+// DeclineDraw turns down the opponent's draw offer and the game goes on
+func (s *Session) DeclineDraw(name string) error {
+	if err := s.answerDraw(name); err != nil {
+		return err
+	}
+	s.DrawOffer = ""
+	return nil
+}
+
+// ClaimAbandonment ends the game with a win for the named player, and their
+// teammate, once an opponent has been disconnected for at least grace
+func (s *Session) ClaimAbandonment(name string, now time.Time, grace time.Duration) error {
+	index, err := s.seatInGame(name)
+	if err != nil {
+		return err
+	}
+	if _, err := s.opponent(index); err != nil {
+		return err
+	}
+	for i, player := range s.Players {
+		if s.side(i) != s.side(index) && player.Away != nil && now.Sub(*player.Away) >= grace {
+			s.FinishGame(index)
+			return nil
+		}
+	}
+	return ErrNotAbandoned
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// newTwoPlayerGame returns a session with a game between alice and bob in progress
+func newTwoPlayerGame() *Session {
+	session := NewSession("testSession")
+	session.AddPlayer(NewPlayer("alice"))
+	session.AddPlayer(NewPlayer("bob"))
+	session.Status = true
+	return session
+}
+
+// TestResign tests that resigning hands the win to the opponent
+func TestResign(t *testing.T) {
+	session := newTwoPlayerGame()
+	if err := session.Resign("carol"); err != ErrPlayerNotFound {
+		t.Errorf("Expected ErrPlayerNotFound, got %v", err)
+	}
+	if err := session.Resign("alice"); err != nil {
+		t.Fatalf("Error resigning: %v", err)
+	}
+	if session.Status || session.Players[1].Wins != 1 {
+		t.Errorf("Expected bob to win the game")
+	}
+	if err := session.Resign("bob"); err != ErrGameNotInProgress {
+		t.Errorf("Expected ErrGameNotInProgress, got %v", err)
+	}
+}
+
+// TestDrawOffer tests offering, declining and accepting draws
+func TestDrawOffer(t *testing.T) {
+	session := newTwoPlayerGame()
+	if err := session.AcceptDraw("bob"); err != ErrNoDrawOffer {
+		t.Errorf("Expected ErrNoDrawOffer, got %v", err)
+	}
+	session.OfferDraw("alice")
+	if err := session.OfferDraw("bob"); err != ErrDrawOffered {
+		t.Errorf("Expected ErrDrawOffered, got %v", err)
+	}
+	if err := session.AcceptDraw("alice"); err != ErrOwnDrawOffer {
+		t.Errorf("Expected ErrOwnDrawOffer, got %v", err)
+	}
+	if err := session.DeclineDraw("bob"); err != nil || session.DrawOffer != "" || !session.Status {
+		t.Errorf("Expected the game to go on after declining, got %v", err)
+	}
+
+	session.OfferDraw("alice")
+	session.Turn = 1
+	session.Play("bob", 0)
+	if session.DrawOffer != "" {
+		t.Errorf("Expected bob's move to turn down the offer")
+	}
+	session.OfferDraw("bob")
+	if err := session.AcceptDraw("alice"); err != nil {
+		t.Fatalf("Error accepting draw: %v", err)
+	}
+	if session.Status || session.Players[0].Wins != 0 || session.Players[1].Wins != 0 || session.DrawOffer != "" {
+		t.Errorf("Expected a finished game without a winner")
+	}
+}
+
+// TestClaimAbandonment tests that a game is only won by claim once the
+// opponent has been away for the grace period
+func TestClaimAbandonment(t *testing.T) {
+	session := newTwoPlayerGame()
+	now := time.Now()
+	if err := session.ClaimAbandonment("alice", now, time.Minute); err != ErrNotAbandoned {
+		t.Errorf("Expected ErrNotAbandoned while bob is connected, got %v", err)
+	}
+	session.Disconnect("bob", now.Add(-30*time.Second))
+	if err := session.ClaimAbandonment("alice", now, time.Minute); err != ErrNotAbandoned {
+		t.Errorf("Expected ErrNotAbandoned during the grace period, got %v", err)
+	}
+	if err := session.ClaimAbandonment("alice", now.Add(time.Minute), time.Minute); err != nil {
+		t.Fatalf("Error claiming the game: %v", err)
+	}
+	if session.Status || session.Players[0].Wins != 1 {
+		t.Errorf("Expected alice to win the abandoned game")
+	}
+}
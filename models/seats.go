@@ -0,0 +1,129 @@
+package models
+
+import (
+	"errors"
+	"slices"
+)
+
+// Limits on the number of players a table seats
+const (
+	MinSeats  = 2
+	MaxSeats  = 4
+	TeamSeats = 4 // Team play is two teams of two
+)
+
+// Errors returned by SetSeats
+var (
+	ErrSeats     = errors.New("A table seats 2 to 4 players")
+	ErrTeamSeats = errors.New("Team play needs exactly four seats")
+)
+
+// SetSeats sets how many players the table takes and whether they play in
+// two teams. Seats may be 0 for the default, which is four in team play
+// and two otherwise.
+func (s *Session) SetSeats(seats int, teams bool) error {
+	if seats == 0 {
+		seats = MinSeats
+		if teams {
+			seats = TeamSeats
+		}
+	}
+	if seats < MinSeats || seats > MaxSeats {
+		return ErrSeats
+	}
+	if teams && seats != TeamSeats {
+		return ErrTeamSeats
+	}
+	s.Seats = seats
+	s.Teams = teams
+	if seats == MinSeats {
+		s.Seats = 0 // Two seats is how every table started out
+	}
+	return nil
+}
+
+// MaxPlayers returns how many players the table seats
+func (s *Session) MaxPlayers() int {
+	if s.Seats == 0 {
+		return MinSeats
+	}
+	return s.Seats
+}
+
+// Full reports whether every seat of the table is taken
+func (s *Session) Full() bool {
+	return len(s.Players) >= s.MaxPlayers()
+}
+
+// side returns the team of the player seated at index in team play, and
+// the seat itself otherwise
+func (s *Session) side(index int) int {
+	if !s.Teams {
+		return index
+	}
+	if index >= len(s.Players) {
+		return index % 2 // Empty seats alternate between the teams like taken ones
+	}
+	return s.Players[index].Team - 1
+}
+
+// joinTeam seats player in the team with fewer players and returns their
+// seat. Teammates sit across the table from each other, so the seats are
+// reordered for the teams to take turns.
+func (s *Session) joinTeam(player *Player) int {
+	var teams [2][]*Player
+	for _, seated := range s.Players {
+		teams[seated.Team-1] = append(teams[seated.Team-1], seated)
+	}
+	player.Team = 1
+	if len(teams[1]) < len(teams[0]) {
+		player.Team = 2
+	}
+	teams[player.Team-1] = append(teams[player.Team-1], player)
+	s.Players = s.Players[:0]
+	for i := 0; i < len(teams[0]) || i < len(teams[1]); i++ {
+		for _, members := range teams {
+			if i < len(members) {
+				s.Players = append(s.Players, members[i])
+			}
+		}
+	}
+	return slices.Index(s.Players, player)
+}
+
+// Symbol returns the symbol played by the player seated at index. Teammates share a symbol.
+func (s *Session) Symbol(index int) string {
+	return PlayerSymbol(s.side(index))
+}
+
+// Sides returns the names the players of the table compete under: each
+// player's own name, or in team play the names of both teammates
+func (s *Session) Sides() []string {
+	if !s.Teams {
+		names := make([]string, len(s.Players))
+		for i, player := range s.Players {
+			names[i] = player.Name
+		}
+		return names
+	}
+	names := make([]string, 2)
+	for i, player := range s.Players {
+		if side := s.side(i); names[side] == "" {
+			names[side] = player.Name
+		} else {
+			names[side] += " & " + player.Name
+		}
+	}
+	return names
+}
+
+// sideName returns the name the player seated at index competes under
+func (s *Session) sideName(index int) string {
+	return s.Sides()[s.side(index)]
+}
+
+// TwoSided reports whether games at the table are between two sides, two
+// players or two teams, as game notation and the engine expect
+func (s *Session) TwoSided() bool {
+	return s.Teams || s.MaxPlayers() == MinSeats
+}
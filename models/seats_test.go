@@ -0,0 +1,130 @@
+package models
+
+import "testing"
+
+// newTeamGame returns a session with a team game in progress, alice and
+// carol playing X against bob and dave playing O
+func newTeamGame() *Session {
+	session := NewSession("testSession")
+	session.SetSeats(0, true)
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		session.AddPlayer(NewPlayer(name))
+	}
+	session.Status = true
+	return session
+}
+
+// TestSetSeats tests the seat counts a table accepts
+func TestSetSeats(t *testing.T) {
+	session := NewSession("testSession")
+	if err := session.SetSeats(5, false); err != ErrSeats {
+		t.Errorf("Expected ErrSeats, got %v", err)
+	}
+	if err := session.SetSeats(3, true); err != ErrTeamSeats {
+		t.Errorf("Expected ErrTeamSeats, got %v", err)
+	}
+	if err := session.SetSeats(3, false); err != nil || session.MaxPlayers() != 3 || session.TwoSided() {
+		t.Errorf("Expected a three player table, got %v", err)
+	}
+	if err := session.SetSeats(0, true); err != nil || session.MaxPlayers() != 4 || !session.TwoSided() {
+		t.Errorf("Expected a four seat team table, got %v", err)
+	}
+	if err := session.SetSeats(2, false); err != nil || session.Seats != 0 {
+		t.Errorf("Expected two seats to be stored as the default, got %d", session.Seats)
+	}
+}
+
+// TestSymbols tests that every player has a symbol of their own unless teammates share one
+func TestSymbols(t *testing.T) {
+	session := NewSession("testSession")
+	session.SetSeats(4, false)
+	for i, want := range []string{"X", "O", "#", "@"} {
+		if symbol := session.Symbol(i); symbol != want {
+			t.Errorf("Expected seat %d to play %s, got %s", i, want, symbol)
+		}
+	}
+	session = newTeamGame()
+	for i, want := range []string{"X", "O", "X", "O"} {
+		if symbol := session.Symbol(i); symbol != want {
+			t.Errorf("Expected seat %d to play %s in team play, got %s", i, want, symbol)
+		}
+	}
+}
+
+// TestTeamWin tests that a win counts for both teammates and for the team's match score
+func TestTeamWin(t *testing.T) {
+	session := newTeamGame()
+	match, _ := NewMatch(MatchFirstTo, 1)
+	session.Match = match
+	session.FinishGame(2)
+	for i, want := range []int{1, 0, 1, 0} {
+		if wins := session.Players[i].Wins; wins != want {
+			t.Errorf("Expected %s to have %d wins, got %d", session.Players[i].Name, want, wins)
+		}
+	}
+	if !match.Decided || match.Winner != "alice & carol" {
+		t.Errorf("Expected the team of alice and carol to win the match, got %+v", match)
+	}
+}
+
+// TestTeamForfeit tests forfeits and draw offers between teams and free for all players
+func TestTeamForfeit(t *testing.T) {
+	session := newTeamGame()
+	session.OfferDraw("alice")
+	if err := session.AcceptDraw("carol"); err != ErrOwnDrawOffer {
+		t.Errorf("Expected a teammate to be unable to accept, got %v", err)
+	}
+	if err := session.Resign("dave"); err != nil {
+		t.Fatalf("Error resigning: %v", err)
+	}
+	if session.Players[0].Wins != 1 || session.Players[2].Wins != 1 {
+		t.Errorf("Expected the other team to win when dave resigns")
+	}
+
+	session = NewSession("testSession")
+	session.SetSeats(3, false)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		session.AddPlayer(NewPlayer(name))
+	}
+	session.Status = true
+	if err := session.OfferDraw("alice"); err != ErrNoOpponent {
+		t.Errorf("Expected ErrNoOpponent offering a draw to two players, got %v", err)
+	}
+	session.Forfeit(0)
+	if session.Status || session.Players[1].Wins != 0 || session.Players[2].Wins != 0 {
+		t.Errorf("Expected a forfeit between three players to end the game without a winner")
+	}
+}
+
+// TestTeamLeave tests that players keep their team, piece and color when a
+// player leaves a team table, and that the player taking the seat joins
+// the short-handed team
+func TestTeamLeave(t *testing.T) {
+	session := newTeamGame()
+	session.Status = false
+	carol, dave := session.Players[2], session.Players[3]
+	session.RemovePlayer(session.PlayerIndex("bob"))
+	if symbol := session.Symbol(session.PlayerIndex("carol")); symbol != "X" {
+		t.Errorf("Expected carol to keep playing X, got %s", symbol)
+	}
+	if symbol := session.Symbol(session.PlayerIndex("dave")); symbol != "O" {
+		t.Errorf("Expected dave to keep playing O, got %s", symbol)
+	}
+	if sides := session.Sides(); sides[0] != "alice & carol" || sides[1] != "dave" {
+		t.Errorf("Expected alice & carol against dave, got %v", sides)
+	}
+
+	session.AddPlayer(NewPlayer("erin"))
+	for i, want := range []string{"X", "O", "X", "O"} {
+		if symbol := session.Symbol(i); symbol != want {
+			t.Errorf("Expected seat %d to play %s after erin joined, got %s", i, want, symbol)
+		}
+	}
+	if sides := session.Sides(); sides[0] != "alice & carol" || sides[1] != "dave & erin" {
+		t.Errorf("Expected alice & carol against dave & erin, got %v", sides)
+	}
+	erin := session.Players[session.PlayerIndex("erin")]
+	if erin.Symbol != dave.Symbol || erin.Color != dave.Color || carol.Color == dave.Color {
+		t.Errorf("Expected erin to take on the appearance of dave, got %s %s", erin.Symbol, erin.Color)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/redis/go-redis/v9"
 	"strings"
+	"time"
 )
 
 type Session struct {
@@ -16,108 +17,121 @@ type Session struct {
 	Players       []*Player  `json:"players"`
 	Grid          [][]string `json:"grid"` // Representing the Connect Four grid
 	Starts        int
-	OccupiedSlots int `json:"occupied_slots"` // Counter for the number of occupied slots
+	OccupiedSlots int    `json:"occupied_slots"`       // Counter for the number of occupied slots
+	Moves         []Move `json:"moves"`                // Pieces dropped in the current game, in order
+	Tournament    string `json:"tournament,omitempty"` // ID of the tournament the table's match belongs to
+	Match         *Match `json:"match,omitempty"`      // Series of games the table is playing, if any
+	Private       bool   `json:"private,omitempty"`    // Hidden from the lobby's public listing
+	Name          string `json:"name,omitempty"`       // Human-friendly name, unique among tables
+	Code          string `json:"code,omitempty"`       // Short join code, unique among tables
+	Protected     bool   `json:"protected,omitempty"`  // Joining requires the table's password
+	// Host is the seated player holding the host role; empty while the
+	// table's creator holds it from outside, or when the table has no host
+	Host   string   `json:"host,omitempty"`
+	Locked bool     `json:"locked,omitempty"` // No one may take a seat
+	Banned []string `json:"banned,omitempty"` // Names the host kicked from the table
+	Paused bool     `json:"paused,omitempty"` // The game waits for a disconnected player
+	// DrawOffer is the player whose draw offer awaits an answer
+	DrawOffer string `json:"draw_offer,omitempty"`
+	// Seats is how many players the table takes, two when unset. Teams
+	// pairs the players of a four seat table into two teams.
+	Seats int  `json:"seats,omitempty"`
+	Teams bool `json:"teams,omitempty"`
+	// FirstMove is the policy choosing who moves first in each game, and
+	// FirstPlayer the player the host chose under FirstMoveHost
+	FirstMove   string    `json:"first_move,omitempty"`
+	FirstPlayer string    `json:"first_player,omitempty"`
+	Handicap    *Handicap `json:"handicap,omitempty"`
+	// Loser is a player on the losing side of the last game won
+	Loser string `json:"loser,omitempty"`
+	// Created and LastActivity are set by SaveSession
+	Created      time.Time `json:"created"`
+	LastActivity time.Time `json:"last_activity"`
+
+	// passwordHash and hostTokenHash are kept in the store by SaveSession
+	// but never sent to clients
+	passwordHash  string
+	hostTokenHash string
 }
 
-const (
-	EmptySlot     = " "
-	Player1Symbol = "X" // Symbol for player 1
-	Player2Symbol = "O" // Symbol for player 2
-)
-
-func (s *Session) StatusBoard(announcement string) string {
-	var sb strings.Builder
-
-	// Calculate the length of the longest string in each section
-	var maxLengthID, maxLengthStatus, maxLengthPlayers int
-	for _, player := range s.Players {
-		if len(player.Name) > maxLengthPlayers {
-			maxLengthPlayers = len(player.Name)
-		}
-	}
-	maxLengthID = len(s.ID)
-	maxLengthStatus = len("Game is in Progress")
-	if !s.Status {
-		maxLengthStatus = len("Game has not started")
-	}
-	// Define the fixed width of the box
-	boxWidth := 60 // Adjust this value as needed
-	// Top line of the box
-	sb.WriteString("┌")
-	sb.WriteString(strings.Repeat("─", boxWidth-2))
-	sb.WriteString("┐\n")
-
-	// Session ID
-	sessionid := fmt.Sprintf("│ Session ID: %-"+fmt.Sprintf("%d", maxLengthID)+"s ", s.ID)
-	sb.WriteString(sessionid)
-	sb.WriteString(strings.Repeat(" ", boxWidth-len(sessionid)+1))
-	sb.WriteString("│\n")
-
-	// Game status
-	status := "Game is in Progress"
-	if !s.Status {
-		status = "Game has not started"
-	}
+// storedSession is a session as kept in Redis
+type storedSession struct {
+	*Session
+	PasswordHash  string `json:"password_hash,omitempty"`
+	HostTokenHash string `json:"host_token_hash,omitempty"`
+}
 
-	stat := fmt.Sprintf("│ Status: %-"+fmt.Sprintf("%d", maxLengthStatus)+"s ", status)
-	sb.WriteString(stat)
-	sb.WriteString(strings.Repeat(" ", boxWidth-len(stat)+1))
-	sb.WriteString("│\n")
+// DefaultSessionTTL is how long an untouched table is kept in Redis
+const DefaultSessionTTL = 24 * time.Hour
 
-	// Player list
-	playerList := strings.Join(func() []string {
-		var names []string
-		for _, v := range s.Players {
-			names = append(names, v.Name)
-		}
-		return names
-	}(), ", ")
-	players := fmt.Sprintf("│ Player List: %-"+fmt.Sprintf("%d", maxLengthPlayers)+"s ", playerList)
-	sb.WriteString(players)
-	sb.WriteString(strings.Repeat(" ", boxWidth-len(players)+1))
-	sb.WriteString("│\n")
-
-	// Current turn
-	if s.Status {
-		currentplayer := s.Players[s.Turn].Name
-		turn := fmt.Sprintf("│ Current Turn: %s", currentplayer)
-		sb.WriteString(turn)
-		sb.WriteString(strings.Repeat(" ", boxWidth-len(turn)+1))
-		sb.WriteString("│\n")
-	}
+// SessionTTL is the expiry SaveSession gives a table, refreshed on every save.
+// Zero keeps tables until they are deleted.
+var SessionTTL = DefaultSessionTTL
 
-	// Players and their symbols
-	for i, player := range s.Players {
-		symbol := ""
-		if i == 0 {
-			symbol = Player1Symbol // Assign symbol "X" to the first player
-		} else if i == 1 {
-			symbol = Player2Symbol // Assign symbol "O" to the second player
-		}
+// Defaults for closing tables nobody uses
+const (
+	DefaultIdleTimeout      = time.Hour
+	DefaultAbandonedTimeout = 10 * time.Minute
+	DefaultJanitorInterval  = time.Minute
+)
 
-		playerInfo := fmt.Sprintf("| Player: %s - Symbol: %s - Wins: %d", player.Name, symbol, player.Wins)
-		sb.WriteString(playerInfo)
-		sb.WriteString(strings.Repeat(" ", boxWidth-len(playerInfo)-1))
-		sb.WriteString("│\n")
-	}
+// Move records where a piece landed
+type Move struct {
+	Column int    `json:"column"`
+	Row    int    `json:"row"`
+	Symbol string `json:"symbol"`
+}
 
-	// Middle line of the box
+// Cell is a position on the grid, rows counted from the top
+type Cell struct {
+	Row    int `json:"row"`
+	Column int `json:"column"`
+}
 
-	// Announcement
-	sb.WriteString(fmt.Sprintf("│ %-"+fmt.Sprintf("%d", boxWidth-4)+"s │\n", announcement))
+// Errors returned by Play
+var (
+	ErrGameNotInProgress = errors.New("Game is not in progress. Please start the game first")
+	ErrPlayerNotFound    = errors.New("Player not found in the table")
+	ErrGameInProgress    = errors.New("Game is currently in progress. Please wait until the game is over")
+)
 
-	// Bottom line of the box
-	sb.WriteString("└")
-	sb.WriteString(strings.Repeat("─", boxWidth-2))
-	sb.WriteString("┘\n")
+const (
+	EmptySlot     = " "
+	Player1Symbol = "X" // Symbol for player 1
+	Player2Symbol = "O" // Symbol for player 2
+	Player3Symbol = "#" // Symbol for player 3
+	Player4Symbol = "@" // Symbol for player 4
+)
 
-	return sb.String()
+// PlayerSymbol returns the symbol played by the player seated at index
+// when every player has a symbol of their own
+func PlayerSymbol(index int) string {
+	switch index {
+	case 0:
+		return Player1Symbol
+	case 1:
+		return Player2Symbol
+	case 2:
+		return Player3Symbol
+	case 3:
+		return Player4Symbol
+	}
+	return ""
 }
 
+// Typical dimensions of a Connect Four grid
+const (
+	DefaultWidth  = 7
+	DefaultHeight = 6
+)
+
 // Initialize the Connect Four grid
 func NewSession(id string) *Session {
-	width := 7  // Typical width for Connect Four
-	height := 6 // Typical height for Connect Four
+	return NewSessionWithSize(id, DefaultWidth, DefaultHeight)
+}
+
+// NewSessionWithSize initializes a session with a width x height grid
+func NewSessionWithSize(id string, width, height int) *Session {
 	grid := make([][]string, height)
 	for i := range grid {
 		grid[i] = make([]string, width)
@@ -138,6 +152,7 @@ func NewSession(id string) *Session {
 
 func (s *Session) ClearBoard() {
 	s.OccupiedSlots = 0
+	s.Moves = nil
 	for r := 0; r <= len(s.Grid)-1; r++ {
 		for c := 0; c <= len(s.Grid[0])-1; c++ {
 			s.Grid[r][c] = EmptySlot
@@ -145,8 +160,35 @@ func (s *Session) ClearBoard() {
 	}
 }
 
+// StartingBoard returns a copy of the table as its current game began:
+// the grid without the moves played since, which keeps pieces placed by a
+// handicap or an imported position, and the first mover's turn
+func (s *Session) StartingBoard() *Session {
+	start := NewSessionWithSize(s.ID, len(s.Grid[0]), len(s.Grid))
+	start.Players, start.Seats, start.Teams = s.Players, s.Seats, s.Teams
+	for r := range s.Grid {
+		copy(start.Grid[r], s.Grid[r])
+	}
+	start.OccupiedSlots = s.OccupiedSlots - len(s.Moves)
+	for _, move := range s.Moves {
+		start.Grid[move.Row][move.Column] = EmptySlot
+	}
+	if n := len(s.Players); n > 0 {
+		start.Turn = ((s.Turn-len(s.Moves))%n + n) % n
+	}
+	return start
+}
+
+// AddPlayer seats a player, settling the symbol and color they chose
+// against those of the other players
 func (s *Session) AddPlayer(player *Player) error {
-	s.Players = append(s.Players, player)
+	index := len(s.Players)
+	if s.Teams {
+		index = s.joinTeam(player)
+	} else {
+		s.Players = append(s.Players, player)
+	}
+	s.settleAppearance(index)
 	return nil
 }
 
@@ -160,6 +202,7 @@ func (s *Session) DropPiece(column int, playerSymbol string) error {
 		if s.Grid[i][column] == EmptySlot {
 			s.Grid[i][column] = playerSymbol
 			s.OccupiedSlots++ // Increment the counter
+			s.Moves = append(s.Moves, Move{Column: column, Row: i, Symbol: playerSymbol})
 			return nil
 		}
 	}
@@ -168,6 +211,104 @@ func (s *Session) DropPiece(column int, playerSymbol string) error {
 	return errors.New("no more slots available in the column")
 }
 
+// PlayerIndex returns the seat of the named player, or -1 if they are not at the table
+func (s *Session) PlayerIndex(name string) int {
+	for i, player := range s.Players {
+		if player.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Play drops the named player's piece into column, enforcing the rules of a
+// game in progress. It advances the turn and ends the game on a win, which is
+// credited to the player, or on a full board.
+func (s *Session) Play(playerName string, column int) (*Move, error) {
+	if !s.Status {
+		return nil, ErrGameNotInProgress
+	}
+	if s.Paused {
+		return nil, ErrGamePaused
+	}
+	playerIndex := s.PlayerIndex(playerName)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
+	}
+	if turnname := s.GetPlayersTurn(); turnname != playerName {
+		return nil, fmt.Errorf("It is %s's turn. Please wait until %s plays their move.", turnname, turnname)
+	}
+	playerSymbol := s.Symbol(playerIndex)
+	if playerSymbol == "" {
+		return nil, errors.New("unexpected player index")
+	}
+	if forced, ok := s.ForcedColumn(playerIndex); ok && column != forced {
+		return nil, ErrHandicapColumn
+	}
+	if err := s.DropPiece(column, playerSymbol); err != nil {
+		return nil, err
+	}
+	s.Turn = (s.Turn + 1) % len(s.Players)
+	// Moving instead of answering turns down the opponent's draw offer
+	if s.DrawOffer != playerName {
+		s.DrawOffer = ""
+	}
+
+	if s.CheckWin(playerSymbol) {
+		s.FinishGame(playerIndex)
+	} else if s.IsBoardFull() {
+		s.FinishGame(-1)
+	}
+	return s.LastMove(), nil
+}
+
+// FinishGame ends the game in progress, crediting the player seated at
+// winner and their teammate with the win, or nobody when winner is -1, and
+// counting the game towards the table's match
+func (s *Session) FinishGame(winner int) {
+	s.Status = false
+	s.Paused = false
+	s.DrawOffer = ""
+	s.recordLoser(winner)
+	if winner >= 0 {
+		for i, player := range s.Players {
+			if s.side(i) == s.side(winner) {
+				player.AddWin()
+			}
+		}
+	}
+	if s.Match != nil {
+		s.Match.record(s, winner)
+	}
+}
+
+// SetReady marks a seated player as ready for the next game
+func (s *Session) SetReady(name string) error {
+	if s.Status {
+		return ErrGameInProgress
+	}
+	index := s.PlayerIndex(name)
+	if index == -1 {
+		return ErrPlayerNotFound
+	}
+	s.Players[index].Ready = true
+	return nil
+}
+
+// AllReady reports whether every seat is taken by a player ready for the
+// next game. The built-in AI is always ready.
+func (s *Session) AllReady() bool {
+	if !s.Full() {
+		return false
+	}
+	for _, player := range s.Players {
+		if !player.Ready && player.AI == 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // IsBoardFull checks if the Connect Four board is completely filled
 func (s *Session) IsBoardFull() bool {
 	return s.OccupiedSlots == len(s.Grid)*len(s.Grid[0]) // Check if all slots are occupied
@@ -212,6 +353,41 @@ func (s *Session) CheckWin(playerSymbol string) bool {
 	return false
 }
 
+// LastMove returns the most recent move of the current game, or nil before the first drop
+func (s *Session) LastMove() *Move {
+	if len(s.Moves) == 0 {
+		return nil
+	}
+	return &s.Moves[len(s.Moves)-1]
+}
+
+// WinningLine returns the cells of a four in a row on the board, or nil if there is none
+func (s *Session) WinningLine() []Cell {
+	directions := [][2]int{{0, 1}, {1, 0}, {1, 1}, {-1, 1}}
+	for i := range s.Grid {
+		for j := range s.Grid[i] {
+			symbol := s.Grid[i][j]
+			if symbol == EmptySlot {
+				continue
+			}
+			for _, d := range directions {
+				line := []Cell{{Row: i, Column: j}}
+				for k := 1; k < 4; k++ {
+					r, c := i+d[0]*k, j+d[1]*k
+					if r < 0 || r >= len(s.Grid) || c >= len(s.Grid[r]) || s.Grid[r][c] != symbol {
+						break
+					}
+					line = append(line, Cell{Row: r, Column: c})
+				}
+				if len(line) == 4 {
+					return line
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // StringBoard returns a string representation of the Connect Four board
 func (s *Session) StringBoard() string {
 	var sb strings.Builder
@@ -254,40 +430,140 @@ func (s *Session) GetPlayersTurn() string {
 	return currentPlayer.Name
 }
 
+// Expired reports whether the table has gone without activity for longer
+// than idle, or for longer than abandoned with nobody seated
+func (s *Session) Expired(now time.Time, idle, abandoned time.Duration) bool {
+	quiet := now.Sub(s.LastActivity)
+	if len(s.Players) == 0 && abandoned > 0 && quiet > abandoned {
+		return true
+	}
+	return idle > 0 && quiet > idle
+}
+
+// Snapshot returns a deep copy of the session
+func (s *Session) Snapshot() *Session {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return s
+	}
+	var snapshot Session
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return s
+	}
+	return &snapshot
+}
+
 // GRAB AND SAVE SESSIONS
 
+// SessionKeyPrefix namespaces table keys in Redis
+const SessionKeyPrefix = "table:"
+
+// SessionKey is the Redis key of a table
+func SessionKey(id string) string {
+	return SessionKeyPrefix + id
+}
+
+// ErrUpdateConflict is returned by UpdateSession when the table kept
+// changing while it was being updated
+var ErrUpdateConflict = errors.New("The table changed while it was being updated. Please try again.")
+
+// maxUpdateAttempts bounds how often UpdateSession retries an update
+const maxUpdateAttempts = 5
+
 func GetSession(ctx context.Context, id string, client *redis.Client) (*Session, error) {
-	val, err := client.Get(ctx, id).Result()
+	val, err := client.Get(ctx, SessionKey(id)).Result()
 	if err != nil {
 		return nil, err
 	}
+	return decodeSession(val)
+}
 
-	// Deserialize the JSON-encoded value into a Session struct
+// decodeSession deserializes a session as stored in Redis
+func decodeSession(val string) (*Session, error) {
 	var session Session
-	if err := json.Unmarshal([]byte(val), &session); err != nil {
+	stored := storedSession{Session: &session}
+	if err := json.Unmarshal([]byte(val), &stored); err != nil {
 		return nil, err
 	}
+	session.passwordHash = stored.PasswordHash
+	session.hostTokenHash = stored.HostTokenHash
 	return &session, nil
 }
 
+// SaveSession stores the session, marking it active and refreshing its expiry
 func SaveSession(ctx context.Context, session *Session, client *redis.Client) error {
-	data, err := json.Marshal(session)
-	if err != nil {
-		return err
+	// Save the JSON-encoded data to Redis along with its lobby entry
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return queueSaveSession(ctx, pipe, session)
+	})
+	return err
+}
+
+// UpdateSession applies update to the latest version of a session and saves
+// the result, watching the table so a concurrent save is never overwritten:
+// if one happens, update runs again on the session as it is then. When
+// update returns an error nothing is saved and the error is returned along
+// with the session update saw.
+func UpdateSession(ctx context.Context, id string, client *redis.Client, update func(*Session) error) (*Session, error) {
+	key := SessionKey(id)
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		var session *Session
+		err := client.Watch(ctx, func(tx *redis.Tx) error {
+			val, err := tx.Get(ctx, key).Result()
+			if err != nil {
+				return err
+			}
+			if session, err = decodeSession(val); err != nil {
+				return err
+			}
+			if err := update(session); err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				return queueSaveSession(ctx, pipe, session)
+			})
+			return err
+		}, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		return session, err
 	}
-	// Save the JSON-encoded data to Redis
-	if err := client.Set(ctx, session.ID, data, 0).Err(); err != nil {
+	return nil, ErrUpdateConflict
+}
+
+// queueSaveSession queues storing the session on pipe, marking it active
+func queueSaveSession(ctx context.Context, pipe redis.Pipeliner, session *Session) error {
+	now := time.Now().UTC()
+	if session.Created.IsZero() {
+		session.Created = now
+	}
+	session.LastActivity = now
+	data, err := json.Marshal(storedSession{
+		Session:       session,
+		PasswordHash:  session.passwordHash,
+		HostTokenHash: session.hostTokenHash,
+	})
+	if err != nil {
 		return err
 	}
-
-	return nil
+	pipe.Set(ctx, SessionKey(session.ID), data, SessionTTL)
+	refreshAliases(ctx, pipe, session, SessionTTL)
+	return indexSession(ctx, pipe, session)
 }
 
 // DeleteSession deletes a Connect 4 session from Redis.
 func DeleteSession(ctx context.Context, sessionID string, client *redis.Client) error {
-	// Delete the session from Redis
-	if err := client.Del(ctx, sessionID).Err(); err != nil {
+	session, err := GetSession(ctx, sessionID, client)
+	if errors.Is(err, redis.Nil) {
+		session = &Session{ID: sessionID}
+	} else if err != nil {
 		return err
 	}
-	return nil
+	// Delete the session from Redis along with its lobby entry
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		QueueDeleteSession(ctx, pipe, session)
+		return nil
+	})
+	return err
 }
@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 )
 
 // TestNewSession tests the NewSession function
@@ -67,3 +68,122 @@ func TestCheckWin(t *testing.T) {
 		t.Errorf("Expected a win for player 1")
 	}
 }
+
+// TestWinningLine tests that the winning cells and last move are reported
+func TestWinningLine(t *testing.T) {
+	session := NewSession("testSession")
+	for _, column := range []int{0, 1, 2} {
+		session.DropPiece(column, Player1Symbol)
+		session.DropPiece(column, Player2Symbol)
+	}
+	if session.WinningLine() != nil {
+		t.Errorf("Expected no winning line yet")
+	}
+	session.DropPiece(3, Player1Symbol)
+
+	line := session.WinningLine()
+	if len(line) != 4 {
+		t.Fatalf("Expected a winning line of 4 cells, got %v", line)
+	}
+	for i, cell := range line {
+		if cell.Row != 5 || cell.Column != i {
+			t.Errorf("Expected cell %d to be row 5 column %d, got %v", i, i, cell)
+		}
+	}
+	if last := session.LastMove(); last == nil || last.Column != 3 || last.Row != 5 {
+		t.Errorf("Expected last move in column 3 row 5, got %v", last)
+	}
+	session.ClearBoard()
+	if session.LastMove() != nil {
+		t.Errorf("Expected no moves after clearing the board")
+	}
+}
+
+// TestPlay tests that Play enforces turns and ends the game on a win
+func TestPlay(t *testing.T) {
+	session := NewSession("testSession")
+	session.AddPlayer(NewPlayer("alice"))
+	session.AddPlayer(NewPlayer("bob"))
+	if _, err := session.Play("alice", 0); err != ErrGameNotInProgress {
+		t.Errorf("Expected ErrGameNotInProgress before the game starts, got %v", err)
+	}
+	session.Status = true
+	if _, err := session.Play("carol", 0); err != ErrPlayerNotFound {
+		t.Errorf("Expected ErrPlayerNotFound, got %v", err)
+	}
+	if _, err := session.Play("bob", 0); err == nil {
+		t.Errorf("Expected an error playing out of turn")
+	}
+	for _, name := range []string{"alice", "bob", "alice", "bob", "alice", "bob"} {
+		if _, err := session.Play(name, session.PlayerIndex(name)); err != nil {
+			t.Fatalf("Error playing for %s: %v", name, err)
+		}
+	}
+	move, err := session.Play("alice", 0)
+	if err != nil || move.Row != 2 {
+		t.Fatalf("Expected the winning piece in row 2, got %v %v", move, err)
+	}
+	if session.Status || session.Players[0].Wins != 1 {
+		t.Errorf("Expected the game to end with a win for alice")
+	}
+}
+
+// TestReady tests that both players, or the built-in AI, must be ready
+func TestReady(t *testing.T) {
+	session := NewSession("testSession")
+	session.AddPlayer(NewPlayer("alice"))
+	if session.AllReady() {
+		t.Errorf("Expected a table with one player not to be ready")
+	}
+	if err := session.SetReady("carol"); err != ErrPlayerNotFound {
+		t.Errorf("Expected ErrPlayerNotFound, got %v", err)
+	}
+	session.SetReady("alice")
+	ai := NewPlayer("ai")
+	ai.AI = 1
+	session.AddPlayer(ai)
+	if !session.AllReady() {
+		t.Errorf("Expected the built-in AI to be ready")
+	}
+	session.Status = true
+	if err := session.SetReady("alice"); err != ErrGameInProgress {
+		t.Errorf("Expected ErrGameInProgress during a game, got %v", err)
+	}
+}
+
+// TestExpired tests that empty tables expire sooner than idle ones
+func TestExpired(t *testing.T) {
+	session := NewSession("testSession")
+	now := time.Now()
+	session.LastActivity = now.Add(-20 * time.Minute)
+	if !session.Expired(now, time.Hour, 10*time.Minute) {
+		t.Errorf("Expected an empty table to be abandoned")
+	}
+	session.AddPlayer(NewPlayer("alice"))
+	if session.Expired(now, time.Hour, 10*time.Minute) {
+		t.Errorf("Expected a table with a player not to expire before the idle timeout")
+	}
+	if !session.Expired(now, 15*time.Minute, 10*time.Minute) {
+		t.Errorf("Expected the table to be idle")
+	}
+	if session.Expired(now, 0, 0) {
+		t.Errorf("Expected no expiry with both timeouts disabled")
+	}
+}
+
+// TestSummary tests the lobby state and variant of a table
+func TestSummary(t *testing.T) {
+	session := NewSessionWithSize("testSession", 8, 7)
+	session.AddPlayer(NewPlayer("alice"))
+	if summary := session.Summary(); summary.State != TableWaiting || summary.Variant != "8x7" || len(summary.Players) != 1 {
+		t.Errorf("Expected a waiting 8x7 table with one player, got %+v", summary)
+	}
+	session.AddPlayer(NewPlayer("bob"))
+	if state := session.State(); state != TableFull {
+		t.Errorf("Expected a full table, got %s", state)
+	}
+	session.Status = true
+	if state := session.State(); state != TableInProgress {
+		t.Errorf("Expected a game in progress, got %s", state)
+	}
+}
@@ -0,0 +1,411 @@
+// Package notation reads and writes Connect 4 games and positions as text.
+//
+// A game is written like PGN: a block of tag pairs followed by the moves as
+// one-based column numbers and the result.
+//
+//	[Event "Connect 4"]
+//	[Date "2026.10.19"]
+//	[Table "6f0c..."]
+//	[X "alice"]
+//	[O "bob"]
+//	[First "X"]
+//	[Size "7x6"]
+//	[Result "1-0"]
+//
+//	4453654 1-0
+//
+// Results are "1-0" when X won, "0-1" when O won, "1/2-1/2" for a draw and
+// "*" for a game still in progress. Boards wider than nine columns separate
+// their moves with spaces.
+//
+// A position lists the rows from top to bottom separated by "/", with runs
+// of empty slots written as a count, followed by the symbol to move:
+//
+//	7/7/7/7/3O3/2XX3 O
+//
+// A game may begin from a position given in a Position tag.
+package notation
+
+import (
+	"blackjackapi/models"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Results of a game
+const (
+	ResultXWins      = "1-0"
+	ResultOWins      = "0-1"
+	ResultDraw       = "1/2-1/2"
+	ResultInProgress = "*"
+)
+
+// Board size limits accepted when reading a game or position
+const (
+	MinSize = 4
+	MaxSize = 16
+)
+
+// Game is a parsed game record
+type Game struct {
+	Event string
+	Date  string
+	Table string
+	// Players holds the names playing X and O, in seat order
+	Players [2]string
+	// First is the symbol that moved first
+	First  string
+	Width  int
+	Height int
+	Result string
+	// Position is the setup the game started from, empty for an empty board
+	Position string
+	// Moves are zero-based columns
+	Moves []int
+}
+
+var tagPattern = regexp.MustCompile(`^\[(\w+)\s+"((?:[^"\\]|\\.)*)"\]$`)
+
+// Export records the current game of a session
+func Export(s *models.Session, date time.Time) Game {
+	g := Game{
+		Event:  "Connect 4",
+		Date:   date.Format("2006.01.02"),
+		Table:  s.ID,
+		Width:  len(s.Grid[0]),
+		Height: len(s.Grid),
+		Result: Result(s),
+	}
+	// Teams are named after both teammates
+	for i, side := range s.Sides() {
+		if i < len(g.Players) {
+			g.Players[i] = side
+		}
+	}
+	if len(s.Moves) > 0 {
+		g.First = s.Moves[0].Symbol
+	} else {
+		g.First = s.Symbol(s.Turn)
+	}
+	for _, move := range s.Moves {
+		g.Moves = append(g.Moves, move.Column)
+	}
+	// Pieces placed before the first move, such as a handicap's, make the
+	// position the game started from
+	if start := s.StartingBoard(); start.OccupiedSlots > 0 {
+		g.Position = Position(start)
+	}
+	return g
+}
+
+// Result returns the result of the current game of a session
+func Result(s *models.Session) string {
+	if line := s.WinningLine(); line != nil {
+		if s.Grid[line[0].Row][line[0].Column] == models.Player1Symbol {
+			return ResultXWins
+		}
+		return ResultOWins
+	}
+	if s.IsBoardFull() {
+		return ResultDraw
+	}
+	return ResultInProgress
+}
+
+// String writes the game in notation
+func (g Game) String() string {
+	var sb strings.Builder
+	tag := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&sb, "[%s %s]\n", name, strconv.Quote(value))
+		}
+	}
+	tag("Event", g.Event)
+	tag("Date", g.Date)
+	tag("Table", g.Table)
+	tag(models.Player1Symbol, g.Players[0])
+	tag(models.Player2Symbol, g.Players[1])
+	tag("First", g.First)
+	tag("Size", fmt.Sprintf("%dx%d", g.Width, g.Height))
+	tag("Position", g.Position)
+	tag("Result", g.Result)
+	sb.WriteString("\n")
+
+	separator := ""
+	if g.Width > 9 {
+		separator = " "
+	}
+	moves := make([]string, len(g.Moves))
+	for i, column := range g.Moves {
+		moves[i] = strconv.Itoa(column + 1)
+	}
+	if len(moves) > 0 {
+		sb.WriteString(strings.Join(moves, separator) + " ")
+	}
+	sb.WriteString(g.Result + "\n")
+	return sb.String()
+}
+
+// Parse reads a game in notation. Missing tags default to a 7x6 board with X
+// moving first. Moves are only checked against the board size; use Replay
+// to check them against the rules.
+func Parse(text string) (Game, error) {
+	g := Game{
+		First:  models.Player1Symbol,
+		Width:  models.DefaultWidth,
+		Height: models.DefaultHeight,
+		Result: ResultInProgress,
+	}
+	var movetext []string
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "[") {
+			if line != "" {
+				movetext = append(movetext, line)
+			}
+			continue
+		}
+		match := tagPattern.FindStringSubmatch(line)
+		if match == nil {
+			return Game{}, fmt.Errorf("line %d: malformed tag %q", n+1, line)
+		}
+		value, err := strconv.Unquote(`"` + match[2] + `"`)
+		if err != nil {
+			return Game{}, fmt.Errorf("line %d: malformed tag value: %v", n+1, err)
+		}
+		switch match[1] {
+		case "Event":
+			g.Event = value
+		case "Date":
+			g.Date = value
+		case "Table":
+			g.Table = value
+		case models.Player1Symbol:
+			g.Players[0] = value
+		case models.Player2Symbol:
+			g.Players[1] = value
+		case "First":
+			if value != models.Player1Symbol && value != models.Player2Symbol {
+				return Game{}, fmt.Errorf("line %d: First must be %s or %s", n+1, models.Player1Symbol, models.Player2Symbol)
+			}
+			g.First = value
+		case "Size":
+			if _, err := fmt.Sscanf(value, "%dx%d", &g.Width, &g.Height); err != nil {
+				return Game{}, fmt.Errorf("line %d: Size must look like 7x6", n+1)
+			}
+		case "Position":
+			g.Position = value
+		case "Result":
+			g.Result = value
+		}
+	}
+	if g.Width < MinSize || g.Width > MaxSize || g.Height < MinSize || g.Height > MaxSize {
+		return Game{}, fmt.Errorf("board size %dx%d is outside %d-%d", g.Width, g.Height, MinSize, MaxSize)
+	}
+	if !validResult(g.Result) {
+		return Game{}, fmt.Errorf("unknown result %q", g.Result)
+	}
+
+	tokens := strings.Fields(strings.Join(movetext, " "))
+	if len(tokens) > 0 && validResult(tokens[len(tokens)-1]) {
+		if g.Result != ResultInProgress && g.Result != tokens[len(tokens)-1] {
+			return Game{}, errors.New("result after the moves does not match the Result tag")
+		}
+		g.Result = tokens[len(tokens)-1]
+		tokens = tokens[:len(tokens)-1]
+	}
+	// Single digit columns may be run together
+	if g.Width <= 9 {
+		tokens = strings.Split(strings.Join(tokens, ""), "")
+		if len(tokens) == 1 && tokens[0] == "" {
+			tokens = nil
+		}
+	}
+	for i, token := range tokens {
+		column, err := strconv.Atoi(token)
+		if err != nil || column < 1 || column > g.Width {
+			return Game{}, fmt.Errorf("move %d: %q is not a column between 1 and %d", i+1, token, g.Width)
+		}
+		g.Moves = append(g.Moves, column-1)
+	}
+	return g, nil
+}
+
+func validResult(result string) bool {
+	switch result {
+	case ResultXWins, ResultOWins, ResultDraw, ResultInProgress:
+		return true
+	}
+	return false
+}
+
+// Replay builds a session for the game, seating its players and playing every
+// move through Session.Play. The game is left in progress unless the moves
+// end it, and a Result tag that disagrees with the moves is an error.
+func (g Game) Replay(id string) (*models.Session, error) {
+	if g.Players[0] == "" || g.Players[1] == "" {
+		return nil, fmt.Errorf("both %s and %s players must be named", models.Player1Symbol, models.Player2Symbol)
+	}
+	if g.Players[0] == g.Players[1] {
+		return nil, errors.New("players must have different names")
+	}
+
+	s := models.NewSessionWithSize(id, g.Width, g.Height)
+	first := g.First
+	if g.Position != "" {
+		position, toMove, err := ParsePosition(g.Position)
+		if err != nil {
+			return nil, fmt.Errorf("position: %v", err)
+		}
+		if len(position.Grid) != g.Height || len(position.Grid[0]) != g.Width {
+			return nil, errors.New("position does not match the Size tag")
+		}
+		s.Grid = position.Grid
+		s.OccupiedSlots = position.OccupiedSlots
+		first = toMove
+	}
+	for _, name := range g.Players {
+		s.AddPlayer(models.NewPlayer(name))
+	}
+	s.Status = true
+	if first == models.Player2Symbol {
+		s.Turn = 1
+	}
+	// Starts counts this game as played, so the next one waits for the
+	// players to be ready, and leaves it on the seat that moved first so
+	// the next game alternates the first player
+	s.Starts = 1
+	if s.Starts%2 != s.Turn {
+		s.Starts++
+	}
+
+	if s.WinningLine() != nil || s.IsBoardFull() {
+		s.Status = false
+	}
+	for i, column := range g.Moves {
+		if !s.Status {
+			return nil, fmt.Errorf("move %d: the game is already over", i+1)
+		}
+		if _, err := s.Play(s.GetPlayersTurn(), column); err != nil {
+			return nil, fmt.Errorf("move %d: %v", i+1, err)
+		}
+	}
+	if g.Result != ResultInProgress && g.Result != Result(s) {
+		return nil, fmt.Errorf("moves end in %s but the result is %s", Result(s), g.Result)
+	}
+	return s, nil
+}
+
+// Position writes the board of a session followed by the symbol to move
+func Position(s *models.Session) string {
+	rows := make([]string, len(s.Grid))
+	for r, row := range s.Grid {
+		var sb strings.Builder
+		empty := 0
+		for _, slot := range row {
+			if slot == models.EmptySlot {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			sb.WriteString(slot)
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+		rows[r] = sb.String()
+	}
+	toMove := s.Symbol(s.Turn)
+	if toMove == "" {
+		toMove = models.Player1Symbol
+	}
+	return strings.Join(rows, "/") + " " + toMove
+}
+
+// ParsePosition reads a position into a session with no players, returning
+// the symbol to move. Pieces must rest on the bottom or on another piece.
+func ParsePosition(text string) (*models.Session, string, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 2 {
+		return nil, "", errors.New("a position is the rows followed by the symbol to move")
+	}
+	toMove := fields[1]
+	if toMove != models.Player1Symbol && toMove != models.Player2Symbol {
+		return nil, "", fmt.Errorf("symbol to move must be %s or %s", models.Player1Symbol, models.Player2Symbol)
+	}
+
+	rows := strings.Split(fields[0], "/")
+	if len(rows) > MaxSize {
+		return nil, "", fmt.Errorf("board has %d rows, at most %d are allowed", len(rows), MaxSize)
+	}
+	var grid [][]string
+	for r, text := range rows {
+		var row []string
+		count := ""
+		// flush adds the empty slots counted so far, refusing counts that
+		// would take the row past MaxSize before allocating them
+		flush := func() error {
+			if count == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(count)
+			if err != nil || n > MaxSize-len(row) {
+				return fmt.Errorf("row %d is wider than %d slots", r+1, MaxSize)
+			}
+			for i := 0; i < n; i++ {
+				row = append(row, models.EmptySlot)
+			}
+			count = ""
+			return nil
+		}
+		for _, ch := range text {
+			if '0' <= ch && ch <= '9' {
+				count += string(ch)
+				continue
+			}
+			if err := flush(); err != nil {
+				return nil, "", err
+			}
+			symbol := string(ch)
+			if symbol != models.Player1Symbol && symbol != models.Player2Symbol {
+				return nil, "", fmt.Errorf("row %d: unknown symbol %q", r+1, symbol)
+			}
+			if len(row) == MaxSize {
+				return nil, "", fmt.Errorf("row %d is wider than %d slots", r+1, MaxSize)
+			}
+			row = append(row, symbol)
+		}
+		if err := flush(); err != nil {
+			return nil, "", err
+		}
+		if r > 0 && len(row) != len(grid[0]) {
+			return nil, "", fmt.Errorf("row %d has %d slots, expected %d", r+1, len(row), len(grid[0]))
+		}
+		grid = append(grid, row)
+	}
+	if len(grid[0]) < MinSize || len(grid[0]) > MaxSize || len(grid) < MinSize || len(grid) > MaxSize {
+		return nil, "", fmt.Errorf("board size %dx%d is outside %d-%d", len(grid[0]), len(grid), MinSize, MaxSize)
+	}
+
+	s := models.NewSessionWithSize("", len(grid[0]), len(grid))
+	s.Grid = grid
+	for r := range grid {
+		for c, slot := range grid[r] {
+			if slot == models.EmptySlot {
+				continue
+			}
+			s.OccupiedSlots++
+			if r+1 < len(grid) && grid[r+1][c] == models.EmptySlot {
+				return nil, "", fmt.Errorf("piece in row %d column %d is floating", r+1, c+1)
+			}
+		}
+	}
+	return s, toMove, nil
+}
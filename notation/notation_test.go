@@ -0,0 +1,167 @@
+package notation
+
+import (
+	"blackjackapi/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleGame = `[Event "Connect 4"]
+[X "alice"]
+[O "bob"]
+[First "X"]
+[Size "7x6"]
+[Result "1-0"]
+
+4455667 1-0
+`
+
+// TestReplay tests that a game is replayed through the rules
+func TestReplay(t *testing.T) {
+	game, err := Parse(sampleGame)
+	if err != nil {
+		t.Fatalf("Error parsing game: %v", err)
+	}
+	if len(game.Moves) != 7 || game.Moves[0] != 3 {
+		t.Fatalf("Expected 7 zero-based moves starting in column 3, got %v", game.Moves)
+	}
+	session, err := game.Replay("replayed")
+	if err != nil {
+		t.Fatalf("Error replaying game: %v", err)
+	}
+	if session.Status {
+		t.Errorf("Expected the game to be over")
+	}
+	if session.Players[0].Wins != 1 {
+		t.Errorf("Expected alice to be credited with the win")
+	}
+}
+
+// TestReplayCountsGame tests that an imported game counts as played, with the
+// next game going to the seat that did not move first
+func TestReplayCountsGame(t *testing.T) {
+	for first, starts := range map[string]int{"X": 2, "O": 1} {
+		game, err := Parse(strings.Replace(sampleGame, `[First "X"]`, `[First "`+first+`"]`, 1))
+		if err != nil {
+			t.Fatalf("Error parsing game: %v", err)
+		}
+		game.Result = ResultInProgress
+		session, err := game.Replay("replayed")
+		if err != nil {
+			t.Fatalf("Error replaying game: %v", err)
+		}
+		if session.Starts != starts {
+			t.Errorf("Expected %s moving first to leave Starts at %d, got %d", first, starts, session.Starts)
+		}
+	}
+}
+
+// TestExportRoundTrip tests that an exported game parses back to the same moves
+func TestExportRoundTrip(t *testing.T) {
+	session := models.NewSession("table")
+	session.AddPlayer(models.NewPlayer("alice"))
+	session.AddPlayer(models.NewPlayer("bob"))
+	session.Status = true
+	session.Turn = 1
+	for _, move := range []struct {
+		name   string
+		column int
+	}{{"bob", 3}, {"alice", 3}, {"bob", 2}} {
+		if _, err := session.Play(move.name, move.column); err != nil {
+			t.Fatalf("Error playing: %v", err)
+		}
+	}
+
+	text := Export(session, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)).String()
+	if !strings.Contains(text, `[Date "2026.10.19"]`) || !strings.Contains(text, "443 *") {
+		t.Errorf("Unexpected export:\n%s", text)
+	}
+	game, err := Parse(text)
+	if err != nil {
+		t.Fatalf("Error parsing export: %v", err)
+	}
+	replayed, err := game.Replay("copy")
+	if err != nil {
+		t.Fatalf("Error replaying export: %v", err)
+	}
+	if Position(replayed) != Position(session) {
+		t.Errorf("Expected position %s, got %s", Position(session), Position(replayed))
+	}
+}
+
+// TestExportHandicap tests that a game started from handicap pieces exports its starting position
+func TestExportHandicap(t *testing.T) {
+	session := models.NewSession("testSession")
+	session.AddPlayer(models.NewPlayer("alice"))
+	session.AddPlayer(models.NewPlayer("bob"))
+	session.SetHandicap(&models.Handicap{Player: "alice", Pieces: []int{3}})
+	session.PlaceHandicap()
+	session.Status = true
+	session.Play("alice", 2)
+
+	game := Export(session, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+	if game.Position != "7/7/7/7/7/3O3 X" {
+		t.Errorf("Expected the handicap position, got %q", game.Position)
+	}
+	replayed, err := game.Replay("copy")
+	if err != nil {
+		t.Fatalf("Error replaying export: %v", err)
+	}
+	if Position(replayed) != Position(session) {
+		t.Errorf("Expected position %s, got %s", Position(session), Position(replayed))
+	}
+}
+
+// TestInvalidGames tests that games breaking the rules are rejected
+func TestInvalidGames(t *testing.T) {
+	cases := map[string]string{
+		"full column":     "[X \"a\"]\n[O \"b\"]\n\n1111111",
+		"after the end":   "[X \"a\"]\n[O \"b\"]\n\n12121211",
+		"wrong result":    "[X \"a\"]\n[O \"b\"]\n[Result \"0-1\"]\n\n1212121",
+		"unnamed players": "[X \"a\"]\n\n1",
+	}
+	for name, text := range cases {
+		game, err := Parse(text)
+		if err == nil {
+			_, err = game.Replay("invalid")
+		}
+		if err == nil {
+			t.Errorf("%s: expected the game to be rejected", name)
+		}
+	}
+	if _, err := Parse("[Size \"7x6\"]\n\n48"); err == nil {
+		t.Errorf("Expected column 8 to be rejected on a 7 wide board")
+	}
+}
+
+// TestPosition tests position notation in both directions
+func TestPosition(t *testing.T) {
+	text := "7/7/7/7/3O3/2XX3 O"
+	session, toMove, err := ParsePosition(text)
+	if err != nil {
+		t.Fatalf("Error parsing position: %v", err)
+	}
+	if toMove != models.Player2Symbol || session.OccupiedSlots != 3 {
+		t.Errorf("Expected O to move with 3 pieces, got %s with %d", toMove, session.OccupiedSlots)
+	}
+	session.Turn = 1
+	if got := Position(session); got != text {
+		t.Errorf("Expected %s, got %s", text, got)
+	}
+	if _, _, err := ParsePosition("7/7/7/7/3O3/7 X"); err == nil {
+		t.Errorf("Expected a floating piece to be rejected")
+	}
+	for _, text := range []string{
+		"99999999999/7/7/7/7/7 X",
+		"99999999999999999999/7/7/7/7/7 X",
+		"17/17/17/17 X",
+		"XXXXXXXXXXXXXXXXX/7/7/7 X",
+		"٧/٧/٧/٧/٧/٧ X",
+		strings.Repeat("7/", 17) + "7 X",
+	} {
+		if _, _, err := ParsePosition(text); err == nil {
+			t.Errorf("Expected %.30q to be rejected", text)
+		}
+	}
+}
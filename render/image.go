@@ -0,0 +1,220 @@
+package render
+
+import (
+	"blackjackapi/models"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Board image geometry in pixels
+const (
+	cellSize     = 64
+	boardMargin  = 12
+	headerHeight = 28
+	pieceRadius  = 26
+	fontScale    = 4
+)
+
+var (
+	backgroundColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	boardColor      = color.RGBA{0x1f, 0x4e, 0xb4, 0xff}
+	holeColor       = color.RGBA{0xe8, 0xee, 0xf7, 0xff}
+	highlightColor  = color.RGBA{0x22, 0xc5, 0x5e, 0xff}
+	textColor       = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	// pieceColors maps the colors players pick to the colors of their pieces
+	pieceColors = map[string]color.RGBA{
+		models.ColorRed:    {0xdc, 0x26, 0x26, 0xff},
+		models.ColorYellow: {0xfa, 0xcc, 0x15, 0xff},
+		models.ColorPurple: {0x93, 0x33, 0xea, 0xff},
+		models.ColorTeal:   {0x0d, 0x94, 0x88, 0xff},
+		models.ColorOrange: {0xf9, 0x73, 0x16, 0xff},
+		models.ColorPink:   {0xec, 0x48, 0x99, 0xff},
+		models.ColorGreen:  {0x65, 0xa3, 0x0d, 0xff},
+		models.ColorBlack:  {0x11, 0x18, 0x27, 0xff},
+	}
+	fallbackPieceColor = color.RGBA{0x6b, 0x72, 0x80, 0xff}
+)
+
+// digitFont is a 3x5 bitmap font for the column numbers, one row per string
+var digitFont = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", "###", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", "..#", "..#", "..#"},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+}
+
+// boardSize returns the width and height of the image of a board
+func boardSize(s *models.Session) (int, int) {
+	return 2*boardMargin + len(s.Grid[0])*cellSize, headerHeight + 2*boardMargin + len(s.Grid)*cellSize
+}
+
+// cellCenter returns the pixel center of a cell
+func cellCenter(row, column int) (int, int) {
+	return boardMargin + column*cellSize + cellSize/2, headerHeight + boardMargin + row*cellSize + cellSize/2
+}
+
+// pieceColor returns the color of a piece on the grid, as its player chose
+func pieceColor(s *models.Session, piece string) color.RGBA {
+	_, name := s.PieceAppearance(piece)
+	if c, ok := pieceColors[name]; ok {
+		return c
+	}
+	return fallbackPieceColor
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// SVG renders the board as an SVG document with column numbers, the last
+// move ringed and any winning line struck through
+func SVG(s *models.Session) []byte {
+	width, height := boardSize(s)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="%s"/>`+"\n", width, height, hexColor(backgroundColor))
+	fmt.Fprintf(&sb, `<rect y="%d" width="%d" height="%d" rx="12" fill="%s"/>`+"\n", headerHeight, width, height-headerHeight, hexColor(boardColor))
+	for c := range s.Grid[0] {
+		x, _ := cellCenter(0, c)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="middle" font-family="sans-serif" font-size="18" fill="%s">%d</text>`+"\n", x, headerHeight-8, hexColor(textColor), c+1)
+	}
+	for r, row := range s.Grid {
+		for c, slot := range row {
+			x, y := cellCenter(r, c)
+			fill := holeColor
+			if slot != models.EmptySlot {
+				fill = pieceColor(s, slot)
+			}
+			fmt.Fprintf(&sb, `<circle cx="%d" cy="%d" r="%d" fill="%s"/>`+"\n", x, y, pieceRadius, hexColor(fill))
+		}
+	}
+	if move := s.LastMove(); move != nil {
+		x, y := cellCenter(move.Row, move.Column)
+		fmt.Fprintf(&sb, `<circle cx="%d" cy="%d" r="%d" fill="none" stroke="%s" stroke-width="4"/>`+"\n", x, y, pieceRadius-2, hexColor(backgroundColor))
+	}
+	if line := s.WinningLine(); line != nil {
+		x1, y1 := cellCenter(line[0].Row, line[0].Column)
+		x2, y2 := cellCenter(line[len(line)-1].Row, line[len(line)-1].Column)
+		fmt.Fprintf(&sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="8" stroke-linecap="round"/>`+"\n", x1, y1, x2, y2, hexColor(highlightColor))
+	}
+	sb.WriteString("</svg>\n")
+	return []byte(sb.String())
+}
+
+// PNG renders the board like SVG and encodes it as a PNG image
+func PNG(w io.Writer, s *models.Session) error {
+	return png.Encode(w, drawBoard(s))
+}
+
+// GIF replays the moves of the current game as an animated GIF, holding
+// on the final position
+func GIF(w io.Writer, s *models.Session) error {
+	palette := color.Palette{backgroundColor, boardColor, holeColor, highlightColor, textColor, fallbackPieceColor}
+	for _, name := range models.Colors {
+		palette = append(palette, pieceColors[name])
+	}
+
+	replay := s.StartingBoard()
+
+	animation := &gif.GIF{}
+	addFrame := func(delay int) {
+		frame := drawBoard(replay)
+		paletted := image.NewPaletted(frame.Bounds(), palette)
+		draw.Draw(paletted, frame.Bounds(), frame, image.Point{}, draw.Src)
+		animation.Image = append(animation.Image, paletted)
+		animation.Delay = append(animation.Delay, delay)
+	}
+	addFrame(60)
+	for i, move := range s.Moves {
+		if err := replay.DropPiece(move.Column, move.Symbol); err != nil {
+			return err
+		}
+		delay := 60
+		if i == len(s.Moves)-1 {
+			delay = 300
+		}
+		addFrame(delay)
+	}
+	return gif.EncodeAll(w, animation)
+}
+
+// drawBoard draws the board into an image
+func drawBoard(s *models.Session) *image.RGBA {
+	width, height := boardSize(s)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, headerHeight, width, height), image.NewUniform(boardColor), image.Point{}, draw.Src)
+
+	for c := range s.Grid[0] {
+		x, _ := cellCenter(0, c)
+		drawNumber(img, c+1, x, headerHeight/2)
+	}
+	for r, row := range s.Grid {
+		for c, slot := range row {
+			x, y := cellCenter(r, c)
+			fill := holeColor
+			if slot != models.EmptySlot {
+				fill = pieceColor(s, slot)
+			}
+			fillCircle(img, x, y, pieceRadius, 0, fill)
+		}
+	}
+	if move := s.LastMove(); move != nil {
+		x, y := cellCenter(move.Row, move.Column)
+		fillCircle(img, x, y, pieceRadius-1, pieceRadius-5, backgroundColor)
+	}
+	if line := s.WinningLine(); line != nil {
+		x1, y1 := cellCenter(line[0].Row, line[0].Column)
+		x2, y2 := cellCenter(line[len(line)-1].Row, line[len(line)-1].Column)
+		steps := cellSize * len(line)
+		for i := 0; i <= steps; i++ {
+			fillCircle(img, x1+(x2-x1)*i/steps, y1+(y2-y1)*i/steps, 4, 0, highlightColor)
+		}
+	}
+	return img
+}
+
+// fillCircle fills the ring between inner and outer radius around (cx, cy);
+// an inner radius of 0 fills the whole disc
+func fillCircle(img *image.RGBA, cx, cy, outer, inner int, c color.RGBA) {
+	for y := -outer; y <= outer; y++ {
+		for x := -outer; x <= outer; x++ {
+			d := x*x + y*y
+			if d <= outer*outer && (inner == 0 || d >= inner*inner) {
+				img.SetRGBA(cx+x, cy+y, c)
+			}
+		}
+	}
+}
+
+// drawNumber draws n with the bitmap font centered on (cx, cy)
+func drawNumber(img *image.RGBA, n, cx, cy int) {
+	digits := fmt.Sprint(n)
+	glyphWidth := 4 * fontScale
+	x0 := cx - (len(digits)*glyphWidth-fontScale)/2
+	y0 := cy - 5*fontScale/2
+	for i, digit := range digits {
+		for gy, row := range digitFont[digit] {
+			for gx, bit := range row {
+				if bit != '#' {
+					continue
+				}
+				px := x0 + i*glyphWidth + gx*fontScale
+				py := y0 + gy*fontScale
+				draw.Draw(img, image.Rect(px, py, px+fontScale, py+fontScale), image.NewUniform(textColor), image.Point{}, draw.Src)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"blackjackapi/models"
+	"bytes"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// TestSVG tests that every slot and the winning line are drawn
+func TestSVG(t *testing.T) {
+	session := models.NewSession("testSession")
+	for i := 0; i < 4; i++ {
+		session.DropPiece(i, models.Player1Symbol)
+	}
+	svg := string(SVG(session))
+	// 42 slots plus the ring around the last move
+	if got := strings.Count(svg, "<circle"); got != 43 {
+		t.Errorf("Expected 43 circles, got %d", got)
+	}
+	if !strings.Contains(svg, "<line") {
+		t.Errorf("Expected the winning line to be drawn")
+	}
+}
+
+// TestPNG tests that the PNG decodes to the board size
+func TestPNG(t *testing.T) {
+	session := models.NewSession("testSession")
+	var buf bytes.Buffer
+	if err := PNG(&buf, session); err != nil {
+		t.Fatalf("Error encoding PNG: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Error decoding PNG: %v", err)
+	}
+	width, height := boardSize(session)
+	if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+		t.Errorf("Expected a %dx%d image, got %v", width, height, img.Bounds())
+	}
+}
+
+// TestGIF tests that the replay has a frame for the empty board and every move
+func TestGIF(t *testing.T) {
+	session := models.NewSession("testSession")
+	for _, column := range []int{3, 3, 4} {
+		session.DropPiece(column, models.Player1Symbol)
+	}
+	var buf bytes.Buffer
+	if err := GIF(&buf, session); err != nil {
+		t.Fatalf("Error encoding GIF: %v", err)
+	}
+	animation, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("Error decoding GIF: %v", err)
+	}
+	if len(animation.Image) != 4 {
+		t.Errorf("Expected 4 frames, got %d", len(animation.Image))
+	}
+}
@@ -0,0 +1,253 @@
+// Package render draws Connect 4 tables for terminal viewers.
+//
+// Every style sizes its boxes from the display width of their content, so
+// long table IDs and multi-byte player names stay aligned.
+package render
+
+import (
+	"blackjackapi/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Style selects the characters and colors used to draw a table
+type Style struct {
+	Name string
+	// Box drawing characters
+	Horizontal, Vertical                           string
+	TopLeft, TopRight, BottomLeft, BottomRight     string
+	TopJoin, BottomJoin, LeftJoin, RightJoin, Join string
+	// Color enables ANSI colors for symbols and highlights
+	Color bool
+}
+
+var (
+	// ASCII draws with plain ASCII characters. The last move is shown as (X)
+	// and a winning line as [X].
+	ASCII = Style{
+		Name: "ascii", Horizontal: "-", Vertical: "|",
+		TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+		TopJoin: "+", BottomJoin: "+", LeftJoin: "+", RightJoin: "+", Join: "+",
+	}
+	// Unicode draws with box drawing characters, highlighting like ASCII
+	Unicode = Style{
+		Name: "unicode", Horizontal: "─", Vertical: "│",
+		TopLeft: "┌", TopRight: "┐", BottomLeft: "└", BottomRight: "┘",
+		TopJoin: "┬", BottomJoin: "┴", LeftJoin: "├", RightJoin: "┤", Join: "┼",
+	}
+	// Color draws like Unicode with colored symbols, the last move in bold
+	// and underlined and a winning line in reverse video
+	Color = Style{
+		Name: "color", Horizontal: "─", Vertical: "│",
+		TopLeft: "┌", TopRight: "┐", BottomLeft: "└", BottomRight: "┘",
+		TopJoin: "┬", BottomJoin: "┴", LeftJoin: "├", RightJoin: "┤", Join: "┼",
+		Color: true,
+	}
+)
+
+var styles = map[string]Style{
+	ASCII.Name:   ASCII,
+	Unicode.Name: Unicode,
+	Color.Name:   Color,
+}
+
+// ANSI escape sequences
+const (
+	ansiReset     = "\033[0m"
+	ansiHighlight = "\033[1;4m"
+	ansiWinning   = "\033[7m"
+)
+
+// ansiColors maps the colors players pick to ANSI foreground colors
+var ansiColors = map[string]string{
+	models.ColorRed:    "\033[31m",
+	models.ColorYellow: "\033[33m",
+	models.ColorPurple: "\033[35m",
+	models.ColorTeal:   "\033[36m",
+	models.ColorOrange: "\033[38;5;208m",
+	models.ColorPink:   "\033[38;5;205m",
+	models.ColorGreen:  "\033[32m",
+	models.ColorBlack:  "\033[90m",
+}
+
+// Lookup returns the style with the given name; an empty name selects ASCII
+func Lookup(name string) (Style, error) {
+	if name == "" {
+		return ASCII, nil
+	}
+	style, ok := styles[name]
+	if !ok {
+		return Style{}, fmt.Errorf("unknown style %q, use one of %s", name, strings.Join(Names(), ", "))
+	}
+	return style, nil
+}
+
+// Names returns the names of all styles
+func Names() []string {
+	var names []string
+	for name := range styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Event renders a stream event: the status box with the event message and the board
+func Event(e models.Event, style Style) string {
+	if e.Session == nil || len(e.Session.Grid) == 0 {
+		return e.Message + "\n"
+	}
+	return Table(e.Session, e.Message, style)
+}
+
+// Table renders the status box followed by the board
+func Table(s *models.Session, announcement string, style Style) string {
+	return Status(s, announcement, style) + Board(s, style)
+}
+
+// Status renders the box describing the table, its players and an announcement
+func Status(s *models.Session, announcement string, style Style) string {
+	status := "Game has not started"
+	if s.Paused {
+		status = "Game is paused"
+	} else if s.Status {
+		status = "Game is in Progress"
+	}
+	var names []string
+	for _, player := range s.Players {
+		names = append(names, player.Name)
+	}
+	lines := []string{
+		"Session ID: " + s.ID,
+		"Status: " + status,
+		"Player List: " + strings.Join(names, ", "),
+	}
+	if label := s.Label(); label != "" {
+		lines = append(lines[:1], append([]string{label}, lines[1:]...)...)
+	}
+	if s.Status && len(s.Players) > 0 {
+		lines = append(lines, "Current Turn: "+s.GetPlayersTurn())
+	}
+	if score := s.MatchScore(); score != "" {
+		lines = append(lines, score)
+	}
+	// Symbols are colored after measuring so escape codes do not count towards the width
+	symbols, colors := map[int]string{}, map[int]string{}
+	for i, player := range s.Players {
+		symbol, color := s.Appearance(i)
+		symbols[len(lines)], colors[len(lines)] = symbol, color
+		line := fmt.Sprintf("Player: %s - Symbol: %s - Wins: %d", player.Name, symbol, player.Wins)
+		if player.Ready && !s.Status {
+			line += " - Ready"
+		}
+		if player.Away != nil {
+			line += " - Disconnected"
+		}
+		if player.Name == s.DrawOffer {
+			line += " - Offers a draw"
+		}
+		lines = append(lines, line)
+	}
+
+	width := 0
+	for _, line := range lines {
+		if w := Width(line); w > width {
+			width = w
+		}
+	}
+	if w := Width(announcement); w > width {
+		width = w
+	}
+
+	var sb strings.Builder
+	rule := strings.Repeat(style.Horizontal, width+2)
+	sb.WriteString(style.TopLeft + rule + style.TopRight + "\n")
+	for i, line := range lines {
+		padded := pad(line, width)
+		if symbol, ok := symbols[i]; ok && style.Color && symbol != "" {
+			padded = strings.Replace(padded, "Symbol: "+symbol, "Symbol: "+colorSymbol(symbol, colors[i], ""), 1)
+		}
+		sb.WriteString(style.Vertical + " " + padded + " " + style.Vertical + "\n")
+	}
+	if announcement != "" {
+		sb.WriteString(style.LeftJoin + rule + style.RightJoin + "\n")
+		sb.WriteString(style.Vertical + " " + pad(announcement, width) + " " + style.Vertical + "\n")
+	}
+	sb.WriteString(style.BottomLeft + rule + style.BottomRight + "\n")
+	return sb.String()
+}
+
+// Board renders the grid with column numbers, highlighting the last move and any winning line
+func Board(s *models.Session, style Style) string {
+	numCols := len(s.Grid[0])
+	winning := map[models.Cell]bool{}
+	for _, cell := range s.WinningLine() {
+		winning[cell] = true
+	}
+	var last *models.Cell
+	if move := s.LastMove(); move != nil {
+		last = &models.Cell{Row: move.Row, Column: move.Column}
+	}
+
+	rule := func(left, join, right string) string {
+		segments := make([]string, numCols)
+		for i := range segments {
+			segments[i] = strings.Repeat(style.Horizontal, 3)
+		}
+		return left + strings.Join(segments, join) + right + "\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(rule(style.TopLeft, style.TopJoin, style.TopRight))
+	for r, row := range s.Grid {
+		if r > 0 {
+			sb.WriteString(rule(style.LeftJoin, style.Join, style.RightJoin))
+		}
+		sb.WriteString(style.Vertical)
+		for c, slot := range row {
+			cell := models.Cell{Row: r, Column: c}
+			sb.WriteString(renderSlot(s, slot, winning[cell], last != nil && *last == cell, style))
+			sb.WriteString(style.Vertical)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(rule(style.BottomLeft, style.BottomJoin, style.BottomRight))
+
+	// Column numbers line up under the middle of each slot
+	for col := 0; col < numCols; col++ {
+		sb.WriteString(fmt.Sprintf("%3d ", col+1))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// renderSlot renders one slot three columns wide, showing the piece in it
+// with the symbol and color its player chose
+func renderSlot(s *models.Session, slot string, winning, last bool, style Style) string {
+	if slot == models.EmptySlot {
+		return "   "
+	}
+	slot, color := s.PieceAppearance(slot)
+	if style.Color {
+		highlight := ""
+		if winning {
+			highlight = ansiWinning
+		} else if last {
+			highlight = ansiHighlight
+		}
+		return " " + colorSymbol(slot, color, highlight) + " "
+	}
+	if winning {
+		return "[" + slot + "]"
+	}
+	if last {
+		return "(" + slot + ")"
+	}
+	return " " + slot + " "
+}
+
+// colorSymbol wraps a symbol in a color and an optional highlight
+func colorSymbol(symbol, color, highlight string) string {
+	return ansiColors[color] + highlight + symbol + ansiReset
+}
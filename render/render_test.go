@@ -0,0 +1,110 @@
+package render
+
+import (
+	"blackjackapi/models"
+	"strings"
+	"testing"
+)
+
+// TestWidth tests display widths of multi-byte strings
+func TestWidth(t *testing.T) {
+	cases := map[string]int{
+		"alice": 5,
+		"zoë":   3,
+		"é":    1,
+		"井上":    4,
+		"🎲x":    3,
+	}
+	for s, want := range cases {
+		if got := Width(s); got != want {
+			t.Errorf("Expected width of %q to be %d, got %d", s, want, got)
+		}
+	}
+}
+
+// TestStatusAlignment tests that every line of the status box has the same width
+func TestStatusAlignment(t *testing.T) {
+	session := models.NewSession("0b7c3c1e-3f2a-4c8e-9d51-7a8f7f3e2b10-with-a-long-suffix")
+	session.AddPlayer(models.NewPlayer("井上さん"))
+	session.AddPlayer(models.NewPlayer("Zoë"))
+	session.Status = true
+
+	for _, style := range []Style{ASCII, Unicode} {
+		lines := strings.Split(strings.TrimSuffix(Status(session, "Player Zoë joined table", style), "\n"), "\n")
+		width := Width(lines[0])
+		for _, line := range lines {
+			if Width(line) != width {
+				t.Errorf("%s: expected line %q to be %d wide, got %d", style.Name, line, width, Width(line))
+			}
+		}
+	}
+}
+
+// TestBoardHighlights tests last move and winning line markers
+func TestBoardHighlights(t *testing.T) {
+	session := models.NewSession("testSession")
+	session.DropPiece(0, models.Player1Symbol)
+	session.DropPiece(1, models.Player2Symbol)
+
+	board := Board(session, ASCII)
+	if !strings.Contains(board, "| X |(O)|") {
+		t.Errorf("Expected last move to be marked, got\n%s", board)
+	}
+	for _, column := range []int{0, 0, 0} {
+		session.DropPiece(column, models.Player1Symbol)
+	}
+	board = Board(session, Unicode)
+	if strings.Count(board, "[X]") != 4 {
+		t.Errorf("Expected 4 winning cells to be marked, got\n%s", board)
+	}
+	if board = Board(session, Color); !strings.Contains(board, ansiWinning) {
+		t.Errorf("Expected the winning line to be highlighted in color")
+	}
+}
+
+// TestCustomAppearance tests that pieces are drawn with the symbol and color their player chose
+func TestCustomAppearance(t *testing.T) {
+	session := models.NewSession("testSession")
+	session.AddPlayer(&models.Player{Name: "alice", Symbol: "*", Color: models.ColorGreen})
+	session.AddPlayer(models.NewPlayer("bob"))
+	session.DropPiece(0, session.Symbol(0))
+	session.DropPiece(1, session.Symbol(1))
+
+	board := Board(session, ASCII)
+	if !strings.Contains(board, "| * |(O)|") {
+		t.Errorf("Expected alice's piece to show as *, got\n%s", board)
+	}
+	if board = Board(session, Color); !strings.Contains(board, ansiColors[models.ColorGreen]+"*") {
+		t.Errorf("Expected alice's piece to be green")
+	}
+	if status := Status(session, "", ASCII); !strings.Contains(status, "Symbol: *") {
+		t.Errorf("Expected the status to show alice's symbol, got\n%s", status)
+	}
+	if svg := string(SVG(session)); !strings.Contains(svg, hexColor(pieceColors[models.ColorGreen])) {
+		t.Errorf("Expected alice's piece to be drawn green")
+	}
+}
+
+// TestStatusMatchScore tests that the status box shows the score of the table's match
+func TestStatusMatchScore(t *testing.T) {
+	session := models.NewSession("table")
+	session.AddPlayer(models.NewPlayer("alice"))
+	session.AddPlayer(models.NewPlayer("bob"))
+	match, _ := models.NewMatch(models.MatchBestOf, 3)
+	session.SetMatch(match)
+	session.Status = true
+	session.FinishGame(1)
+	if status := Status(session, "", ASCII); !strings.Contains(status, "alice 0 - bob 1") {
+		t.Errorf("Expected the status to show the match score, got\n%s", status)
+	}
+}
+
+// TestLookup tests style selection by name
+func TestLookup(t *testing.T) {
+	if style, err := Lookup(""); err != nil || style.Name != ASCII.Name {
+		t.Errorf("Expected the default style to be ascii, got %v %v", style.Name, err)
+	}
+	if _, err := Lookup("sparkles"); err == nil {
+		t.Errorf("Expected an error for an unknown style")
+	}
+}
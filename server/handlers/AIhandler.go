@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"blackjackapi/engine"
+	"blackjackapi/models"
+	"log"
+	"sync"
+)
+
+// ais reuses the built-in AI of each level, transposition table included
+var ais [engine.MaxLevel + 1]sync.Pool
+
+// aiMove plays the built-in AI's move at a table if it is still the move the
+// AI was handed
+func (h *Handler) aiMove(tableID, name string, level, moves int) {
+	done, ok := h.track()
+	if !ok {
+		return
+	}
+	defer done()
+	table, err := models.GetSession(h.Context, tableID, h.Client)
+	if err != nil || !table.Status || len(table.Moves) != moves || table.GetPlayersTurn() != name {
+		return
+	}
+	board, err := engine.FromSession(table)
+	if err != nil {
+		log.Printf("Error reading table %s for the AI: %v", tableID, err)
+		return
+	}
+	history := make([]int, len(table.Moves))
+	for i, move := range table.Moves {
+		history[i] = move.Column
+	}
+
+	ai, ok := ais[level].Get().(*engine.AI)
+	if !ok {
+		ai = engine.NewAI(level, nil)
+	}
+	ai.Book = h.Book
+	column, forced := table.ForcedColumn(table.PlayerIndex(name))
+	if !forced {
+		column = ai.Move(board, history)
+	}
+	ais[level].Put(ai)
+
+	if _, err := h.playMove(table, name, column); err != nil {
+		log.Printf("Error playing the AI's move at table %s: %v", tableID, err)
+	}
+}
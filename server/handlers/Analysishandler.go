@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"blackjackapi/engine"
+	"blackjackapi/models"
+	"blackjackapi/notation"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultAnalysisTime is the search time of analysis requests that do not set one
+const defaultAnalysisTime = time.Second
+
+// errAnalysisDisabled answers analysis requests when the server has analysis turned off
+const errAnalysisDisabled = "Analysis is disabled on this server"
+
+// maxSearchers bounds the analyses and reviews searched at once, as each
+// holds a searcher with a transposition table of 2^20 entries
+const maxSearchers = 4
+
+// searchers holds the idle searchers, reused between analysis requests. A
+// nil searcher is a free slot whose table is not allocated yet.
+var searchers = func() chan *engine.Searcher {
+	searchers := make(chan *engine.Searcher, maxSearchers)
+	for i := 0; i < maxSearchers; i++ {
+		searchers <- nil
+	}
+	return searchers
+}()
+
+// acquireSearcher waits for an idle searcher, to be handed back with
+// releaseSearcher. If the request ends first it answers 503 and returns nil.
+func acquireSearcher(w http.ResponseWriter, r *http.Request) *engine.Searcher {
+	select {
+	case searcher := <-searchers:
+		if searcher == nil {
+			searcher = engine.NewSearcher(20)
+		}
+		return searcher
+	case <-r.Context().Done():
+		http.Error(w, "The server is busy with other analyses, please try again", http.StatusServiceUnavailable)
+		return nil
+	}
+}
+
+// releaseSearcher hands a searcher back for the next analysis
+func releaseSearcher(searcher *engine.Searcher) {
+	searchers <- searcher
+}
+
+// analysisResponse is an analysis together with the position it is for
+type analysisResponse struct {
+	Position string `json:"position"`
+	engine.Analysis
+}
+
+// TableAnalysisHandler evaluates every column of the current position of a table
+func (h *Handler) TableAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.Analysis {
+		http.Error(w, errAnalysisDisabled, http.StatusNotFound)
+		return
+	}
+	budget, err := h.parseBudget(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	table, ok := h.loadTable(w, mux.Vars(r)["tableID"])
+	if !ok {
+		return
+	}
+	if !table.TwoSided() {
+		http.Error(w, "Only games between two sides can be analyzed", http.StatusConflict)
+		return
+	}
+	writeAnalysis(w, r, table, budget)
+}
+
+// PositionAnalysisHandler evaluates every column of a position given in position notation
+func (h *Handler) PositionAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.Analysis {
+		http.Error(w, errAnalysisDisabled, http.StatusNotFound)
+		return
+	}
+	budget, err := h.parseBudget(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	position, toMove, err := notation.ParsePosition(r.URL.Query().Get("position"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid position: %v", err), http.StatusBadRequest)
+		return
+	}
+	if toMove == models.Player2Symbol {
+		position.Turn = 1
+	}
+	writeAnalysis(w, r, position, budget)
+}
+
+func writeAnalysis(w http.ResponseWriter, r *http.Request, table *models.Session, budget engine.Budget) {
+	board, err := engine.FromSession(table)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Position cannot be analyzed: %v", err), http.StatusBadRequest)
+		return
+	}
+	searcher := acquireSearcher(w, r)
+	if searcher == nil {
+		return
+	}
+	defer releaseSearcher(searcher)
+
+	response := analysisResponse{
+		Position: notation.Position(table),
+		Analysis: searcher.Analyze(board, budget),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseBudget reads the time_ms, nodes and depth query parameters, within
+// the server's limits
+func (h *Handler) parseBudget(r *http.Request) (engine.Budget, error) {
+	budget := engine.Budget{MaxTime: min(defaultAnalysisTime, h.MaxAnalysisTime)}
+	query := r.URL.Query()
+	if value := query.Get("time_ms"); value != "" {
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms <= 0 || time.Duration(ms)*time.Millisecond > h.MaxAnalysisTime {
+			return budget, fmt.Errorf("time_ms must be between 1 and %d", h.MaxAnalysisTime.Milliseconds())
+		}
+		budget.MaxTime = time.Duration(ms) * time.Millisecond
+	}
+	if value := query.Get("nodes"); value != "" {
+		nodes, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || nodes <= 0 || nodes > h.MaxAnalysisNodes {
+			return budget, fmt.Errorf("nodes must be between 1 and %d", h.MaxAnalysisNodes)
+		}
+		budget.MaxNodes = nodes
+	}
+	if value := query.Get("depth"); value != "" {
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth <= 0 {
+			return budget, errors.New("depth must be a positive number")
+		}
+		budget.MaxDepth = depth
+	}
+	return budget, nil
+}
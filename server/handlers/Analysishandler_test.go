@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"blackjackapi/engine"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestAnalysisSlots tests that no more than maxSearchers analyses run at
+// once, a request waiting for a slot being refused when it ends
+func TestAnalysisSlots(t *testing.T) {
+	h := newTestHandler(t)
+	h.Analysis = true
+	analyze := func(ctx context.Context) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/analysis?position=7%2F7%2F7%2F7%2F7%2F3X3+O&depth=2", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		h.PositionAnalysisHandler(w, r)
+		return w
+	}
+
+	busy := make([]*engine.Searcher, maxSearchers)
+	for i := range busy {
+		busy[i] = <-searchers
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if w := analyze(ctx); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while every searcher is busy, got %d", w.Code)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- analyze(context.Background()) }()
+	select {
+	case <-done:
+		t.Fatalf("Expected the analysis to wait for a free searcher")
+	case <-time.After(50 * time.Millisecond):
+	}
+	for _, searcher := range busy {
+		releaseSearcher(searcher)
+	}
+	if w := <-done; w.Code != http.StatusOK {
+		t.Errorf("Expected 200 once a searcher is free, got %d: %s", w.Code, w.Body.String())
+	}
+}
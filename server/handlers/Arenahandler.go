@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"blackjackapi/arena"
+	"blackjackapi/engine"
+	"blackjackapi/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+// arenaPollInterval is how often a running arena game is checked when no
+// table event arrives
+const arenaPollInterval = time.Second
+
+// aiPrefix marks players that are levels of the built-in AI, as in ai:3
+const aiPrefix = "ai:"
+
+// createArenaRequest is the body of a new arena
+type createArenaRequest struct {
+	Players []string `json:"players"`
+	Games   int      `json:"games"`
+}
+
+// CreateArenaHandler schedules a round robin between registered bots and
+// levels of the built-in AI and runs it in the background
+func (h *Handler) CreateArenaHandler(w http.ResponseWriter, r *http.Request) {
+	var request createArenaRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&request); err != nil {
+		http.Error(w, "Invalid arena request", http.StatusBadRequest)
+		return
+	}
+	if request.Games == 0 {
+		request.Games = arena.DefaultGames
+	}
+	seats := map[string]*models.Player{}
+	for _, name := range request.Players {
+		player, err := h.arenaPlayer(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		seats[name] = player
+	}
+	a, err := arena.New(uuid.New().String(), request.Players, request.Games)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := arena.Save(h.Context, a, h.Client); err != nil {
+		http.Error(w, "Failed to save arena to Redis", http.StatusInternalServerError)
+		return
+	}
+	go h.runArena(a, seats)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+// GetArenaHandler sends the progress, cross table and standings of an arena
+func (h *Handler) GetArenaHandler(w http.ResponseWriter, r *http.Request) {
+	a, err := arena.Get(h.Context, mux.Vars(r)["arenaID"], h.Client)
+	if errors.Is(err, redis.Nil) {
+		http.Error(w, "Arena does not exist. Please make sure your arena id is correct.", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve arena from Redis", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+// arenaPlayer turns an arena entrant into the player seated for it: ai:<level>
+// for the built-in AI, otherwise the name of a registered bot
+func (h *Handler) arenaPlayer(name string) (*models.Player, error) {
+	if player, ok, err := aiPlayer(name); ok {
+		return player, err
+	}
+	if _, err := models.GetBot(h.Context, name, h.Client); err != nil {
+		return nil, fmt.Errorf("Unknown bot %s", name)
+	}
+	player := models.NewPlayer(name)
+	player.Bot = true
+	return player, nil
+}
+
+// aiPlayer returns the built-in AI player named ai:<level>. ok is false for
+// names without the prefix.
+func aiPlayer(name string) (player *models.Player, ok bool, err error) {
+	value, ok := strings.CutPrefix(name, aiPrefix)
+	if !ok {
+		return nil, false, nil
+	}
+	level, err := strconv.Atoi(value)
+	if err != nil || level < engine.MinLevel || level > engine.MaxLevel {
+		return nil, true, fmt.Errorf("AI levels are %s%d to %s%d", aiPrefix, engine.MinLevel, aiPrefix, engine.MaxLevel)
+	}
+	player = models.NewPlayer(name)
+	player.AI = level
+	return player, true, nil
+}
+
+// runArena plays every pairing of an arena in turn, saving the arena after
+// each game
+func (h *Handler) runArena(a *arena.Arena, seats map[string]*models.Player) {
+	err := h.playArena(a, seats)
+	if err != nil {
+		log.Printf("Arena %s stopped: %v", a.ID, err)
+	}
+	a.Finish(err)
+	if err := arena.Save(h.Context, a, h.Client); err != nil {
+		log.Printf("Error saving arena %s: %v", a.ID, err)
+	}
+}
+
+func (h *Handler) playArena(a *arena.Arena, seats map[string]*models.Player) error {
+	for i := range a.Pairings {
+		table := models.NewSession(uuid.New().String())
+		for _, name := range a.Pairings[i].Players {
+			seat := *seats[name]
+			table.AddPlayer(&seat)
+		}
+		if err := models.SaveSession(h.Context, table, h.Client); err != nil {
+			return err
+		}
+		h.announceTable(models.EventTableCreated, table)
+		a.Pairings[i].TableID = table.ID
+		if err := arena.Save(h.Context, a, h.Client); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(h.Context)
+		events, err := h.Broker.Subscribe(ctx, table.ID)
+		if err != nil {
+			cancel()
+			return err
+		}
+		for game := 0; game < a.Games; game++ {
+			winner, err := h.playArenaGame(ctx, table.ID, events)
+			if err != nil {
+				cancel()
+				return err
+			}
+			a.Record(i, winner)
+			if err := arena.Save(h.Context, a, h.Client); err != nil {
+				cancel()
+				return err
+			}
+		}
+		cancel()
+	}
+	return nil
+}
+
+// playArenaGame starts the next game of a table and waits for it to end,
+// returning the seat of the winner or -1 for a draw. The players move by
+// themselves; the table's events only say when to look again.
+func (h *Handler) playArenaGame(ctx context.Context, tableID string, events <-chan models.Event) (int, error) {
+	table, err := models.GetSession(ctx, tableID, h.Client)
+	if err != nil {
+		return 0, err
+	}
+	before := [2]int{table.Players[0].Wins, table.Players[1].Wins}
+	if _, err := h.startGame(table, nil); err != nil {
+		return 0, err
+	}
+	for {
+		table, err = models.GetSession(ctx, tableID, h.Client)
+		if err != nil {
+			return 0, err
+		}
+		if !table.Status {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case _, ok := <-events:
+			if !ok {
+				// Keep polling without the stream
+				events = nil
+			}
+		case <-time.After(arenaPollInterval):
+		}
+	}
+	for seat, wins := range before {
+		if table.Players[seat].Wins > wins {
+			return seat, nil
+		}
+	}
+	return -1, nil
+}
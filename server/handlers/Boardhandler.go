@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"blackjackapi/render"
+	"bytes"
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
+)
+
+// BoardSVGHandler sends the current board of a table as an SVG image
+func (h *Handler) BoardSVGHandler(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.loadTable(w, mux.Vars(r)["tableID"])
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(render.SVG(table))
+}
+
+// BoardPNGHandler sends the current board of a table as a PNG image
+func (h *Handler) BoardPNGHandler(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.loadTable(w, mux.Vars(r)["tableID"])
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := render.PNG(&buf, table); err != nil {
+		log.Printf("Error encoding board PNG: %v", err)
+		http.Error(w, "Failed to render board", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(buf.Bytes())
+}
+
+// BoardGIFHandler sends the move history of a finished game as an animated GIF
+func (h *Handler) BoardGIFHandler(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.loadTable(w, mux.Vars(r)["tableID"])
+	if !ok {
+		return
+	}
+	if table.Status {
+		http.Error(w, "Game is currently in progress. Please wait until the game is over", http.StatusConflict)
+		return
+	}
+	var buf bytes.Buffer
+	if err := render.GIF(&buf, table); err != nil {
+		log.Printf("Error encoding board GIF: %v", err)
+		http.Error(w, "Failed to render game history", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/gif")
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"blackjackapi/notation"
+	"blackjackapi/render"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+// EventMoveRejected answers a move sent over a bot WebSocket that failed
+const EventMoveRejected = "move_rejected"
+
+// upgrader accepts bot WebSocket connections from any origin since bots
+// authenticate with their token rather than cookies
+var upgrader = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+
+// registerBotRequest is the body of a bot registration
+type registerBotRequest struct {
+	Name       string `json:"name"`
+	MoveTimeMS int64  `json:"move_time_ms"`
+}
+
+// registerBotResponse returns the token a bot authenticates with, which is
+// only ever shown once
+type registerBotResponse struct {
+	Name       string `json:"name"`
+	Token      string `json:"token"`
+	MoveTimeMS int64  `json:"move_time_ms"`
+}
+
+// botMove is a move sent by a bot over its WebSocket
+type botMove struct {
+	TableID string `json:"table_id"`
+	Column  int    `json:"column"`
+}
+
+// RegisterBotHandler registers an external engine and returns its token
+func (h *Handler) RegisterBotHandler(w http.ResponseWriter, r *http.Request) {
+	var request registerBotRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&request); err != nil {
+		http.Error(w, "Invalid bot registration", http.StatusBadRequest)
+		return
+	}
+	moveTime := models.DefaultBotMoveTime
+	if request.MoveTimeMS != 0 {
+		moveTime = time.Duration(request.MoveTimeMS) * time.Millisecond
+	}
+	bot, token, err := models.NewBot(request.Name, moveTime)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = models.RegisterBot(h.Context, bot, h.Client)
+	if errors.Is(err, models.ErrBotExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to save bot to Redis", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(registerBotResponse{Name: bot.Name, Token: token, MoveTimeMS: bot.MoveTime.Milliseconds()})
+}
+
+// BotJoinHandler seats the authenticated bot at a table
+func (h *Handler) BotJoinHandler(w http.ResponseWriter, r *http.Request) {
+	bot, ok := h.authenticateBot(w, r)
+	if !ok {
+		return
+	}
+	player := models.NewPlayer(bot.Name)
+	player.Bot = true
+	if h.seatPlayer(w, r, mux.Vars(r)["tableID"], player) {
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// BotReadyHandler marks the authenticated bot ready for the next game at a table
+func (h *Handler) BotReadyHandler(w http.ResponseWriter, r *http.Request) {
+	bot, ok := h.authenticateBot(w, r)
+	if !ok {
+		return
+	}
+	table, ok := h.loadTable(w, mux.Vars(r)["tableID"])
+	if !ok {
+		return
+	}
+	if status, err := h.markReady(table, bot.Name); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// BotMoveHandler drops a piece for the authenticated bot
+func (h *Handler) BotMoveHandler(w http.ResponseWriter, r *http.Request) {
+	bot, ok := h.authenticateBot(w, r)
+	if !ok {
+		return
+	}
+	vars := mux.Vars(r)
+	column, err := strconv.Atoi(vars["column"])
+	if err != nil {
+		http.Error(w, "Invalid column number", http.StatusBadRequest)
+		return
+	}
+	if status, err := h.botMove(bot, vars["tableID"], column); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// BotEventsHandler streams the your_turn and move_timeout events of the
+// authenticated bot as JSON SSE frames
+func (h *Handler) BotEventsHandler(w http.ResponseWriter, r *http.Request) {
+	bot, ok := h.authenticateBot(w, r)
+	if !ok || h.shuttingDown(w) {
+		return
+	}
+	ctx := r.Context()
+	events, err := h.Broker.Subscribe(ctx, models.BotStream(bot.Name))
+	if err != nil {
+		http.Error(w, "Failed to subscribe to bot events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	connected := models.Event{Type: models.EventConnected, Message: fmt.Sprintf("Connected as bot %s", bot.Name)}
+	if err := writeEvent(w, "json", render.ASCII, connected); err != nil {
+		log.Printf("Error writing SSE event to response: %v", err)
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.stopStreams():
+			writeEvent(w, "json", render.ASCII, h.shutdownEvent())
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, "json", render.ASCII, event); err != nil {
+				log.Printf("Error writing SSE event to response: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// BotSocketHandler connects the authenticated bot over a WebSocket. The
+// server sends the bot's events as JSON messages and the bot sends moves as
+// {"table_id": ..., "column": ...}; a move that fails is answered with a
+// move_rejected event.
+func (h *Handler) BotSocketHandler(w http.ResponseWriter, r *http.Request) {
+	bot, ok := h.authenticateBot(w, r)
+	if !ok || h.shuttingDown(w) {
+		return
+	}
+	ctx := r.Context()
+	events, err := h.Broker.Subscribe(ctx, models.BotStream(bot.Name))
+	if err != nil {
+		http.Error(w, "Failed to subscribe to bot events", http.StatusInternalServerError)
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already answered the client
+		return
+	}
+	defer conn.Close()
+
+	// Events and rejections are written from two goroutines
+	var writeMu sync.Mutex
+	write := func(event models.Event) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(event)
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-h.stopStreams():
+				// Closing the connection ends the read loop below
+				write(h.shutdownEvent())
+				conn.Close()
+				return
+			case event, ok := <-events:
+				if !ok {
+					conn.Close()
+					return
+				}
+				if err := write(event); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	defer close(done)
+
+	write(models.Event{Type: models.EventConnected, Message: fmt.Sprintf("Connected as bot %s", bot.Name)})
+	for {
+		var move botMove
+		if err := conn.ReadJSON(&move); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				write(models.Event{Type: EventMoveRejected, Message: "Invalid move message"})
+				continue
+			}
+			return
+		}
+		if _, err := h.botMove(bot, move.TableID, move.Column); err != nil {
+			write(models.Event{Type: EventMoveRejected, TableID: move.TableID, Message: err.Error()})
+		}
+	}
+}
+
+// authenticateBot finds the bot whose token is given as a bearer token or
+// the token query parameter, answering 401 itself when there is none
+func (h *Handler) authenticateBot(w http.ResponseWriter, r *http.Request) (*models.Bot, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("token")
+	}
+	name, _, _ := strings.Cut(token, ".")
+	if token == "" || name == token {
+		http.Error(w, "A bot token is required", http.StatusUnauthorized)
+		return nil, false
+	}
+	bot, err := models.GetBot(h.Context, name, h.Client)
+	if errors.Is(err, redis.Nil) || (err == nil && !bot.CheckToken(token)) {
+		http.Error(w, "Invalid bot token", http.StatusUnauthorized)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve bot from Redis", http.StatusInternalServerError)
+		return nil, false
+	}
+	return bot, true
+}
+
+// botMove plays a move for a bot seated at a table
+func (h *Handler) botMove(bot *models.Bot, tableID string, column int) (int, error) {
+	table, err := models.GetSession(h.Context, tableID, h.Client)
+	if errors.Is(err, redis.Nil) {
+		return http.StatusNotFound, errors.New("Table does not exist. Please make sure your table id is correct.")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to retrieve table from Redis")
+	}
+	if index := table.PlayerIndex(bot.Name); index == -1 || !table.Players[index].Bot {
+		return http.StatusForbidden, errors.New("The bot is not seated at this table")
+	}
+	return h.playMove(table, bot.Name, column)
+}
+
+// notifyTurn hands the turn to the player to move if it is not a person: the
+// built-in AI moves in the background, and a bot is sent a your_turn event
+// and forfeits the game if it has not moved by the deadline
+func (h *Handler) notifyTurn(table *models.Session) {
+	if !table.Status {
+		return
+	}
+	name := table.GetPlayersTurn()
+	index := table.PlayerIndex(name)
+	if index == -1 {
+		return
+	}
+	if level := table.Players[index].AI; level > 0 {
+		go h.aiMove(table.ID, name, level, len(table.Moves))
+		return
+	}
+	if !table.Players[index].Bot {
+		return
+	}
+	bot, err := models.GetBot(h.Context, name, h.Client)
+	if err != nil {
+		log.Printf("Error retrieving bot %s: %v", name, err)
+		return
+	}
+	deadline := time.Now().Add(bot.MoveTime)
+	event := models.NewEvent(models.EventYourTurn, table, fmt.Sprintf("Your turn at table %s", table.ID))
+	event.Position = notation.Position(table)
+	event.Deadline = &deadline
+	if err := h.Broker.PublishTo(h.Context, models.BotStream(bot.Name), event); err != nil {
+		log.Printf("Error notifying bot %s: %v", name, err)
+	}
+	moves := len(table.Moves)
+	time.AfterFunc(bot.MoveTime, func() { h.expireMove(table.ID, name, moves) })
+}
+
+// expireMove ends the game against a bot that is still to make the move it
+// was notified about
+func (h *Handler) expireMove(tableID, name string, moves int) {
+	done, ok := h.track()
+	if !ok {
+		return
+	}
+	defer done()
+	table, err := h.updateSession(tableID, func(table *models.Session) error {
+		if !table.Status || table.Paused || len(table.Moves) != moves || table.GetPlayersTurn() != name {
+			return errUnchanged // notifyTurn sets a new deadline when a paused game resumes
+		}
+		table.Forfeit(table.PlayerIndex(name))
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, errUnchanged) && !errors.Is(err, redis.Nil) {
+			log.Printf("Error saving table %s after a move timeout: %v", tableID, err)
+		}
+		return
+	}
+	message := fmt.Sprintf("Bot %s ran out of time and forfeits the game", name)
+	event := models.NewEvent(models.EventMoveTimeout, table, message)
+	if err := h.publish(event); err != nil {
+		log.Printf("Error broadcasting move timeout: %v", err)
+	}
+	if err := h.Broker.PublishTo(h.Context, models.BotStream(name), event); err != nil {
+		log.Printf("Error notifying bot %s: %v", name, err)
+	}
+	h.gameOver(table)
+}
@@ -1,25 +1,146 @@
 package handlers
 
 import (
+	"blackjackapi/engine"
+	"blackjackapi/models"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/redis/go-redis/v9"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Handler struct {
-	Client        *redis.Client
-	Context       context.Context
-	KAFKAUSERNAME string
-	KAFKAPASSWORD string
-	KAFKAADDRESS  string
+	Client  *redis.Client
+	Context context.Context
+	Broker  models.Broker
+	Book    *engine.Book
+	// Tables are closed by RunJanitor after IdleTimeout without activity,
+	// or after AbandonedTimeout with nobody seated. Zero disables either.
+	IdleTimeout      time.Duration
+	AbandonedTimeout time.Duration
+	// A seated player whose stream drops has ReconnectGrace to come back
+	// before DisconnectAction, models.DisconnectForfeit or
+	// models.DisconnectPause, is applied to the game. Zero stops tracking.
+	ReconnectGrace   time.Duration
+	DisconnectAction string
+	// Analysis turns on the analysis and review endpoints, whose searches
+	// are held to MaxAnalysisTime and MaxAnalysisNodes
+	Analysis         bool
+	MaxAnalysisTime  time.Duration
+	MaxAnalysisNodes int64
+
+	// Streams are ended by StopStreams telling subscribers to reconnect
+	// after ShutdownRetry
+	ShutdownRetry time.Duration
+
+	// stopping is closed by StopStreams; inflight counts the moves played
+	// in the background that Shutdown waits for
+	drainMu  sync.Mutex
+	stopping chan struct{}
+	stopped  bool
+	inflight sync.WaitGroup
+
+	// connections counts the named connections to each table's stream
+	presenceMu  sync.Mutex
+	connections map[string]int
 }
 
 // NewHandler initializes and returns a new Handler instance
-func NewHandler(tableStore *redis.Client, user string, pass string,address string) *Handler {
+func NewHandler(tableStore *redis.Client, user string, pass string, address string) *Handler {
 	return &Handler{
-		Client:        tableStore,
-		Context:       context.Background(),
-		KAFKAUSERNAME: user,
-		KAFKAPASSWORD: pass,
-		KAFKAADDRESS: address,
+		Client:  tableStore,
+		Context: context.Background(),
+		Broker: &models.KafkaBroker{
+			Address:  address,
+			Username: user,
+			Password: pass,
+		},
+		Book:             engine.DefaultBook(),
+		IdleTimeout:      models.DefaultIdleTimeout,
+		AbandonedTimeout: models.DefaultAbandonedTimeout,
+		ReconnectGrace:   models.DefaultReconnectGrace,
+		DisconnectAction: models.DisconnectForfeit,
+		Analysis:         true,
+		MaxAnalysisTime:  engine.DefaultMaxAnalysisTime,
+		MaxAnalysisNodes: engine.DefaultMaxAnalysisNodes,
+		ShutdownRetry:    models.DefaultShutdownRetry,
+	}
+}
+
+// broadcast publishes an event about the table to everyone connected to it
+func (h *Handler) broadcast(eventType string, table *models.Session, message string) error {
+	return h.publish(models.NewEvent(eventType, table, message))
+}
+
+// publish sends a table event to everyone connected to the table and
+// tells the lobby the table changed
+func (h *Handler) publish(event models.Event) error {
+	if err := h.Broker.Publish(h.Context, event); err != nil {
+		return err
+	}
+	lobbyEvent := models.EventTableUpdated
+	if event.Type == models.EventTableExpired || event.Type == models.EventTableClosed {
+		lobbyEvent = models.EventTableClosed
+	}
+	h.announceTable(lobbyEvent, event.Session)
+	return nil
+}
+
+// announceTable tells the lobby a public table was created, updated or
+// closed. Lobby events carry the table's summary rather than the session.
+func (h *Handler) announceTable(eventType string, table *models.Session) {
+	if table == nil || table.Private {
+		return
+	}
+	event := models.Event{Type: eventType, TableID: table.ID}
+	if eventType == models.EventTableClosed {
+		event.Message = fmt.Sprintf("Table %s was closed", table.ID)
+	} else {
+		summary := table.Summary()
+		event.Table = &summary
+		event.Message = fmt.Sprintf("Table %s is %s", table.ID, strings.ReplaceAll(table.State(), "_", " "))
+	}
+	if err := h.Broker.PublishTo(h.Context, models.LobbyStream, event); err != nil {
+		log.Printf("Error updating the lobby about table %s: %v", table.ID, err)
+	}
+}
+
+// withdrawTable tells the lobby a public table was made private, which
+// lobby subscribers see as the table closing
+func (h *Handler) withdrawTable(tableID string) {
+	event := models.Event{Type: models.EventTableClosed, TableID: tableID, Message: fmt.Sprintf("Table %s left the lobby", tableID)}
+	if err := h.Broker.PublishTo(h.Context, models.LobbyStream, event); err != nil {
+		log.Printf("Error updating the lobby about table %s: %v", tableID, err)
+	}
+}
+
+// errUnchanged is returned by table updates that leave the table as it
+// was, so nothing is saved
+var errUnchanged = errors.New("Table unchanged")
+
+// updateSession applies update to the latest version of a table and saves
+// it, running update again on the table as it is then if someone else saved
+// it meanwhile. Timers and background moves use it so they never overwrite
+// a move or forfeit made at the same time.
+func (h *Handler) updateSession(tableID string, update func(*models.Session) error) (*models.Session, error) {
+	return models.UpdateSession(h.Context, tableID, h.Client, update)
+}
+
+// loadTable retrieves a table, answering 404 or 500 itself when it cannot
+func (h *Handler) loadTable(w http.ResponseWriter, tableID string) (*models.Session, bool) {
+	table, err := models.GetSession(h.Context, tableID, h.Client)
+	if errors.Is(err, redis.Nil) {
+		http.Error(w, "Table does not exist. Please make sure your table id is correct.", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve table from Redis", http.StatusInternalServerError)
+		return nil, false
 	}
+	return table, true
 }
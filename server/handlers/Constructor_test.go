@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestHandler returns a handler backed by an in-memory Redis and broker
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	store := miniredis.RunT(t)
+	h := NewHandler(redis.NewClient(&redis.Options{Addr: store.Addr()}), "", "", "")
+	h.Broker = models.NewMemoryBroker()
+	return h
+}
+
+// newTestTable saves a table seating the named players, starting the game
+// when started is set
+func newTestTable(t *testing.T, h *Handler, started bool, names ...string) *models.Session {
+	t.Helper()
+	return seatTestTable(t, h, models.NewSession("table"), started, names...)
+}
+
+// seatTestTable seats the named players at table and saves it, starting the
+// game when started is set
+func seatTestTable(t *testing.T, h *Handler, table *models.Session, started bool, names ...string) *models.Session {
+	t.Helper()
+	for _, name := range names {
+		if err := table.AddPlayer(models.NewPlayer(name)); err != nil {
+			t.Fatalf("Error seating %s: %v", name, err)
+		}
+	}
+	if err := models.SaveSession(h.Context, table, h.Client); err != nil {
+		t.Fatalf("Error saving table: %v", err)
+	}
+	if started {
+		if _, err := h.startGame(table, nil); err != nil {
+			t.Fatalf("Error starting game: %v", err)
+		}
+	}
+	return table
+}
+
+// serve calls handler with a request to path carrying the route variables vars
+func serve(handler http.HandlerFunc, method, path string, body io.Reader, vars map[string]string) *httptest.ResponseRecorder {
+	r := mux.SetURLVars(httptest.NewRequest(method, path, body), vars)
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// interleave is a Redis hook standing in for another request: the first
+// time the table is read, it saves a change to it through its own client
+type interleave struct {
+	once sync.Once
+	save func()
+}
+
+func (i *interleave) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (i *interleave) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if cmd.Name() == "get" {
+			i.once.Do(i.save)
+		}
+		return err
+	}
+}
+
+func (i *interleave) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// interleaveSave makes change to the saved table right after h next reads it
+func interleaveSave(t *testing.T, h *Handler, tableID string, change func(*models.Session)) {
+	t.Helper()
+	other := redis.NewClient(&redis.Options{Addr: h.Client.Options().Addr})
+	t.Cleanup(func() { other.Close() })
+	h.Client.AddHook(&interleave{save: func() {
+		table, err := models.GetSession(h.Context, tableID, other)
+		if err != nil {
+			t.Errorf("Error loading table: %v", err)
+			return
+		}
+		change(table)
+		if err := models.SaveSession(h.Context, table, other); err != nil {
+			t.Errorf("Error saving table: %v", err)
+		}
+	}})
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+// hostToken reads the host token of a request from the X-Host-Token
+// header or the host_token query parameter
+func hostToken(r *http.Request) string {
+	if token := r.Header.Get("X-Host-Token"); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("host_token")
+}
+
+// errNotHost is returned by table updates the host did not ask for
+var errNotHost = errors.New("Only the host of the table can do that")
+
+// authorizeHost checks the request comes from the table's host, answering 403 itself when not
+func (h *Handler) authorizeHost(w http.ResponseWriter, r *http.Request, table *models.Session) bool {
+	if !table.CheckHostToken(hostToken(r)) {
+		http.Error(w, errNotHost.Error(), http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// updateHostedTable applies update to the latest version of the table of
+// the request and saves it, for the table's host only. update returns the
+// HTTP status to answer with when it fails; every failure is answered here.
+func (h *Handler) updateHostedTable(w http.ResponseWriter, r *http.Request, update func(*models.Session) (int, error)) (*models.Session, bool) {
+	status := http.StatusInternalServerError
+	var updateErr error
+	table, err := h.updateSession(mux.Vars(r)["tableID"], func(latest *models.Session) error {
+		if !latest.CheckHostToken(hostToken(r)) {
+			status, updateErr = http.StatusForbidden, errNotHost
+			return updateErr
+		}
+		status, updateErr = update(latest)
+		return updateErr
+	})
+	if errors.Is(err, redis.Nil) {
+		http.Error(w, "Table does not exist. Please make sure your table id is correct.", http.StatusNotFound)
+		return nil, false
+	} else if errors.Is(err, models.ErrUpdateConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return nil, false
+	} else if err != nil && err == updateErr {
+		http.Error(w, err.Error(), status)
+		return nil, false
+	} else if err != nil {
+		http.Error(w, "Failed to save table to Redis", http.StatusInternalServerError)
+		return nil, false
+	}
+	return table, true
+}
+
+// announceChange tells everyone connected to a table the host changed it,
+// answering 500 itself when it cannot
+func (h *Handler) announceChange(w http.ResponseWriter, eventType string, table *models.Session, message string) bool {
+	if err := h.broadcast(eventType, table, message); err != nil {
+		http.Error(w, "Failed to broadcast table update", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// KickHandler removes a player or a named viewer from the table for good.
+// A player kicked during a game forfeits it.
+func (h *Handler) KickHandler(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	var forfeited *models.Session
+	table, ok := h.updateHostedTable(w, r, func(latest *models.Session) (int, error) {
+		forfeited = nil
+		// The forfeit counts towards the tournament and match while the
+		// player is still seated; the kick then abandons any match left
+		if index := latest.PlayerIndex(name); index != -1 && latest.Status {
+			latest.Forfeit(index)
+			seated := *latest
+			seated.Players = slices.Clone(latest.Players)
+			forfeited = &seated
+		}
+		latest.Kick(name)
+		return http.StatusOK, nil
+	})
+	if !ok {
+		return
+	}
+	message := fmt.Sprintf("The host removed %s from the table", name)
+	if forfeited != nil {
+		h.recordResult(forfeited)
+		message = fmt.Sprintf("The host removed %s from the table, forfeiting the game", name)
+	}
+	if h.announceChange(w, models.EventPlayerKicked, table, message) {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// LockHandler stops anyone else from taking a seat at the table
+func (h *Handler) LockHandler(w http.ResponseWriter, r *http.Request) {
+	h.setLocked(w, r, true)
+}
+
+// UnlockHandler lets players take the seats of the table again
+func (h *Handler) UnlockHandler(w http.ResponseWriter, r *http.Request) {
+	h.setLocked(w, r, false)
+}
+
+func (h *Handler) setLocked(w http.ResponseWriter, r *http.Request, locked bool) {
+	table, ok := h.updateHostedTable(w, r, func(latest *models.Session) (int, error) {
+		latest.Locked = locked
+		return http.StatusOK, nil
+	})
+	if !ok {
+		return
+	}
+	eventType, message := models.EventTableLocked, "The host locked the table"
+	if !locked {
+		eventType, message = models.EventTableUnlocked, "The host unlocked the table"
+	}
+	if h.announceChange(w, eventType, table, message) {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// tableSettings is the body of a settings request. Settings left out are kept.
+type tableSettings struct {
+	Name        *string          `json:"name"`
+	Password    *string          `json:"password"`
+	Private     *bool            `json:"private"`
+	FirstMove   *string          `json:"first_move"`
+	FirstPlayer *string          `json:"first_player"`
+	Handicap    *models.Handicap `json:"handicap"`
+}
+
+// SettingsHandler changes the name, password, privacy, first move policy
+// and handicap of a table between games. Every setting is checked before
+// the table is renamed, and the rename is undone if the table cannot be saved.
+func (h *Handler) SettingsHandler(w http.ResponseWriter, r *http.Request) {
+	var settings tableSettings
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&settings); err != nil {
+		http.Error(w, "Invalid settings request", http.StatusBadRequest)
+		return
+	}
+	var name string
+	if settings.Name != nil {
+		name = strings.TrimSpace(*settings.Name)
+		if name != "" {
+			if err := models.ValidateTableName(name); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if settings.FirstMove != nil {
+		if err := models.ValidateFirstMove(*settings.FirstMove); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	var wasPrivate bool
+	var renamed *models.Session
+	var oldName string
+	table, ok := h.updateHostedTable(w, r, func(latest *models.Session) (int, error) {
+		status, err := applySettings(latest, settings)
+		if err != nil {
+			return status, err
+		}
+		wasPrivate, renamed, oldName = latest.Private, nil, latest.Name
+		if settings.Private != nil {
+			latest.Private = *settings.Private
+		}
+		// Renaming claims the name in Redis, so it comes last
+		if settings.Name != nil {
+			if err := models.RenameTable(h.Context, latest, name, h.Client); errors.Is(err, models.ErrTableNameTaken) {
+				return http.StatusConflict, err
+			} else if err != nil {
+				return http.StatusInternalServerError, errors.New("Failed to save table to Redis")
+			}
+			renamed = latest
+		}
+		return http.StatusOK, nil
+	})
+	if !ok {
+		if renamed != nil {
+			if err := models.RenameTable(h.Context, renamed, oldName, h.Client); err != nil {
+				log.Printf("Error giving table %s back its name %q: %v", renamed.ID, oldName, err)
+			}
+		}
+		return
+	}
+	if !h.announceChange(w, models.EventSettingsChanged, table, "The host changed the table settings") {
+		return
+	}
+	if table.Private && !wasPrivate {
+		h.withdrawTable(table.ID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(table)
+}
+
+// applySettings applies the settings of a settings request that depend on
+// the table, other than its name and privacy. On failure it returns the
+// HTTP status to answer with.
+func applySettings(table *models.Session, settings tableSettings) (int, error) {
+	if table.Status {
+		return http.StatusConflict, models.ErrGameInProgress
+	}
+	if settings.Password != nil {
+		if err := table.SetPassword(*settings.Password); errors.Is(err, models.ErrPasswordLength) {
+			return http.StatusBadRequest, err
+		} else if err != nil {
+			return http.StatusInternalServerError, errors.New("Failed to save table to Redis")
+		}
+	}
+	if settings.FirstMove != nil {
+		table.FirstMove = *settings.FirstMove
+	}
+	if settings.FirstPlayer != nil {
+		if *settings.FirstPlayer != "" && table.PlayerIndex(*settings.FirstPlayer) == -1 {
+			return http.StatusBadRequest, models.ErrPlayerNotFound
+		}
+		table.FirstPlayer = *settings.FirstPlayer
+	}
+	// An empty handicap removes it
+	if settings.Handicap != nil {
+		if err := table.SetHandicap(settings.Handicap); errors.Is(err, models.ErrHandicapSides) {
+			return http.StatusConflict, err
+		} else if err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// TransferHostHandler hands the host role to a seated player. The old host
+// token stops working and the new one is sent back to be passed on.
+func (h *Handler) TransferHostHandler(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	var token string
+	table, ok := h.updateHostedTable(w, r, func(latest *models.Session) (int, error) {
+		var err error
+		token, err = latest.TransferHost(name)
+		if errors.Is(err, models.ErrNotSeated) {
+			return http.StatusBadRequest, err
+		} else if err != nil {
+			return http.StatusInternalServerError, errors.New("Failed to create host token")
+		}
+		return http.StatusOK, nil
+	})
+	if !ok || !h.announceChange(w, models.EventHostChanged, table, fmt.Sprintf("%s is now the host", name)) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"host": name, "host_token": token})
+}
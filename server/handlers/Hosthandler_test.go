@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// failSaves is a Redis hook failing the transactions tables are saved in
+type failSaves struct{}
+
+func (failSaves) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (failSaves) ProcessHook(next redis.ProcessHook) redis.ProcessHook { return next }
+
+func (failSaves) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		return errors.New("Redis is unavailable")
+	}
+}
+
+// newNamedTable saves a table called name, returning it with its host token
+func newNamedTable(t *testing.T, h *Handler, id, name string) (*models.Session, string) {
+	t.Helper()
+	table := models.NewSession(id)
+	token, err := table.NewHostToken()
+	if err != nil {
+		t.Fatalf("Error creating host token: %v", err)
+	}
+	if err := models.RenameTable(h.Context, table, name, h.Client); err != nil {
+		t.Fatalf("Error naming table: %v", err)
+	}
+	return seatTestTable(t, h, table, false, "alice", "bob"), token
+}
+
+// changeSettings sends a settings request for the table as its host
+func changeSettings(h *Handler, tableID, token, body string) (int, string) {
+	w := serve(h.SettingsHandler, "POST", "/"+tableID+"/settings?host_token="+token, strings.NewReader(body), map[string]string{"tableID": tableID})
+	return w.Code, w.Body.String()
+}
+
+// checkNames tests which of the names another table could take
+func checkNames(t *testing.T, h *Handler, taken, free string) {
+	t.Helper()
+	other := models.NewSession("other")
+	if err := models.RenameTable(h.Context, other, taken, h.Client); !errors.Is(err, models.ErrTableNameTaken) {
+		t.Errorf("Expected %q to still be taken, got %v", taken, err)
+	}
+	if err := models.RenameTable(h.Context, other, free, h.Client); err != nil {
+		t.Errorf("Expected %q to be free, got %v", free, err)
+	}
+}
+
+// TestSettingsInvalid tests that a request with an invalid setting changes
+// nothing, not even the table's name
+func TestSettingsInvalid(t *testing.T) {
+	h := newTestHandler(t)
+	table, token := newNamedTable(t, h, "table", "Old Name")
+
+	requests := []string{
+		`{"name": "New Name", "first_move": "never"}`,
+		`{"name": "New Name", "first_player": "carol"}`,
+		`{"name": "New Name", "handicap": {"player": "alice", "pieces": [99]}}`,
+		`{"name": "New Name", "password": "` + strings.Repeat("p", models.MaxPasswordLength+1) + `"}`,
+	}
+	for _, body := range requests {
+		if code, message := changeSettings(h, table.ID, token, body); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d: %s", body, code, message)
+		}
+	}
+	stored, err := models.GetSession(h.Context, table.ID, h.Client)
+	if err != nil {
+		t.Fatalf("Error loading table: %v", err)
+	}
+	if stored.Name != "Old Name" || stored.FirstMove != table.FirstMove || stored.Handicap != nil || stored.Protected {
+		t.Errorf("Expected the table to be unchanged, got %+v", stored)
+	}
+	checkNames(t, h, "Old Name", "New Name")
+}
+
+// TestSettingsRollback tests that a table keeps its name when its new
+// settings cannot be saved
+func TestSettingsRollback(t *testing.T) {
+	h := newTestHandler(t)
+	table, token := newNamedTable(t, h, "table", "Old Name")
+	h.Client.AddHook(failSaves{})
+
+	if code, message := changeSettings(h, table.ID, token, `{"name": "New Name"}`); code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 when the table cannot be saved, got %d: %s", code, message)
+	}
+	checkNames(t, h, "Old Name", "New Name")
+}
+
+// TestHostActionsKeepChanges tests that host actions are applied to the
+// table as it is when saved, keeping changes made after they loaded it
+func TestHostActionsKeepChanges(t *testing.T) {
+	h := newTestHandler(t)
+	table, token := newNamedTable(t, h, "table", "Old Name")
+	vars := map[string]string{"tableID": table.ID}
+	interleaveSave(t, h, table.ID, func(latest *models.Session) {
+		latest.Players[1].Ready = true
+	})
+	if w := serve(h.LockHandler, "POST", "/table/lock?host_token="+token, nil, vars); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 locking the table, got %d: %s", w.Code, w.Body.String())
+	}
+
+	interleaveSave(t, h, table.ID, func(latest *models.Session) {
+		latest.Players[0].Ready = true
+	})
+	if code, message := changeSettings(h, table.ID, token, `{"name": "New Name", "private": true}`); code != http.StatusOK {
+		t.Fatalf("Expected 200 changing the settings, got %d: %s", code, message)
+	}
+	stored, err := models.GetSession(h.Context, table.ID, h.Client)
+	if err != nil {
+		t.Fatalf("Error loading table: %v", err)
+	}
+	if !stored.Locked || !stored.Private || stored.Name != "New Name" {
+		t.Errorf("Expected the table locked, private and renamed, got %+v", stored)
+	}
+	if !stored.Players[0].Ready || !stored.Players[1].Ready {
+		t.Errorf("Expected both players to stay ready")
+	}
+	checkNames(t, h, "New Name", "Old Name")
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RunJanitor closes idle and abandoned tables every interval until ctx is done
+func (h *Handler) RunJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.sweepTables(ctx); err != nil {
+				log.Printf("Error sweeping tables: %v", err)
+			}
+		}
+	}
+}
+
+// sweepTables closes every table that has expired, telling its viewers, and
+// drops the tables Redis has expired from the lobby
+func (h *Handler) sweepTables(ctx context.Context) error {
+	if err := models.PruneLobby(ctx, h.Client); err != nil {
+		log.Printf("Error pruning the lobby: %v", err)
+	}
+	iter := h.Client.Scan(ctx, 0, models.SessionKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		table, err := h.expireTable(ctx, iter.Val())
+		if err != nil {
+			log.Printf("Error expiring %s: %v", iter.Val(), err)
+			continue
+		}
+		if table == nil {
+			continue
+		}
+		event := models.NewEvent(models.EventTableExpired, table, "Table closed after a period of inactivity")
+		if err := h.publish(event); err != nil {
+			log.Printf("Error broadcasting the expiry of table %s: %v", table.ID, err)
+		}
+	}
+	return iter.Err()
+}
+
+// expireTable deletes the table stored at key if it has expired, returning
+// it, or nil when it is still in use. The key is watched so a table played
+// on while it is checked is kept.
+func (h *Handler) expireTable(ctx context.Context, key string) (*models.Session, error) {
+	var expired *models.Session
+	err := h.Client.Watch(ctx, func(tx *redis.Tx) error {
+		val, err := tx.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var table models.Session
+		if err := json.Unmarshal([]byte(val), &table); err != nil {
+			return err
+		}
+		if !table.Expired(time.Now(), h.IdleTimeout, h.AbandonedTimeout) {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			models.QueueDeleteSession(ctx, pipe, &table)
+			return nil
+		})
+		if err == nil {
+			expired = &table
+		}
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		// Someone used the table meanwhile
+		return nil, nil
+	}
+	return expired, err
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"testing"
+	"time"
+)
+
+// TestLobbyPrunedByJanitor tests that listing the lobby only skips the tables
+// Redis has expired, leaving the janitor to drop them from the index
+func TestLobbyPrunedByJanitor(t *testing.T) {
+	h := newTestHandler(t)
+	defer func(ttl time.Duration) { models.SessionTTL = ttl }(models.SessionTTL)
+	models.SessionTTL = 50 * time.Millisecond
+
+	gone := seatTestTable(t, h, models.NewSession("gone"), false, "alice")
+	time.Sleep(2 * models.SessionTTL)
+	kept := seatTestTable(t, h, models.NewSession("kept"), false, "bob")
+
+	tables, total, err := models.ListTables(h.Context, h.Client, models.LobbyFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("Error listing tables: %v", err)
+	}
+	if total != 1 || len(tables) != 1 || tables[0].ID != kept.ID {
+		t.Errorf("Expected only the table saved within the expiry to be listed, got %d: %+v", total, tables)
+	}
+	if _, err := models.GetSession(h.Context, gone.ID, h.Client); err != nil {
+		t.Errorf("Expected listing the lobby to leave the table alone, got %v", err)
+	}
+
+	// Redis expires the table, and the janitor prunes its entry
+	h.Client.Del(h.Context, models.SessionKey(gone.ID))
+	if err := h.sweepTables(h.Context); err != nil {
+		t.Fatalf("Error sweeping tables: %v", err)
+	}
+	models.SessionTTL = models.DefaultSessionTTL
+	tables, total, _ = models.ListTables(h.Context, h.Client, models.LobbyFilter{Limit: 10})
+	if total != 1 || len(tables) != 1 || tables[0].ID != kept.ID {
+		t.Errorf("Expected the janitor to drop only the expired table from the lobby, got %d: %+v", total, tables)
+	}
+}
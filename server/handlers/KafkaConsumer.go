@@ -1,96 +1,132 @@
 package handlers
 
 import (
-    "blackjackapi/models"
-    "crypto/tls"
-    "fmt"
-    "github.com/gorilla/mux"
-    "github.com/segmentio/kafka-go"
-    "github.com/segmentio/kafka-go/sasl/scram"
-    "log"
-    "net/http"
-    "strings"
+	"blackjackapi/models"
+	"blackjackapi/render"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
 )
 
-// documentation from upstash kafka.
-
+// KafkaSSEHandler streams the events of a table to the client. By default
+// each event is written as the rendered status box and board for terminal
+// viewers, drawn in the render style chosen with ?style=; with ?format=json
+// every event is sent as a standard SSE frame whose data is the JSON-encoded
+// models.Event. Viewers may give their ?name= so the host can kick them;
+// the stream ends when they are kicked, the table is closed or the server
+// shuts down. A seated player streaming under their name is present at the
+// table while the stream is open.
 func (h *Handler) KafkaSSEHandler(w http.ResponseWriter, r *http.Request) {
-    // Set HTTP headers
-    w.Header().Set("Content-Type", "text/event-stream")
-    w.Header().Set("Cache-Control", "no-cache")
-    w.Header().Set("Connection", "keep-alive")
+	if h.shuttingDown(w) {
+		return
+	}
+	// Get tableID from request parameters
+	vars := mux.Vars(r)
+	tableID := vars["tableID"]
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "text" && format != "json" {
+		http.Error(w, "Unknown stream format. Use text or json.", http.StatusBadRequest)
+		return
+	}
+	style, err := render.Lookup(r.URL.Query().Get("style"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-    // Get tableID from request parameters
-    vars := mux.Vars(r)
-    tableID := vars["tableID"]
+	// Get session information
+	table, err := models.GetSession(h.Context, tableID, h.Client)
+	if err != nil {
+		http.Error(w, "Failed to retrieve table from Redis. Ensure tableID has been created and is correct", http.StatusInternalServerError)
+		return
+	}
+	// Viewers may name themselves so the host can kick them
+	viewer := r.URL.Query().Get("name")
+	if viewer != "" && table.IsBanned(viewer) {
+		http.Error(w, "You have been removed from this table", http.StatusForbidden)
+		return
+	}
 
-    // Get session information
-    _, err := models.GetSession(h.Context, tableID, h.Client)
-    if err != nil {
-        http.Error(w, "Failed to retrieve table from Redis. Ensure tableID has been created and is correct", http.StatusInternalServerError)
-        return
-    }
+	// Subscribe before writing anything so no event is missed after the connect message
+	ctx := r.Context()
+	events, err := h.Broker.Subscribe(ctx, tableID)
+	if err != nil {
+		http.Error(w, "Failed to subscribe to table events", http.StatusInternalServerError)
+		return
+	}
 
-    // Write connection message
-    connected := fmt.Sprintf("Connected to table %s\n\n", tableID)
-    _, err = w.Write([]byte(connected))
-    if err != nil {
-        log.Printf("Error writing SSE event to response: %v", err)
-        return
-    }
+	// Set HTTP headers
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
 
-    // Create Kafka reader
-    mechanism, _ := scram.Mechanism(scram.SHA512, h.KAFKAUSERNAME, h.KAFKAPASSWORD)
-    kafkaReader := kafka.NewReader(kafka.ReaderConfig{
-        Brokers:     []string{h.KAFKAADDRESS},
-        GroupID:     tableID,
-        Topic:       "broadcast",
-        Dialer:      &kafka.Dialer{SASLMechanism: mechanism, TLS: &tls.Config{}},
-        StartOffset: kafka.LastOffset,
-        // Disable auto-commit to manually commit offsets
-        CommitInterval: 0,
-    })
+	// Write connection message
+	connected := models.NewEvent(models.EventConnected, table, fmt.Sprintf("Connected to table %s", tableID))
+	if err := writeEvent(w, format, style, connected); err != nil {
+		log.Printf("Error writing SSE event to response: %v", err)
+		return
+	}
 
-    // Close Kafka reader when function returns
-    defer kafkaReader.Close()
+	if viewer != "" {
+		h.playerConnected(tableID, viewer)
+		defer h.playerDisconnected(tableID, viewer)
+	}
 
-    // Read messages from Kafka and send them to the client
-    ctx := r.Context()
-    for {
-        select {
-        case <-ctx.Done():
-            return // Stop processing if the request is canceled
-        default:
-            message, err := kafkaReader.ReadMessage(ctx)
-            if err != nil {
-                log.Printf("Error reading message from Kafka: %v", err)
-                return
-            }
-            
-            // Check if the message key matches the desired tableID
-            if string(message.Key) != tableID {
-                continue
-            }
+	// Send events to the client until it goes away or the stream ends
+	for {
+		select {
+		case <-ctx.Done():
+			return // Stop processing if the request is canceled
+		case <-h.stopStreams():
+			writeEvent(w, format, style, h.shutdownEvent())
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, format, style, event); err != nil {
+				log.Printf("Error writing SSE event to response: %v", err)
+				return
+			}
+			if event.Type == models.EventTableExpired || event.Type == models.EventTableClosed {
+				return // The table is gone, nothing more will happen at it
+			}
+			if viewer != "" && event.Session != nil && event.Session.IsBanned(viewer) {
+				return // The host kicked this viewer
+			}
+		}
+	}
+}
 
-            // Write message to the client
-            replacedString := strings.ReplaceAll(string(message.Value), "+", " ")
-            _, err = w.Write([]byte(replacedString + "\n\n"))
-            if err != nil {
-                log.Printf("Error writing SSE event to response: %v", err)
-                return
-            }
-            
-            // Flush the response writer to ensure data is sent immediately
-            if f, ok := w.(http.Flusher); ok {
-                f.Flush()
-            }
+// writeEvent writes one event in the requested format and flushes it to the client
+func writeEvent(w http.ResponseWriter, format string, style render.Style, event models.Event) error {
+	var err error
+	if format == "json" {
+		var data []byte
+		data, err = json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		// The retry field sets how long the client's EventSource waits before reconnecting
+		if event.RetryMS > 0 {
+			_, err = fmt.Fprintf(w, "retry: %d\n", event.RetryMS)
+		}
+		if err == nil {
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+	} else {
+		_, err = fmt.Fprintf(w, "%s\n", render.Event(event, style))
+	}
+	if err != nil {
+		return err
+	}
 
-            // Manually commit the offset to mark the message as consumed
-            err = kafkaReader.CommitMessages(ctx, message)
-            if err != nil {
-                log.Printf("Error committing message offset: %v", err)
-                return
-            }
-        }
-    }
+	// Flush the response writer to ensure data is sent immediately
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
 }
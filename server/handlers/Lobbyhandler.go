@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"blackjackapi/render"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// defaultLobbyLimit is how many tables are listed when no limit is given
+const defaultLobbyLimit = 20
+
+// lobbyPage is one page of the lobby listing
+type lobbyPage struct {
+	Tables []models.TableSummary `json:"tables"`
+	Total  int                   `json:"total"`
+	Offset int                   `json:"offset"`
+	Limit  int                   `json:"limit"`
+}
+
+// ListTablesHandler lists the public tables in the lobby, most recently
+// active first, filtered by ?state= and ?variant= and paged by ?offset= and ?limit=
+func (h *Handler) ListTablesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.LobbyFilter{
+		State:   query.Get("state"),
+		Variant: query.Get("variant"),
+		Limit:   defaultLobbyLimit,
+	}
+	switch filter.State {
+	case "", models.TableWaiting, models.TableFull, models.TableInProgress:
+	default:
+		http.Error(w, "Unknown table state. Use waiting, full or in_progress.", http.StatusBadRequest)
+		return
+	}
+	if offset := query.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = n
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > models.MaxLobbyLimit {
+			http.Error(w, fmt.Sprintf("Limit must be between 1 and %d", models.MaxLobbyLimit), http.StatusBadRequest)
+			return
+		}
+		filter.Limit = n
+	}
+
+	tables, total, err := models.ListTables(h.Context, h.Client, filter)
+	if err != nil {
+		http.Error(w, "Failed to list tables from Redis", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lobbyPage{Tables: tables, Total: total, Offset: filter.Offset, Limit: filter.Limit})
+}
+
+// LobbyStreamHandler streams table_created, table_updated and table_closed
+// events for every public table, as text or JSON SSE frames like a table stream
+func (h *Handler) LobbyStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown(w) {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "text" && format != "json" {
+		http.Error(w, "Unknown stream format. Use text or json.", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	events, err := h.Broker.Subscribe(ctx, models.LobbyStream)
+	if err != nil {
+		http.Error(w, "Failed to subscribe to lobby events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	connected := models.Event{Type: models.EventConnected, Message: "Connected to the lobby"}
+	if err := writeEvent(w, format, render.ASCII, connected); err != nil {
+		log.Printf("Error writing SSE event to response: %v", err)
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.stopStreams():
+			writeEvent(w, format, render.ASCII, h.shutdownEvent())
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, format, render.ASCII, event); err != nil {
+				log.Printf("Error writing SSE event to response: %v", err)
+				return
+			}
+		}
+	}
+}
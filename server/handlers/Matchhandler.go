@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// matchRequest is the body of a match request
+type matchRequest struct {
+	Format string `json:"format"`
+	Games  int    `json:"games"`
+}
+
+// SetMatchHandler lets the host start a new match at a table. Every game the
+// table finishes counts towards it, and the next game starts by itself until
+// the match is decided.
+func (h *Handler) SetMatchHandler(w http.ResponseWriter, r *http.Request) {
+	var request matchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&request); err != nil {
+		http.Error(w, "Invalid match request", http.StatusBadRequest)
+		return
+	}
+	match, err := models.NewMatch(request.Format, request.Games)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	table, ok := h.updateHostedTable(w, r, func(latest *models.Session) (int, error) {
+		if err := latest.SetMatch(match); err != nil {
+			return http.StatusConflict, err
+		}
+		return http.StatusOK, nil
+	})
+	if !ok {
+		return
+	}
+	message := fmt.Sprintf("A new match has been set up. %s", table.MatchScore())
+	if err := h.broadcast(models.EventMatchStarted, table, message); err != nil {
+		http.Error(w, "Failed to broadcast table update", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(table)
+}
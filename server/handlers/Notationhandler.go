@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"blackjackapi/notation"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxNotationSize limits the body accepted by ImportTableHandler
+const maxNotationSize = 64 << 10
+
+// ExportTableHandler sends the current game of a table in game notation, or
+// only its position with ?format=position
+func (h *Handler) ExportTableHandler(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.loadTable(w, mux.Vars(r)["tableID"])
+	if !ok {
+		return
+	}
+	if !table.TwoSided() {
+		http.Error(w, "Only games between two sides can be exported", http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	switch r.URL.Query().Get("format") {
+	case "", "game":
+		io.WriteString(w, notation.Export(table, time.Now()).String())
+	case "position":
+		io.WriteString(w, notation.Position(table)+"\n")
+	default:
+		http.Error(w, "Unknown export format. Use game or position.", http.StatusBadRequest)
+	}
+}
+
+// ImportTableHandler creates a table from a game in notation and sends the
+// client the new table ID. Every move is replayed through the game rules.
+func (h *Handler) ImportTableHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxNotationSize+1))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxNotationSize {
+		http.Error(w, "Game notation is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	game, err := notation.Parse(string(body))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid game notation: %v", err), http.StatusBadRequest)
+		return
+	}
+	table, err := game.Replay(uuid.New().String())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid game: %v", err), http.StatusBadRequest)
+		return
+	}
+	// Whoever imports the game hosts its table
+	token, err := table.NewHostToken()
+	if err != nil {
+		http.Error(w, "Trouble saving table. Please try again.", http.StatusInternalServerError)
+		return
+	}
+	// Claim a join code before anyone can see the table
+	if err := models.ReserveTable(h.Context, table, h.Client); err != nil {
+		http.Error(w, "Trouble saving table. Please try again.", http.StatusInternalServerError)
+		return
+	}
+	err = models.SaveSession(h.Context, table, h.Client)
+	if err != nil {
+		http.Error(w, "Trouble saving table. Please try again.", http.StatusInternalServerError)
+		return
+	}
+	h.announceTable(models.EventTableCreated, table)
+	w.Header().Set("X-Host-Token", token)
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(table.ID))
+}
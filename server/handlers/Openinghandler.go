@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"blackjackapi/engine"
+	"encoding/json"
+	"net/http"
+)
+
+// tableOpening is the opening being played at a table
+type tableOpening struct {
+	TableID string `json:"table_id"`
+	// InBook is false once the moves leave the book or when the table is
+	// not a standard game from an empty board
+	InBook bool   `json:"in_book"`
+	Name   string `json:"name"`
+	// Moves are the moves of the named opening
+	Moves []int `json:"moves"`
+	// Continuations are the book moves for the position, best first
+	Continuations []int `json:"continuations"`
+}
+
+// errBookDisabled answers opening requests when the server has no opening book
+const errBookDisabled = "The opening book is disabled on this server"
+
+// OpeningsHandler lists the named openings of the book, or names the opening
+// being played at the table given by the table query parameter
+func (h *Handler) OpeningsHandler(w http.ResponseWriter, r *http.Request) {
+	if h.Book == nil {
+		http.Error(w, errBookDisabled, http.StatusNotFound)
+		return
+	}
+	tableID := r.URL.Query().Get("table")
+	w.Header().Set("Content-Type", "application/json")
+	if tableID == "" {
+		json.NewEncoder(w).Encode(h.Book.Openings())
+		return
+	}
+	table, ok := h.loadTable(w, tableID)
+	if !ok {
+		return
+	}
+
+	response := tableOpening{TableID: table.ID, Moves: []int{}, Continuations: []int{}}
+	standard := len(table.Grid) == engine.BookHeight && len(table.Grid[0]) == engine.BookWidth && table.OccupiedSlots == len(table.Moves)
+	if standard {
+		moves := make([]int, len(table.Moves))
+		for i, move := range table.Moves {
+			moves[i] = move.Column
+		}
+		if opening, ok := h.Book.Name(moves); ok {
+			response.Name = opening.Name
+			response.Moves = opening.Moves
+		}
+		response.Continuations = h.Book.Next(moves)
+		response.InBook = h.Book.Contains(moves)
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestOpeningsWithoutBook tests that the opening endpoints answer 404
+// when the server runs without an opening book
+func TestOpeningsWithoutBook(t *testing.T) {
+	h := newTestHandler(t)
+	table := newTestTable(t, h, true, "alice", "bob")
+	for _, path := range []string{"/openings", "/openings?table=" + table.ID} {
+		if w := serve(h.OpeningsHandler, "GET", path, nil, nil); w.Code != http.StatusOK {
+			t.Errorf("Expected 200 for %s with the book, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	h.Book = nil
+	for _, path := range []string{"/openings", "/openings?table=" + table.ID} {
+		if w := serve(h.OpeningsHandler, "GET", path, nil, nil); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s without the book, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+}
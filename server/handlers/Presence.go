@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// presenceKey identifies the connections of a player to a table
+func presenceKey(tableID, name string) string {
+	return tableID + "/" + name
+}
+
+// playerConnected counts a named connection to a table's stream. The first
+// connection of a seated player who was away brings them back and resumes a
+// game that was paused for them.
+func (h *Handler) playerConnected(tableID, name string) {
+	h.presenceMu.Lock()
+	if h.connections == nil {
+		h.connections = map[string]int{}
+	}
+	h.connections[presenceKey(tableID, name)]++
+	first := h.connections[presenceKey(tableID, name)] == 1
+	h.presenceMu.Unlock()
+	if !first {
+		return
+	}
+
+	var paused bool
+	table, err := h.updateSession(tableID, func(table *models.Session) error {
+		paused = table.Paused
+		if !table.Reconnect(name) {
+			return errUnchanged
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, errUnchanged) && !errors.Is(err, redis.Nil) {
+			log.Printf("Error saving table %s after %s reconnected: %v", tableID, name, err)
+		}
+		return
+	}
+	message := fmt.Sprintf("Player %s reconnected", name)
+	if paused && !table.Paused {
+		message += ", the game resumes"
+	}
+	if err := h.broadcast(models.EventPlayerReconnected, table, message); err != nil {
+		log.Printf("Error broadcasting reconnection: %v", err)
+	}
+	if paused && !table.Paused {
+		h.notifyTurn(table)
+	}
+}
+
+// playerDisconnected counts a named connection to a table's stream going
+// away. When a seated player's last connection drops, they have
+// ReconnectGrace to come back before DisconnectAction is applied to the game.
+func (h *Handler) playerDisconnected(tableID, name string) {
+	h.presenceMu.Lock()
+	h.connections[presenceKey(tableID, name)]--
+	last := h.connections[presenceKey(tableID, name)] == 0
+	if last {
+		delete(h.connections, presenceKey(tableID, name))
+	}
+	h.presenceMu.Unlock()
+	// Players cut off by a shutdown are not marked away
+	if !last || h.ReconnectGrace <= 0 || h.isStopping() {
+		return
+	}
+
+	now := time.Now()
+	table, err := h.updateSession(tableID, func(table *models.Session) error {
+		if !table.Disconnect(name, now) {
+			return errUnchanged
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, errUnchanged) && !errors.Is(err, redis.Nil) {
+			log.Printf("Error saving table %s after %s disconnected: %v", tableID, name, err)
+		}
+		return
+	}
+	message := fmt.Sprintf("Player %s disconnected", name)
+	if table.Status {
+		message += fmt.Sprintf(" and has %s to reconnect", h.ReconnectGrace)
+	}
+	if err := h.broadcast(models.EventPlayerDisconnected, table, message); err != nil {
+		log.Printf("Error broadcasting disconnection: %v", err)
+	}
+	if table.Status {
+		h.awaitReconnect(tableID, name, now)
+	}
+}
+
+// awaitReconnect applies DisconnectAction to the game at the table if the
+// player who went away at since is still missing once their grace period is over
+func (h *Handler) awaitReconnect(tableID, name string, since time.Time) {
+	time.AfterFunc(time.Until(since.Add(h.ReconnectGrace)), func() { h.expireGrace(tableID, name, since) })
+}
+
+// expireGrace forfeits or pauses the game of a player who did not reconnect in time
+func (h *Handler) expireGrace(tableID, name string, since time.Time) {
+	done, ok := h.track()
+	if !ok {
+		return
+	}
+	defer done()
+	table, err := h.updateSession(tableID, func(table *models.Session) error {
+		if !table.Status || table.Paused {
+			return errUnchanged
+		}
+		index := table.PlayerIndex(name)
+		if index == -1 || table.Players[index].Away == nil || !table.Players[index].Away.Equal(since) {
+			return errUnchanged // They came back, possibly leaving again later
+		}
+		table.Abandon(name, h.DisconnectAction)
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, errUnchanged) && !errors.Is(err, redis.Nil) {
+			log.Printf("Error saving table %s after %s did not reconnect: %v", tableID, name, err)
+		}
+		return
+	}
+	if table.Paused {
+		message := fmt.Sprintf("The game is paused until %s reconnects", name)
+		if err := h.broadcast(models.EventGamePaused, table, message); err != nil {
+			log.Printf("Error broadcasting pause: %v", err)
+		}
+		return
+	}
+	message := fmt.Sprintf("Player %s did not reconnect in time and forfeits the game", name)
+	if err := h.broadcast(models.EventGameForfeited, table, message); err != nil {
+		log.Printf("Error broadcasting forfeit: %v", err)
+	}
+	h.gameOver(table)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestPresenceDuringMoves tests that a player's stream dropping and coming
+// back while moves are played never overwrites a move
+func TestPresenceDuringMoves(t *testing.T) {
+	h := newTestHandler(t)
+	h.ReconnectGrace = time.Hour
+	table := newTestTable(t, h, true, "alice", "bob")
+
+	h.playerConnected(table.ID, "bob")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			h.playerDisconnected(table.ID, "bob")
+			h.playerConnected(table.ID, "bob")
+		}
+	}()
+
+	played := 0
+	for _, column := range []int{0, 1, 0, 1, 2, 3} {
+		for {
+			latest, err := models.GetSession(h.Context, table.ID, h.Client)
+			if err != nil {
+				t.Fatalf("Error loading table: %v", err)
+			}
+			status, err := h.playMove(latest, latest.GetPlayersTurn(), column)
+			if status == http.StatusConflict {
+				continue
+			}
+			if err != nil {
+				t.Fatalf("Error playing column %d: %v", column, err)
+			}
+			played++
+			break
+		}
+	}
+	wg.Wait()
+
+	table, err := models.GetSession(h.Context, table.ID, h.Client)
+	if err != nil {
+		t.Fatalf("Error loading table: %v", err)
+	}
+	if len(table.Moves) != played {
+		t.Errorf("Expected %d moves to be saved, got %d", played, len(table.Moves))
+	}
+	if bob := table.Players[table.PlayerIndex("bob")]; bob.Away != nil {
+		t.Errorf("Expected bob to be back at the table, got away since %v", bob.Away)
+	}
+}
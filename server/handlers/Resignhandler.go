@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+// ResignHandler ends the game in progress with a win for the player's opponent
+func (h *Handler) ResignHandler(w http.ResponseWriter, r *http.Request) {
+	h.gameAction(w, r, models.EventPlayerResigned, "Player %s resigned the game", func(table *models.Session, name string) error {
+		return table.Resign(name)
+	})
+}
+
+// OfferDrawHandler offers the player's opponent a draw, which stands until
+// the opponent answers it or makes a move
+func (h *Handler) OfferDrawHandler(w http.ResponseWriter, r *http.Request) {
+	h.gameAction(w, r, models.EventDrawOffered, "Player %s offers a draw", func(table *models.Session, name string) error {
+		return table.OfferDraw(name)
+	})
+}
+
+// AcceptDrawHandler accepts the opponent's draw offer, ending the game without a winner
+func (h *Handler) AcceptDrawHandler(w http.ResponseWriter, r *http.Request) {
+	h.gameAction(w, r, models.EventDrawAccepted, "Player %s accepted the draw", func(table *models.Session, name string) error {
+		return table.AcceptDraw(name)
+	})
+}
+
+// DeclineDrawHandler turns down the opponent's draw offer
+func (h *Handler) DeclineDrawHandler(w http.ResponseWriter, r *http.Request) {
+	h.gameAction(w, r, models.EventDrawDeclined, "Player %s declined the draw", func(table *models.Session, name string) error {
+		return table.DeclineDraw(name)
+	})
+}
+
+// ClaimAbandonmentHandler wins the game for a player whose opponent has been
+// disconnected for longer than the reconnection grace period
+func (h *Handler) ClaimAbandonmentHandler(w http.ResponseWriter, r *http.Request) {
+	h.gameAction(w, r, models.EventAbandonmentClaimed, "Player %s claimed the game their opponent abandoned", func(table *models.Session, name string) error {
+		return table.ClaimAbandonment(name, time.Now(), h.ReconnectGrace)
+	})
+}
+
+// errNotInPerson is returned by game actions of players seated through the
+// bot API or as the built-in AI
+var errNotInPerson = errors.New("Only players seated in person can do that")
+
+// gameAction applies an action of a seated player to the latest version of
+// the game at the table, saves it and broadcasts eventType with a message
+// formatted with the player's name. A game the action ended is followed up
+// like any other.
+func (h *Handler) gameAction(w http.ResponseWriter, r *http.Request, eventType, format string, action func(*models.Session, string) error) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	var actionErr error
+	table, err := h.updateSession(vars["tableID"], func(latest *models.Session) error {
+		if index := latest.PlayerIndex(name); index != -1 && (latest.Players[index].Bot || latest.Players[index].AI > 0) {
+			return errNotInPerson
+		}
+		actionErr = action(latest, name)
+		return actionErr
+	})
+	if errors.Is(err, redis.Nil) {
+		http.Error(w, "Table does not exist. Please make sure your table id is correct.", http.StatusNotFound)
+		return
+	} else if errors.Is(err, errNotInPerson) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	} else if errors.Is(err, models.ErrPlayerNotFound) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if errors.Is(err, models.ErrUpdateConflict) || (err != nil && err == actionErr) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to save table to Redis", http.StatusInternalServerError)
+		return
+	}
+	if err := h.broadcast(eventType, table, fmt.Sprintf(format, name)); err != nil {
+		http.Error(w, "Failed to broadcast table update", http.StatusInternalServerError)
+		return
+	}
+	if !table.Status {
+		h.gameOver(table)
+	}
+	w.WriteHeader(http.StatusOK)
+}
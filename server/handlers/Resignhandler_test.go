@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"net/http"
+	"testing"
+)
+
+// TestResignAfterMove tests that a resignation is applied to the table as
+// it is when saved, keeping a move made after the resigning request loaded it
+func TestResignAfterMove(t *testing.T) {
+	h := newTestHandler(t)
+	table := newTestTable(t, h, true, "alice", "bob")
+	mover := table.GetPlayersTurn()
+	resigner := "alice"
+	if mover == "alice" {
+		resigner = "bob"
+	}
+	interleaveSave(t, h, table.ID, func(latest *models.Session) {
+		if _, err := latest.Play(mover, 3); err != nil {
+			t.Errorf("Error playing move: %v", err)
+		}
+	})
+
+	w := serve(h.ResignHandler, http.MethodGet, "/", nil, map[string]string{"tableID": table.ID, "name": resigner})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	saved, err := models.GetSession(h.Context, table.ID, h.Client)
+	if err != nil {
+		t.Fatalf("Error loading table: %v", err)
+	}
+	if len(saved.Moves) != 1 {
+		t.Errorf("Expected the move made meanwhile to be kept, got %d moves", len(saved.Moves))
+	}
+	if saved.Status || saved.Players[saved.PlayerIndex(mover)].Wins != 1 {
+		t.Errorf("Expected %s to resign the game to %s", resigner, mover)
+	}
+}
+
+// TestResignByBot tests that players seated through the bot API cannot resign
+func TestResignByBot(t *testing.T) {
+	h := newTestHandler(t)
+	table := models.NewSession("table")
+	bot := models.NewPlayer("bot")
+	bot.Bot = true
+	table.AddPlayer(bot)
+	seatTestTable(t, h, table, true, "alice")
+
+	w := serve(h.ResignHandler, http.MethodGet, "/", nil, map[string]string{"tableID": table.ID, "name": "bot"})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %d", w.Code)
+	}
+	w = serve(h.ResignHandler, http.MethodGet, "/", nil, map[string]string{"tableID": "missing", "name": "alice"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing table, got %d", w.Code)
+	}
+}
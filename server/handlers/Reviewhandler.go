@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"blackjackapi/engine"
+	"blackjackapi/models"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"time"
+)
+
+// defaultReviewTime is the search time spent on each position of a review
+const defaultReviewTime = 100 * time.Millisecond
+
+// playerReview is the accuracy of one player over a reviewed game
+type playerReview struct {
+	Name     string  `json:"name"`
+	Symbol   string  `json:"symbol"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// reviewedMove is a move review together with the player who made it
+type reviewedMove struct {
+	Player string `json:"player"`
+	engine.MoveReview
+}
+
+// reviewResponse is the annotated review of a finished game
+type reviewResponse struct {
+	Players []playerReview `json:"players"`
+	Moves   []reviewedMove `json:"moves"`
+}
+
+// TableReviewHandler analyzes every move of the last finished game of a table.
+// The search budget applies to each position, but the whole review is held
+// to MaxAnalysisTime, shared evenly among the moves.
+func (h *Handler) TableReviewHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.Analysis {
+		http.Error(w, errAnalysisDisabled, http.StatusNotFound)
+		return
+	}
+	budget, err := h.parseBudget(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("time_ms") == "" {
+		budget.MaxTime = min(defaultReviewTime, h.MaxAnalysisTime)
+	}
+	table, ok := h.loadTable(w, mux.Vars(r)["tableID"])
+	if !ok {
+		return
+	}
+	if table.Status {
+		http.Error(w, "The game is still in progress", http.StatusConflict)
+		return
+	}
+	if len(table.Moves) == 0 {
+		http.Error(w, "No moves to review", http.StatusConflict)
+		return
+	}
+	if !table.TwoSided() {
+		http.Error(w, "Only games between two sides can be reviewed", http.StatusConflict)
+		return
+	}
+
+	start, err := startingBoard(table)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Game cannot be reviewed: %v", err), http.StatusBadRequest)
+		return
+	}
+	columns := make([]int, len(table.Moves))
+	for i, move := range table.Moves {
+		columns[i] = move.Column
+	}
+	// A zero MaxTime would mean no limit, so every move gets some time
+	share := max(h.MaxAnalysisTime/time.Duration(len(columns)), time.Nanosecond)
+	budget.MaxTime = min(budget.MaxTime, share)
+	searcher := acquireSearcher(w, r)
+	if searcher == nil {
+		return
+	}
+	defer releaseSearcher(searcher)
+	review, err := searcher.Review(start, columns, budget)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Game cannot be reviewed: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := reviewResponse{Players: []playerReview{}, Moves: []reviewedMove{}}
+	names := map[string]string{}
+	for i, side := range table.Sides() {
+		symbol := models.PlayerSymbol(i)
+		names[symbol] = side
+		if accuracy, ok := review.Accuracy[symbol]; ok {
+			response.Players = append(response.Players, playerReview{Name: side, Symbol: symbol, Accuracy: accuracy})
+		}
+	}
+	for _, move := range review.Moves {
+		response.Moves = append(response.Moves, reviewedMove{Player: names[move.Symbol], MoveReview: move})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// startingBoard rebuilds the position a table's game started from by taking
+// its moves back off the grid, which keeps any setup position in place
+func startingBoard(table *models.Session) (*engine.Board, error) {
+	return engine.FromSession(table.StartingBoard())
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestReviewTimeLimit tests that reviewing a long game takes no longer than
+// the analysis time limit, however much time each position may have
+func TestReviewTimeLimit(t *testing.T) {
+	h := newTestHandler(t)
+	h.Analysis, h.MaxAnalysisTime = true, 300*time.Millisecond
+	table := newTestTable(t, h, true, "alice", "bob")
+	moves := []int{3, 4, 5, 6, 0, 1, 2, 3, 4, 5, 6, 0, 1, 2, 3, 4, 5, 6, 0, 1, 2, 2, 4, 5, 5, 0, 1, 2, 2, 4, 5, 6, 0, 1, 1, 4}
+	for _, column := range moves {
+		if _, err := table.Play(table.GetPlayersTurn(), column); err != nil {
+			t.Fatalf("Error playing column %d: %v", column, err)
+		}
+	}
+	if table.Status {
+		table.FinishGame(-1)
+	}
+	if err := models.SaveSession(h.Context, table, h.Client); err != nil {
+		t.Fatalf("Error saving table: %v", err)
+	}
+
+	start := time.Now()
+	w := serve(h.TableReviewHandler, "GET", "/table/review?time_ms=300", nil, map[string]string{"tableID": table.ID})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the review of %d moves to take about %v, took %v", len(moves), h.MaxAnalysisTime, elapsed)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// StopStreams ends every table, lobby, tournament and bot stream with a
+// server_shutdown event telling the subscriber when to reconnect, and
+// turns away new streams and background moves. Register it with
+// http.Server.RegisterOnShutdown so streams end while the server drains.
+func (h *Handler) StopStreams() {
+	h.drainMu.Lock()
+	defer h.drainMu.Unlock()
+	if h.stopping == nil {
+		h.stopping = make(chan struct{})
+	}
+	if !h.stopped {
+		h.stopped = true
+		close(h.stopping)
+	}
+}
+
+// Shutdown stops the streams, waits for the moves played in the background
+// to finish and closes the broker and the Redis client. It gives up
+// waiting once ctx is done, closing them anyway.
+func (h *Handler) Shutdown(ctx context.Context) error {
+	h.StopStreams()
+	drained := make(chan struct{})
+	go func() {
+		h.inflight.Wait()
+		close(drained)
+	}()
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = fmt.Errorf("Gave up waiting for moves in flight: %w", ctx.Err())
+	}
+	if closeErr := h.Broker.Close(); closeErr != nil {
+		log.Printf("Error closing the event broker: %v", closeErr)
+	}
+	if closeErr := h.Client.Close(); closeErr != nil {
+		log.Printf("Error closing the Redis client: %v", closeErr)
+	}
+	return err
+}
+
+// stopStreams returns a channel closed once the server is shutting down
+func (h *Handler) stopStreams() <-chan struct{} {
+	h.drainMu.Lock()
+	defer h.drainMu.Unlock()
+	if h.stopping == nil {
+		h.stopping = make(chan struct{})
+	}
+	return h.stopping
+}
+
+// track counts a move played in the background until done is called. It
+// reports false once the server is shutting down, when the move is left
+// for after the restart.
+func (h *Handler) track() (done func(), ok bool) {
+	h.drainMu.Lock()
+	defer h.drainMu.Unlock()
+	if h.stopped {
+		return nil, false
+	}
+	h.inflight.Add(1)
+	return h.inflight.Done, true
+}
+
+// isStopping reports whether the server is shutting down
+func (h *Handler) isStopping() bool {
+	h.drainMu.Lock()
+	defer h.drainMu.Unlock()
+	return h.stopped
+}
+
+// shuttingDown reports whether the server is shutting down, answering 503
+// with a Retry-After header itself when it is
+func (h *Handler) shuttingDown(w http.ResponseWriter) bool {
+	if !h.isStopping() {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(h.ShutdownRetry.Seconds())))
+	http.Error(w, "The server is shutting down. Please reconnect shortly.", http.StatusServiceUnavailable)
+	return true
+}
+
+// shutdownEvent is the last event of every stream when the server shuts down
+func (h *Handler) shutdownEvent() models.Event {
+	return models.Event{
+		Type:    models.EventServerShutdown,
+		Message: fmt.Sprintf("The server is shutting down. Reconnect in %s.", h.ShutdownRetry),
+		RetryMS: h.ShutdownRetry.Milliseconds(),
+	}
+}
@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"blackjackapi/models"
+	"blackjackapi/render"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // Creates a connect 4 table and sends the client the board id.
@@ -19,90 +25,292 @@ func (h *Handler) CreateTableHandler(w http.ResponseWriter, r *http.Request) {
 	table := models.NewSession(tableID)
 	// Set the table ID
 	table.ID = tableID
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "text" && format != "json" {
+		http.Error(w, "Unknown response format. Use text or json.", http.StatusBadRequest)
+		return
+	}
+	// Private tables are left out of the lobby's public listing
+	table.Private = query.Get("private") == "true"
+	seats := 0
+	if value := query.Get("seats"); value != "" {
+		var err error
+		if seats, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Invalid number of seats", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := table.SetSeats(seats, query.Get("teams") == "true"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	table.FirstMove = query.Get("first_move")
+	if err := models.ValidateFirstMove(table.FirstMove); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if name := strings.TrimSpace(query.Get("name")); name != "" {
+		if err := models.ValidateTableName(name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		table.Name = name
+	}
+	if err := table.SetPassword(query.Get("password")); errors.Is(err, models.ErrPasswordLength) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "Trouble saving table. Please try again.", http.StatusInternalServerError)
+		return
+	}
+	// The creator hosts the table
+	token, err := table.NewHostToken()
+	if err != nil {
+		http.Error(w, "Trouble saving table. Please try again.", http.StatusInternalServerError)
+		return
+	}
+	// Claim the name and a join code before anyone can see the table
+	if err := models.ReserveTable(h.Context, table, h.Client); errors.Is(err, models.ErrTableNameTaken) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Trouble saving table. Please try again.", http.StatusInternalServerError)
+		return
+	}
 	// Save the table to Redis
-	err := models.SaveSession(h.Context, table, h.Client)
+	err = models.SaveSession(h.Context, table, h.Client)
 	if err != nil {
 		http.Error(w, "Trouble saving table. Please try again.", http.StatusInternalServerError)
 		return
 	}
+	h.announceTable(models.EventTableCreated, table)
+	w.Header().Set("X-Host-Token", token)
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(createdTable{
+			ID:        table.ID,
+			Name:      table.Name,
+			Code:      table.Code,
+			Invite:    invitePath(table.Code),
+			HostToken: token,
+			Private:   table.Private,
+			Protected: table.Protected,
+		})
+		return
+	}
 	// Respond to the client with the table ID
 	response := tableID
 	w.WriteHeader(http.StatusCreated)
 	w.Write([]byte(response))
 }
 
+// createdTable is the JSON answer to creating a table
+type createdTable struct {
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"`
+	Code      string `json:"code"`
+	Invite    string `json:"invite"`
+	HostToken string `json:"host_token"`
+	Private   bool   `json:"private,omitempty"`
+	Protected bool   `json:"protected,omitempty"`
+}
+
+// invitePath is the link that shares a table through its join code
+func invitePath(code string) string {
+	return "/join/" + code
+}
+
+// InviteHandler resolves the join code of an invite link to the table it leads to
+func (h *Handler) InviteHandler(w http.ResponseWriter, r *http.Request) {
+	tableID, err := models.ResolveCode(h.Context, mux.Vars(r)["code"], h.Client)
+	if errors.Is(err, models.ErrCodeNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve join code from Redis", http.StatusInternalServerError)
+		return
+	}
+	table, ok := h.loadTable(w, tableID)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(table.Summary())
+}
+
 // DeleteTableHandler
 // DeleteTableHandler deletes a Connect 4 table.
 func (h *Handler) DeleteTableHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	tableID := vars["tableID"]
 
+	table, ok := h.loadTable(w, tableID)
+	if !ok || !h.authorizeHost(w, r, table) {
+		return
+	}
 	// Delete the table from Redis
 	err := models.DeleteSession(h.Context, tableID, h.Client)
 	if err != nil {
 		http.Error(w, "Failed to delete table from Redis", http.StatusInternalServerError)
 		return
 	}
+	if err := h.broadcast(models.EventTableClosed, table, "The host closed the table"); err != nil {
+		log.Printf("Error broadcasting the closing of table %s: %v", tableID, err)
+	}
 	// Respond to the client
 	response := fmt.Sprintf("Connect 4 table with ID %s deleted successfully. Thank you for deleting the table.", tableID)
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(response))
 }
 
-// JoinTableHandler handles requests to join a Connect 4 table
+// GetTableHandler sends the client the current state of a Connect 4 table as JSON
+func (h *Handler) GetTableHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tableID := vars["tableID"]
+
+	table, ok := h.loadTable(w, tableID)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(table)
+}
+
+// JoinTableHandler handles requests to join a Connect 4 table. The host
+// seats the built-in AI by joining it as ai:<level>.
 func (h *Handler) JoinTableHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	tableID := vars["tableID"]
 	playerName := vars["name"]
 
+	player, ok, err := aiPlayer(playerName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		player = models.NewPlayer(playerName)
+	}
+	if h.seatPlayer(w, r, tableID, player) {
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// seatPlayer adds a player to a table and tells everyone connected to it,
+// answering any failure itself. The table's password is read from the
+// request; the host may join with their token instead, taking the host
+// role to the seat, and is let in even when the table is locked. Only the
+// host may seat the built-in AI, which never takes the host role.
+func (h *Handler) seatPlayer(w http.ResponseWriter, r *http.Request, tableID string, player *models.Player) bool {
 	// Retrieve table from Redis
 	table, err := models.GetSession(h.Context, tableID, h.Client)
 	if err != nil {
 		http.Error(w, "Failed to retrieve table from Redis", http.StatusInternalServerError)
-		return
+		return false
+	}
+	byHost := table.CheckHostToken(hostToken(r))
+	if player.AI > 0 && !byHost {
+		http.Error(w, "Only the host of the table can seat the AI", http.StatusForbidden)
+		return false
+	}
+	host := !player.Bot && player.AI == 0 && byHost
+	// The AI is seated by the host, so it passes the lock and password too
+	trusted := host || player.AI > 0
+	if table.IsBanned(player.Name) {
+		http.Error(w, "You have been removed from this table", http.StatusForbidden)
+		return false
+	}
+	if !trusted && table.Locked {
+		http.Error(w, "Table is locked", http.StatusForbidden)
+		return false
+	}
+	if !trusted && !table.CheckPassword(r.URL.Query().Get("password")) {
+		http.Error(w, "Incorrect table password", http.StatusForbidden)
+		return false
 	}
 	// Check if the table is already full
-	if len(table.Players) >= 2 {
+	if table.Full() {
 		http.Error(w, "Table is already full", http.StatusConflict)
-		return
+		return false
 	}
-	PlayerIsin := false
-
-	for _, v := range table.Players {
-		if v.Name == playerName {
-			PlayerIsin = true
-		}
+	if player.Bot && table.MaxPlayers() != models.MinSeats {
+		http.Error(w, "Bots only play at two player tables", http.StatusConflict)
+		return false
 	}
-
-	if PlayerIsin {
-		http.Error(w, fmt.Sprintf("Name %s has already been taken", playerName), http.StatusConflict)
-		return
+	if player.AI > 0 && table.MaxPlayers() != models.MinSeats {
+		http.Error(w, "The AI only plays at two player tables", http.StatusConflict)
+		return false
+	}
+	if table.PlayerIndex(player.Name) != -1 {
+		http.Error(w, fmt.Sprintf("Name %s has already been taken", player.Name), http.StatusConflict)
+		return false
 	}
-	// Create a new player
-	player := models.NewPlayer(playerName)
+	if !readAppearance(w, r, player) {
+		return false
+	}
+	symbol, color := player.Symbol, player.Color
 	// Add the player to the table
 	err = table.AddPlayer(player)
 	if err != nil {
 		http.Error(w, "Failed to add player to the table", http.StatusInternalServerError)
-		return
+		return false
+	}
+	if host {
+		table.Host = player.Name
 	}
 	// Save the updated table to Redis
 	err = models.SaveSession(h.Context, table, h.Client)
 	if err != nil {
 		http.Error(w, "Failed to save table to Redis", http.StatusInternalServerError)
-		return
+		return false
 	}
 
-	// Produce message to Kafka topic
+	// Broadcast to everyone connected to the table
 	message := fmt.Sprintf("Player %s joined table", player.Name)
-	message = table.StatusBoard(message) + table.StringBoard()
-
-	err = models.ProduceMessage(h.KAFKAADDRESS, table.ID, message, h.KAFKAUSERNAME, h.KAFKAPASSWORD)
+	if (symbol != "" && symbol != player.Symbol) || (color != "" && color != player.Color) {
+		message += fmt.Sprintf(" playing %s in %s, as their choice was taken", player.Symbol, player.Color)
+	}
+	err = h.broadcast(models.EventPlayerJoined, table, message)
 	if err != nil {
-		http.Error(w, "Failed to produce message to Kafka topic", http.StatusInternalServerError)
-		return
+		http.Error(w, "Failed to broadcast table update", http.StatusInternalServerError)
+		return false
 	}
-	w.WriteHeader(http.StatusCreated)
+	return true
+}
+
+// readAppearance reads the symbol, color and avatar a player picked from
+// the request, answering a bad choice itself
+func readAppearance(w http.ResponseWriter, r *http.Request, player *models.Player) bool {
+	query := r.URL.Query()
+	if symbol := query.Get("symbol"); symbol != "" {
+		err := models.ValidateSymbol(symbol)
+		if err == nil && render.Width(symbol) != 1 {
+			err = fmt.Errorf("Symbol %q is not one column wide", symbol)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+		player.Symbol = symbol
+	}
+	if color := query.Get("color"); color != "" {
+		if err := models.ValidateColor(color); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+		player.Color = color
+	}
+	if avatar := query.Get("avatar"); avatar != "" {
+		if err := models.ValidateAvatar(avatar); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+		player.Avatar = avatar
+	}
+	return true
 }
 
 // StartGameHandler handles requests to start a Connect 4 game
@@ -115,35 +323,142 @@ func (h *Handler) StartGameHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Table does not exist. Please make sure your table id is correct.", http.StatusInternalServerError)
 		return
 	}
-	// Check if there are exactly two players on the table
-	if len(table.Players) != 2 {
-		http.Error(w, "Need exactly two players to start the game", http.StatusBadRequest)
+	// Check every seat of the table is taken
+	if !table.Full() {
+		http.Error(w, fmt.Sprintf("Need exactly %d players to start the game", table.MaxPlayers()), http.StatusBadRequest)
 		return
 	}
-	// Start the game (for example, set the status to true)
-	table.Status = true
-	table.Starts++
-	table.Turn = table.Starts % 2
-	table.ClearBoard()
-	// Start
-	err = models.SaveSession(h.Context, table, h.Client)
-	if err != nil {
-		http.Error(w, "Failed to save table to Redis", http.StatusInternalServerError)
+	if table.Status {
+		http.Error(w, models.ErrGameInProgress.Error(), http.StatusConflict)
+		return
+	}
+	// Once a game has been played, the next one starts when every player is ready
+	if !rematchReady(table) {
+		http.Error(w, "Every player must be ready for a rematch", http.StatusConflict)
 		return
 	}
-	// Produce message to Kafka topic
+	if status, err := h.startGame(table, rematchReady); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// rematchReady reports whether a table may start its next game: the first
+// game starts at once, and later ones once every player is ready
+func rematchReady(table *models.Session) bool {
+	return table.Starts == 0 || table.AllReady()
+}
+
+// errCannotStart is returned when a table changed so that its next game
+// can no longer start
+var errCannotStart = errors.New("The table changed before the game could start")
+
+// startGame starts the next game of a table, letting its first move policy
+// pick the first player and placing any handicap, and tells everyone
+// connected to it. The game starts on the latest version of the table, and
+// only while its seats are all taken, no game is in progress and check, if
+// any, agrees. On failure it returns the HTTP status to answer with.
+func (h *Handler) startGame(table *models.Session, check func(*models.Session) bool) (int, error) {
+	updated, err := h.updateSession(table.ID, func(latest *models.Session) error {
+		if latest.Status {
+			return models.ErrGameInProgress
+		}
+		if !latest.Full() || (check != nil && !check(latest)) {
+			return errCannotStart
+		}
+		latest.Status = true
+		latest.Starts++
+		latest.Turn = latest.FirstSeat()
+		latest.ClearBoard()
+		latest.PlaceHandicap()
+		for _, player := range latest.Players {
+			player.Ready = false
+		}
+		return nil
+	})
+	if errors.Is(err, models.ErrGameInProgress) || errors.Is(err, errCannotStart) || errors.Is(err, models.ErrUpdateConflict) {
+		return http.StatusConflict, err
+	} else if errors.Is(err, redis.Nil) {
+		return http.StatusNotFound, errors.New("Table does not exist. Please make sure your table id is correct.")
+	} else if err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to save table to Redis")
+	}
+	*table = *updated
+	// Broadcast to everyone connected to the table
+	if table.FirstMove == models.FirstMoveCoinFlip {
+		message := fmt.Sprintf("The coin flip goes to %s, who moves first", table.GetPlayersTurn())
+		if err := h.broadcast(models.EventCoinFlipped, table, message); err != nil {
+			return http.StatusInternalServerError, errors.New("Failed to broadcast table update")
+		}
+	}
 	message := "Game has been started"
+	if err := h.broadcast(models.EventGameStarted, table, message); err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to broadcast table update")
+	}
+	h.notifyTurn(table)
+	// Players who are away when the game starts still get their grace period
+	for _, player := range table.Players {
+		if player.Away != nil {
+			h.awaitReconnect(table.ID, player.Name, *player.Away)
+		}
+	}
+	return http.StatusOK, nil
+}
 
-	message = table.StatusBoard(message) + table.StringBoard()
-	err = models.ProduceMessage(h.KAFKAADDRESS, tableID, message, h.KAFKAUSERNAME, h.KAFKAPASSWORD)
-	if err != nil {
-		http.Error(w, "Failed to produce message to Kafka topic", http.StatusInternalServerError)
+// ReadyHandler handles players asking for the next game at a table. The
+// game starts as soon as both players are ready.
+func (h *Handler) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	table, ok := h.loadTable(w, vars["tableID"])
+	if !ok {
+		return
+	}
+	if index := table.PlayerIndex(vars["name"]); index != -1 && table.Players[index].Bot {
+		http.Error(w, "Bots must signal ready through the bot API", http.StatusForbidden)
+		return
+	}
+	if status, err := h.markReady(table, vars["name"]); err != nil {
+		http.Error(w, err.Error(), status)
 		return
 	}
-
 	w.WriteHeader(http.StatusOK)
 }
 
+// markReady marks a player ready, tells everyone connected to the table and
+// starts the next game once both players are ready. On failure it returns
+// the HTTP status to answer with.
+func (h *Handler) markReady(table *models.Session, name string) (int, error) {
+	var readyErr error
+	updated, err := h.updateSession(table.ID, func(latest *models.Session) error {
+		readyErr = latest.SetReady(name)
+		return readyErr
+	})
+	if errors.Is(err, models.ErrGameInProgress) || errors.Is(err, models.ErrUpdateConflict) {
+		return http.StatusConflict, err
+	} else if errors.Is(err, redis.Nil) {
+		return http.StatusNotFound, errors.New("Table does not exist. Please make sure your table id is correct.")
+	} else if err != nil && err == readyErr {
+		return http.StatusBadRequest, err
+	} else if err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to save table to Redis")
+	}
+	*table = *updated
+	message := fmt.Sprintf("Player %s is ready", name)
+	if err := h.broadcast(models.EventPlayerReady, table, message); err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to broadcast table update")
+	}
+	if !table.AllReady() {
+		return http.StatusOK, nil
+	}
+	// Another player readied at the same time may have started the game already
+	status, err := h.startGame(table, (*models.Session).AllReady)
+	if errors.Is(err, models.ErrGameInProgress) || errors.Is(err, errCannotStart) {
+		return http.StatusOK, nil
+	}
+	return status, err
+}
+
 // DropPieceHandler handles requests to drop a piece in the Connect 4 game
 func (h *Handler) DropPieceHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -161,116 +476,143 @@ func (h *Handler) DropPieceHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to retrieve table from Redis", http.StatusInternalServerError)
 		return
 	}
-	// Find the player
-	var currentPlayer *models.Player
-	for _, player := range table.Players {
-		if player.Name == playerName {
-			currentPlayer = player
-			break
+	if index := table.PlayerIndex(playerName); index != -1 {
+		if table.Players[index].Bot {
+			http.Error(w, "Bots must move through the bot API", http.StatusForbidden)
+			return
+		}
+		if table.Players[index].AI > 0 {
+			http.Error(w, "The built-in AI makes its own moves", http.StatusForbidden)
+			return
 		}
 	}
-	if currentPlayer == nil {
-		http.Error(w, "Player not found in the table", http.StatusBadRequest)
+	if status, err := h.playMove(table, playerName, column); err != nil {
+		http.Error(w, err.Error(), status)
 		return
 	}
+	w.WriteHeader(http.StatusOK)
+}
 
-	turnname := table.GetPlayersTurn()
+// errPositionChanged refuses a move made on a position that has changed
+// since the player saw it
+var errPositionChanged = errors.New("The board changed before your move was played. Please try again")
 
-	if turnname != playerName {
-		http.Error(w, fmt.Sprintf("It is %s's turn. Please wait until %s plays their move.", turnname, turnname), http.StatusBadRequest)
-		return
-	}
-	// Get symbol for the current player based on their position in the Players array
-	playerIndex := 0
-	for i, player := range table.Players {
-		if player == currentPlayer {
-			playerIndex = i
-			break
+// playMove drops a piece through Session.Play, saves the table, tells
+// everyone connected to it and hands the turn to the next player if it is a
+// bot or the built-in AI. The move is played on the position table shows;
+// if a move, forfeit or new game was saved since, the move is refused. On
+// failure it returns the HTTP status to answer with.
+func (h *Handler) playMove(table *models.Session, playerName string, column int) (int, error) {
+	starts, moves := table.Starts, len(table.Moves)
+	var playErr error
+	updated, err := h.updateSession(table.ID, func(latest *models.Session) error {
+		if latest.Starts != starts || len(latest.Moves) != moves {
+			return errPositionChanged
 		}
+		// Drop the piece, checking the player is seated and it is their turn
+		_, playErr = latest.Play(playerName, column)
+		return playErr
+	})
+	if errors.Is(err, models.ErrGamePaused) || errors.Is(err, errPositionChanged) || errors.Is(err, models.ErrUpdateConflict) {
+		return http.StatusConflict, err
+	} else if errors.Is(err, redis.Nil) {
+		return http.StatusNotFound, errors.New("Table does not exist. Please make sure your table id is correct.")
+	} else if err != nil && err == playErr {
+		return http.StatusBadRequest, err
+	} else if err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to save table to Redis")
 	}
-	playerSymbol := ""
-	if playerIndex == 0 {
-		playerSymbol = "X"
-	} else if playerIndex == 1 {
-		playerSymbol = "O"
-	} else {
-		http.Error(w, "Unexpected player index", http.StatusInternalServerError)
-		return
+	*table = *updated
+
+	// Broadcast to everyone connected to the table
+	message := fmt.Sprintf("Player %s dropped piece in column %d", playerName, column)
+	if err := h.broadcast(models.EventPieceDropped, table, message); err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to broadcast table update")
+	}
+	if !table.Status {
+		h.gameOver(table)
+		return http.StatusOK, nil
 	}
+	h.notifyTurn(table)
+	return http.StatusOK, nil
+}
 
-	err = table.DropPiece(column, playerSymbol)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+// gameOver follows up on a finished game: it reports tournament results,
+// announces a decided match and otherwise starts the match's next game
+func (h *Handler) gameOver(table *models.Session) {
+	if !h.recordResult(table) {
 		return
 	}
-
-	if table.Turn == 1 {
-		table.Turn = 0
-	} else {
-		table.Turn = 1
+	if _, err := h.startGame(table, matchGoesOn); err != nil {
+		log.Printf("Error starting the next game of the match at table %s: %v", table.ID, err)
 	}
-	// Produce message to Kafka topic
+}
 
-	message := fmt.Sprintf("Player %s dropped piece in column %d", currentPlayer.Name, column)
-	if table.CheckWin(playerSymbol) {
-		table.Players[playerIndex].AddWin()
-		table.Status = false
-	} else if table.IsBoardFull() {
-		table.Status = false
-	}
+// matchGoesOn reports whether the table is playing a match that is not yet decided
+func matchGoesOn(table *models.Session) bool {
+	return table.Match != nil && !table.Match.Decided
+}
 
-	models.SaveSession(h.Context, table, h.Client)
-	message = table.StatusBoard(message) + table.StringBoard()
-	err = models.ProduceMessage(h.KAFKAADDRESS, tableID, message, h.KAFKAUSERNAME, h.KAFKAPASSWORD)
-	if err != nil {
-		http.Error(w, "Failed to produce message to Kafka topic", http.StatusInternalServerError)
-		return
+// recordResult reports a finished game to the table's tournament and
+// announces the match it decided. It reports whether the match goes on.
+func (h *Handler) recordResult(table *models.Session) bool {
+	if table.Tournament != "" {
+		h.tournamentGameOver(table)
 	}
-	w.WriteHeader(http.StatusOK)
+	if table.Match == nil {
+		return false
+	}
+	if table.Match.Decided {
+		if err := h.broadcast(models.EventMatchDecided, table, table.MatchScore()); err != nil {
+			log.Printf("Error broadcasting the result of the match at table %s: %v", table.ID, err)
+		}
+		return false
+	}
+	return true
 }
 
+// Errors answered to players who cannot leave a table
+var (
+	errLeaveInProgress = errors.New("Game is currently in progress. Please wait until the game is over")
+	errLeaveNotSeated  = errors.New("Player not found in the table")
+)
+
 // LeaveTableHandler handles requests from players who want to leave the Connect 4 table
 func (h *Handler) LeaveTableHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	tableID := vars["tableID"]
 	playerName := vars["name"]
 
-	// Retrieve the table from Redis
-	table, err := models.GetSession(h.Context, tableID, h.Client)
-	if err != nil {
-		http.Error(w, "Failed to retrieve table from Redis", http.StatusInternalServerError)
+	// Remove the player from the latest version of the table, abandoning any match
+	table, err := h.updateSession(tableID, func(latest *models.Session) error {
+		if latest.Status {
+			return errLeaveInProgress
+		}
+		playerIndex := latest.PlayerIndex(playerName)
+		if playerIndex == -1 {
+			return errLeaveNotSeated
+		}
+		latest.RemovePlayer(playerIndex)
+		return nil
+	})
+	if errors.Is(err, errLeaveInProgress) || errors.Is(err, errLeaveNotSeated) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
-	}
-	if table.Status {
-		http.Error(w, "Game is currently in progress. Please wait until the game is over", http.StatusBadRequest)
+	} else if errors.Is(err, redis.Nil) {
+		http.Error(w, "Table does not exist. Please make sure your table id is correct.", http.StatusNotFound)
 		return
-	}
-	// Find the player in the table
-	playerIndex := -1
-	for i, player := range table.Players {
-		if player.Name == playerName {
-			playerIndex = i
-			break
-		}
-	}
-	if playerIndex == -1 {
-		http.Error(w, "Player not found in the table", http.StatusBadRequest)
+	} else if errors.Is(err, models.ErrUpdateConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
 		return
-	}
-	// Remove the player from the table
-	table.Players = append(table.Players[:playerIndex], table.Players[playerIndex+1:]...)
-	// Save the updated table to Redis
-	err = models.SaveSession(h.Context, table, h.Client)
-	if err != nil {
+	} else if err != nil {
 		http.Error(w, "Failed to save table to Redis", http.StatusInternalServerError)
 		return
 	}
-	// Produce a message to the Kafka topic indicating the player has left the table
+	// Broadcast that the player has left the table
 	message := fmt.Sprintf("Player %s left the table ", playerName)
-	message = table.StatusBoard(message) + table.StringBoard()
-	err = models.ProduceMessage(h.KAFKAADDRESS, tableID, message, h.KAFKAUSERNAME, h.KAFKAPASSWORD)
+	err = h.broadcast(models.EventPlayerLeft, table, message)
 	if err != nil {
-		http.Error(w, "Failed to produce message to Kafka topic", http.StatusInternalServerError)
+		http.Error(w, "Failed to broadcast table update", http.StatusInternalServerError)
 		return
 	}
 
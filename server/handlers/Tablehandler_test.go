@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"net/http"
+	"testing"
+)
+
+// TestLeaveTeamTable tests that the players left at a team table keep
+// their team and pieces when a player leaves
+func TestLeaveTeamTable(t *testing.T) {
+	h := newTestHandler(t)
+	table := models.NewSession("table")
+	table.SetSeats(0, true)
+	seatTestTable(t, h, table, false, "alice", "bob", "carol", "dave")
+
+	w := serve(h.LeaveTableHandler, "GET", "/table/alice/leave", nil, map[string]string{"tableID": table.ID, "name": "alice"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected alice to leave, got %d: %s", w.Code, w.Body.String())
+	}
+	table, err := models.GetSession(h.Context, table.ID, h.Client)
+	if err != nil {
+		t.Fatalf("Error loading table: %v", err)
+	}
+	for name, want := range map[string]string{"bob": "O", "carol": "X", "dave": "O"} {
+		if symbol := table.Symbol(table.PlayerIndex(name)); symbol != want {
+			t.Errorf("Expected %s to keep playing %s, got %s", name, want, symbol)
+		}
+	}
+	if sides := table.Sides(); sides[0] != "carol" || sides[1] != "bob & dave" {
+		t.Errorf("Expected carol against bob & dave, got %v", sides)
+	}
+}
+
+// TestLeaveKeepsChanges tests that a player leaves the table as it is when
+// saved, keeping changes made after the request loaded it
+func TestLeaveKeepsChanges(t *testing.T) {
+	h := newTestHandler(t)
+	table := newTestTable(t, h, false, "alice", "bob")
+	interleaveSave(t, h, table.ID, func(latest *models.Session) {
+		latest.Locked = true
+	})
+
+	w := serve(h.LeaveTableHandler, "GET", "/table/alice/leave", nil, map[string]string{"tableID": table.ID, "name": "alice"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected alice to leave, got %d: %s", w.Code, w.Body.String())
+	}
+	stored, err := models.GetSession(h.Context, table.ID, h.Client)
+	if err != nil {
+		t.Fatalf("Error loading table: %v", err)
+	}
+	if !stored.Locked || len(stored.Players) != 1 {
+		t.Errorf("Expected a locked table seating only bob, got %+v", stored)
+	}
+}
+
+// TestReadyKeepsChanges tests that a player readied at the same time as
+// their opponent is not lost, so the game starts
+func TestReadyKeepsChanges(t *testing.T) {
+	h := newTestHandler(t)
+	table := newTestTable(t, h, false, "alice", "bob")
+	interleaveSave(t, h, table.ID, func(latest *models.Session) {
+		latest.SetReady("bob")
+	})
+
+	w := serve(h.ReadyHandler, "GET", "/table/alice/ready", nil, map[string]string{"tableID": table.ID, "name": "alice"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected alice to be ready, got %d: %s", w.Code, w.Body.String())
+	}
+	stored, err := models.GetSession(h.Context, table.ID, h.Client)
+	if err != nil {
+		t.Fatalf("Error loading table: %v", err)
+	}
+	if !stored.Status {
+		t.Errorf("Expected the game to start once both players were ready")
+	}
+}
+
+// TestRematchOnLatestTable tests that a match's next game starts on the
+// table as it is, not on the finished game: not after a player left or
+// the match was decided meanwhile
+func TestRematchOnLatestTable(t *testing.T) {
+	changes := map[string]func(*models.Session){
+		"leave":  func(latest *models.Session) { latest.RemovePlayer(latest.PlayerIndex("bob")) },
+		"decide": func(latest *models.Session) { latest.Match.Decided = true },
+	}
+	for name, change := range changes {
+		h := newTestHandler(t)
+		table := models.NewSession("table")
+		match, _ := models.NewMatch(models.MatchBestOf, 3)
+		table.SetMatch(match)
+		seatTestTable(t, h, table, true, "alice", "bob")
+		table.FinishGame(0)
+		if err := models.SaveSession(h.Context, table, h.Client); err != nil {
+			t.Fatalf("Error saving table: %v", err)
+		}
+		interleaveSave(t, h, table.ID, change)
+
+		h.gameOver(table)
+		stored, err := models.GetSession(h.Context, table.ID, h.Client)
+		if err != nil {
+			t.Fatalf("Error loading table: %v", err)
+		}
+		if stored.Status || stored.Starts != 1 {
+			t.Errorf("Expected no game to start after %s, got %d starts", name, stored.Starts)
+		}
+		if name == "leave" && len(stored.Players) != 1 {
+			t.Errorf("Expected bob to stay gone, got %d players", len(stored.Players))
+		}
+	}
+}
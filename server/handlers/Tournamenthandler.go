@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"blackjackapi/models"
+	"blackjackapi/render"
+	"blackjackapi/tournament"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+// tournamentMu serializes the updates of tournaments, which arrive from
+// requests and from games finishing on their tables
+var tournamentMu sync.Mutex
+
+// createTournamentRequest is the body of a new tournament
+type createTournamentRequest struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Rounds int    `json:"rounds"`
+}
+
+// CreateTournamentHandler opens registration for a tournament
+func (h *Handler) CreateTournamentHandler(w http.ResponseWriter, r *http.Request) {
+	var request createTournamentRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&request); err != nil {
+		http.Error(w, "Invalid tournament request", http.StatusBadRequest)
+		return
+	}
+	t, err := tournament.New(uuid.New().String(), request.Name, request.Format, request.Rounds)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := tournament.Save(h.Context, t, h.Client); err != nil {
+		http.Error(w, "Failed to save tournament to Redis", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(t)
+}
+
+// GetTournamentHandler sends a tournament with all of its matches
+func (h *Handler) GetTournamentHandler(w http.ResponseWriter, r *http.Request) {
+	t, ok := h.loadTournament(w, mux.Vars(r)["tournamentID"])
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// TournamentStandingsHandler sends the ranking of a tournament's players
+func (h *Handler) TournamentStandingsHandler(w http.ResponseWriter, r *http.Request) {
+	t, ok := h.loadTournament(w, mux.Vars(r)["tournamentID"])
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t.Standings())
+}
+
+// RegisterTournamentHandler enters a player in a tournament
+func (h *Handler) RegisterTournamentHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tournamentMu.Lock()
+	defer tournamentMu.Unlock()
+	t, ok := h.loadTournament(w, vars["tournamentID"])
+	if !ok {
+		return
+	}
+	err := t.Register(vars["name"])
+	if errors.Is(err, tournament.ErrNotRegistering) || errors.Is(err, tournament.ErrNameTaken) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := tournament.Save(h.Context, t, h.Client); err != nil {
+		http.Error(w, "Failed to save tournament to Redis", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// StartTournamentHandler closes registration and pairs the first round,
+// creating a table for every match
+func (h *Handler) StartTournamentHandler(w http.ResponseWriter, r *http.Request) {
+	tournamentMu.Lock()
+	defer tournamentMu.Unlock()
+	t, ok := h.loadTournament(w, mux.Vars(r)["tournamentID"])
+	if !ok {
+		return
+	}
+	matches, err := t.Start()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err := h.openRound(t, matches); err != nil {
+		http.Error(w, "Failed to create the tables of the round", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// TournamentStreamHandler streams the pairings and results of a tournament,
+// in the same formats as a table stream
+func (h *Handler) TournamentStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown(w) {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "text" && format != "json" {
+		http.Error(w, "Unknown stream format. Use text or json.", http.StatusBadRequest)
+		return
+	}
+	t, ok := h.loadTournament(w, mux.Vars(r)["tournamentID"])
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	events, err := h.Broker.Subscribe(ctx, tournament.Stream(t.ID))
+	if err != nil {
+		http.Error(w, "Failed to subscribe to tournament events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	connected := models.Event{Type: models.EventConnected, Message: fmt.Sprintf("Connected to tournament %s", t.Name)}
+	if err := writeEvent(w, format, render.ASCII, connected); err != nil {
+		log.Printf("Error writing SSE event to response: %v", err)
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.stopStreams():
+			writeEvent(w, format, render.ASCII, h.shutdownEvent())
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, format, render.ASCII, event); err != nil {
+				log.Printf("Error writing SSE event to response: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// loadTournament retrieves a tournament, answering 404 or 500 itself when it cannot
+func (h *Handler) loadTournament(w http.ResponseWriter, id string) (*tournament.Tournament, bool) {
+	t, err := tournament.Get(h.Context, id, h.Client)
+	if errors.Is(err, redis.Nil) {
+		http.Error(w, "Tournament does not exist. Please make sure your tournament id is correct.", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve tournament from Redis", http.StatusInternalServerError)
+		return nil, false
+	}
+	return t, true
+}
+
+// openRound creates a table for every match of a new round, saves the
+// tournament and announces the pairings
+func (h *Handler) openRound(t *tournament.Tournament, matches []*tournament.Match) error {
+	var pairings []string
+	for _, m := range matches {
+		if m.Result == tournament.ResultBye {
+			pairings = append(pairings, fmt.Sprintf("%s has a bye", m.Players[0]))
+			continue
+		}
+		table := models.NewSession(uuid.New().String())
+		table.Tournament = t.ID
+		for _, name := range m.Players {
+			table.AddPlayer(models.NewPlayer(name))
+		}
+		if err := models.SaveSession(h.Context, table, h.Client); err != nil {
+			return err
+		}
+		h.announceTable(models.EventTableCreated, table)
+		m.TableID = table.ID
+		pairings = append(pairings, fmt.Sprintf("%s vs %s at table %s", m.Players[0], m.Players[1], table.ID))
+	}
+	if err := tournament.Save(h.Context, t, h.Client); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Round %d: %s", t.Round, strings.Join(pairings, "; "))
+	h.announce(t, models.EventRoundPaired, message)
+	return nil
+}
+
+// tournamentGameOver records the game that just ended on a tournament table
+// as the result of its match and pairs the next round once every match of
+// the round has a result
+func (h *Handler) tournamentGameOver(table *models.Session) {
+	tournamentMu.Lock()
+	defer tournamentMu.Unlock()
+	t, err := tournament.Get(h.Context, table.Tournament, h.Client)
+	if err != nil {
+		log.Printf("Error retrieving tournament %s: %v", table.Tournament, err)
+		return
+	}
+	if t.MatchAt(table.ID) == nil || len(table.Players) != 2 {
+		return
+	}
+
+	result := tournament.ResultDraw
+	if table.Players[0].Wins > table.Players[1].Wins {
+		result = tournament.ResultFirst
+	} else if table.Players[1].Wins > table.Players[0].Wins {
+		result = tournament.ResultSecond
+	}
+	m, next, err := t.Report(table.ID, result)
+	if err != nil {
+		log.Printf("Error reporting the result at table %s: %v", table.ID, err)
+		return
+	}
+	if m.Result == tournament.ResultPending {
+		h.announce(t, models.EventMatchResult, fmt.Sprintf("%s vs %s was drawn and will be replayed", m.Players[0], m.Players[1]))
+		return
+	}
+	if err := tournament.Save(h.Context, t, h.Client); err != nil {
+		log.Printf("Error saving tournament %s: %v", t.ID, err)
+		return
+	}
+	message := fmt.Sprintf("%s vs %s finished %s", m.Players[0], m.Players[1], m.Result)
+	h.announce(t, models.EventMatchResult, message)
+
+	if t.Status == tournament.StatusFinished {
+		h.announce(t, models.EventTournamentFinished, fmt.Sprintf("%s won %s", t.Champion, t.Name))
+		return
+	}
+	if next != nil {
+		if err := h.openRound(t, next); err != nil {
+			log.Printf("Error opening round %d of tournament %s: %v", t.Round, t.ID, err)
+		}
+	}
+}
+
+// announce sends an event on a tournament's stream
+func (h *Handler) announce(t *tournament.Tournament, eventType, message string) {
+	event := models.Event{Type: eventType, Message: message}
+	if err := h.Broker.PublishTo(h.Context, tournament.Stream(t.ID), event); err != nil {
+		log.Printf("Error announcing to tournament %s: %v", t.ID, err)
+	}
+}
@@ -2,29 +2,104 @@ package server
 
 import (
 	"blackjackapi/server/handlers"
+	_ "embed"
 	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
-// maybe tableid first makes more sense
+// Routes, all documented in openapi.json:
+//
+// GET /openapi.json
+// GET /create
+// GET /join/{code}
+// GET /tables
+// GET /tables/connect
+// POST /tables/import
+// GET /analysis
+// GET /openings
+// POST /bots
+// GET /bots/events
+// GET /bots/ws
+// POST /bots/tables/{tableID}/join
+// POST /bots/tables/{tableID}/ready
+// POST /bots/tables/{tableID}/{column}/move
+// POST /arenas
+// GET /arenas/{arenaID}
+// POST /tournaments
+// GET /tournaments/{tournamentID}
+// GET /tournaments/{tournamentID}/standings
+// GET /tournaments/{tournamentID}/connect
+// POST /tournaments/{tournamentID}/start
+// POST /tournaments/{tournamentID}/{name}/register
+// GET /{tableID}
+// GET /{tableID}/delete
+// ANY /{tableID}/start
+// GET /{tableID}/{name}/join
+// GET /{tableID}/{name}/leave
+// GET /{tableID}/{name}/ready
+// GET /{tableID}/{name}/resign
+// GET /{tableID}/{name}/draw
+// GET /{tableID}/{name}/draw/accept
+// GET /{tableID}/{name}/draw/decline
+// GET /{tableID}/{name}/abandon
+// POST /{tableID}/{name}/kick
+// POST /{tableID}/{name}/host
+// POST /{tableID}/lock
+// POST /{tableID}/unlock
+// POST /{tableID}/settings
+// GET /{tableID}/{name}/{column}/drop
+// GET /{tableID}/connect
+// GET /{tableID}/board.svg
+// GET /{tableID}/board.png
+// GET /{tableID}/history.gif
+// GET /{tableID}/export
+// GET /{tableID}/analysis
+// GET /{tableID}/review
+// POST /{tableID}/match
 
-// CREATE   /create
-// DELETE /{tableid}/delete/
-// START /{tableid}
-// JOIN  /{tableid}/join/{id}
-// DROP  /{tableid}/{id}/{column}/drop
-// CONNECT /{tableid}/connect
-
-// Handler holds the HTTP handlers for the API
+//go:embed openapi.json
+var openAPISpec []byte
 
 // NewRouter initializes and returns the HTTP router
 func NewRouter(handler *handlers.Handler) http.Handler {
 	router := mux.NewRouter()
 	// STATIC
 	router.Handle("/", http.FileServer(http.Dir("./static")))
+	// SPEC
+	router.HandleFunc("/openapi.json", OpenAPIHandler).Methods("GET")
 	//CREATE
 	router.HandleFunc("/create", handler.CreateTableHandler).Methods("GET")
+	// INVITES
+	router.HandleFunc("/join/{code}", handler.InviteHandler).Methods("GET")
+	// LOBBY
+	router.HandleFunc("/tables", handler.ListTablesHandler).Methods("GET")
+	router.HandleFunc("/tables/connect", handler.LobbyStreamHandler).Methods("GET")
+	// IMPORT
+	router.HandleFunc("/tables/import", handler.ImportTableHandler).Methods("POST")
+	// ANALYSIS
+	router.HandleFunc("/analysis", handler.PositionAnalysisHandler).Methods("GET")
+	// OPENINGS
+	router.HandleFunc("/openings", handler.OpeningsHandler).Methods("GET")
+	// BOTS
+	router.HandleFunc("/bots", handler.RegisterBotHandler).Methods("POST")
+	router.HandleFunc("/bots/events", handler.BotEventsHandler).Methods("GET")
+	router.HandleFunc("/bots/ws", handler.BotSocketHandler).Methods("GET")
+	router.HandleFunc("/bots/tables/{tableID}/join", handler.BotJoinHandler).Methods("POST")
+	router.HandleFunc("/bots/tables/{tableID}/ready", handler.BotReadyHandler).Methods("POST")
+	router.HandleFunc("/bots/tables/{tableID}/{column}/move", handler.BotMoveHandler).Methods("POST")
+	// ARENAS
+	router.HandleFunc("/arenas", handler.CreateArenaHandler).Methods("POST")
+	router.HandleFunc("/arenas/{arenaID}", handler.GetArenaHandler).Methods("GET")
+	// TOURNAMENTS
+	router.HandleFunc("/tournaments", handler.CreateTournamentHandler).Methods("POST")
+	router.HandleFunc("/tournaments/{tournamentID}", handler.GetTournamentHandler).Methods("GET")
+	router.HandleFunc("/tournaments/{tournamentID}/standings", handler.TournamentStandingsHandler).Methods("GET")
+	router.HandleFunc("/tournaments/{tournamentID}/connect", handler.TournamentStreamHandler).Methods("GET")
+	router.HandleFunc("/tournaments/{tournamentID}/start", handler.StartTournamentHandler).Methods("POST")
+	router.HandleFunc("/tournaments/{tournamentID}/{name}/register", handler.RegisterTournamentHandler).Methods("POST")
+	// TABLE
+	router.HandleFunc("/{tableID}", handler.GetTableHandler).Methods("GET")
 	//DELETE
 	router.HandleFunc("/{tableID}/delete", handler.DeleteTableHandler).Methods("GET")
 	//START
@@ -33,10 +108,42 @@ func NewRouter(handler *handlers.Handler) http.Handler {
 	router.HandleFunc("/{tableID}/{name}/join", handler.JoinTableHandler).Methods("GET")
 	// LEAVE
 	router.HandleFunc("/{tableID}/{name}/leave", handler.LeaveTableHandler).Methods("GET")
+	// READY
+	router.HandleFunc("/{tableID}/{name}/ready", handler.ReadyHandler).Methods("GET")
+	// END EARLY
+	router.HandleFunc("/{tableID}/{name}/resign", handler.ResignHandler).Methods("GET")
+	router.HandleFunc("/{tableID}/{name}/draw", handler.OfferDrawHandler).Methods("GET")
+	router.HandleFunc("/{tableID}/{name}/draw/accept", handler.AcceptDrawHandler).Methods("GET")
+	router.HandleFunc("/{tableID}/{name}/draw/decline", handler.DeclineDrawHandler).Methods("GET")
+	router.HandleFunc("/{tableID}/{name}/abandon", handler.ClaimAbandonmentHandler).Methods("GET")
+	// HOST
+	router.HandleFunc("/{tableID}/{name}/kick", handler.KickHandler).Methods("POST")
+	router.HandleFunc("/{tableID}/{name}/host", handler.TransferHostHandler).Methods("POST")
+	router.HandleFunc("/{tableID}/lock", handler.LockHandler).Methods("POST")
+	router.HandleFunc("/{tableID}/unlock", handler.UnlockHandler).Methods("POST")
+	router.HandleFunc("/{tableID}/settings", handler.SettingsHandler).Methods("POST")
 	// DROP
 	router.HandleFunc("/{tableID}/{name}/{column}/drop", handler.DropPieceHandler).Methods("GET")
 	// CONNECT
 	router.HandleFunc("/{tableID}/connect", handler.KafkaSSEHandler).Methods("GET")
+	// IMAGES
+	router.HandleFunc("/{tableID}/board.svg", handler.BoardSVGHandler).Methods("GET")
+	router.HandleFunc("/{tableID}/board.png", handler.BoardPNGHandler).Methods("GET")
+	router.HandleFunc("/{tableID}/history.gif", handler.BoardGIFHandler).Methods("GET")
+	// EXPORT
+	router.HandleFunc("/{tableID}/export", handler.ExportTableHandler).Methods("GET")
+	// ANALYSIS
+	router.HandleFunc("/{tableID}/analysis", handler.TableAnalysisHandler).Methods("GET")
+	// REVIEW
+	router.HandleFunc("/{tableID}/review", handler.TableReviewHandler).Methods("GET")
+	// MATCH
+	router.HandleFunc("/{tableID}/match", handler.SetMatchHandler).Methods("POST")
 
 	return router
 }
+
+// OpenAPIHandler serves the OpenAPI 3 document describing the API
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
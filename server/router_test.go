@@ -0,0 +1,46 @@
+package server
+
+import (
+	"blackjackapi/server/handlers"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// TestOpenAPICoversRoutes tests that every route of the router is documented
+func TestOpenAPICoversRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("Error parsing openapi.json: %v", err)
+	}
+
+	router := NewRouter(&handlers.Handler{}).(*mux.Router)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || path == "/" {
+			return nil
+		}
+		operations, ok := spec.Paths[path]
+		if !ok {
+			t.Errorf("Route %s is missing from openapi.json", path)
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"GET"}
+		}
+		for _, method := range methods {
+			if _, ok := operations[strings.ToLower(method)]; !ok {
+				t.Errorf("Route %s %s is missing from openapi.json", method, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error walking routes: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package tournament
+
+import "sort"
+
+// pairSwiss pairs players on equal or nearby scores who have not met yet.
+// With an odd number of players the lowest ranked player without a bye sits
+// out for a point. Of each pair, the player who has started fewer games
+// takes the second seat, which moves first in a table's first game.
+func (t *Tournament) pairSwiss() []Match {
+	standings := t.Standings()
+	ranked := make([]string, len(standings))
+	for i, s := range standings {
+		ranked[i] = s.Player
+	}
+	met := map[[2]string]bool{}
+	byes := map[string]bool{}
+	starts := map[string]int{}
+	for _, m := range t.Matches {
+		if m.Result == ResultBye {
+			byes[m.Players[0]] = true
+			continue
+		}
+		met[[2]string{m.Players[0], m.Players[1]}] = true
+		met[[2]string{m.Players[1], m.Players[0]}] = true
+		starts[m.Players[1]]++
+	}
+
+	var bye *Match
+	if len(ranked)%2 == 1 {
+		sitting := len(ranked) - 1
+		for i := len(ranked) - 1; i >= 0; i-- {
+			if !byes[ranked[i]] {
+				sitting = i
+				break
+			}
+		}
+		bye = &Match{Players: [2]string{ranked[sitting]}, Result: ResultBye}
+		ranked = append(ranked[:sitting:sitting], ranked[sitting+1:]...)
+	}
+
+	pairs := pairOff(ranked, met)
+	if pairs == nil {
+		// Every pairing repeats a match, so allow rematches
+		pairs = pairOff(ranked, nil)
+	}
+	matches := []Match{}
+	for _, pair := range pairs {
+		if starts[pair[0]] < starts[pair[1]] {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		matches = append(matches, Match{Players: pair})
+	}
+	if bye != nil {
+		matches = append(matches, *bye)
+	}
+	return matches
+}
+
+// pairOff pairs each player, from the top of the ranking down, with the
+// highest ranked opponent they have not met, backtracking when the players
+// left cannot all be paired. It returns nil when no pairing exists.
+func pairOff(players []string, met map[[2]string]bool) [][2]string {
+	if len(players) == 0 {
+		return [][2]string{}
+	}
+	first := players[0]
+	for i := 1; i < len(players); i++ {
+		if met[[2]string{first, players[i]}] {
+			continue
+		}
+		rest := make([]string, 0, len(players)-2)
+		rest = append(rest, players[1:i]...)
+		rest = append(rest, players[i+1:]...)
+		if pairs := pairOff(rest, met); pairs != nil {
+			return append([][2]string{{first, players[i]}}, pairs...)
+		}
+	}
+	return nil
+}
+
+// pairElimination pairs the next round of a bracket. The first round seeds
+// the players so the best seeds meet as late as possible, with byes for the
+// top seeds when the field is not a power of two; later rounds pair the
+// winners of neighbouring matches. In double elimination players who have
+// lost once play on in the losers bracket in the order they dropped into it,
+// and the last player of each bracket meet in a final that is played again
+// if the winners bracket player loses it.
+func (t *Tournament) pairElimination() []Match {
+	slots := seeding(t.Players)
+	if t.Round == 0 {
+		matches := []Match{}
+		for i := 0; i < len(slots); i += 2 {
+			m := Match{Players: [2]string{slots[i], slots[i+1]}}
+			if m.Players[1] == "" {
+				m.Result = ResultBye
+			}
+			if t.Format == FormatDouble {
+				m.Bracket = BracketWinners
+			}
+			matches = append(matches, m)
+		}
+		return matches
+	}
+
+	position := map[string]int{}
+	for i, player := range slots {
+		if player != "" {
+			position[player] = i
+		}
+	}
+	losses := map[string]int{}
+	dropped := map[string]int{}
+	for _, m := range t.Matches {
+		if loser := m.Loser(); loser != "" {
+			losses[loser]++
+			if dropped[loser] == 0 {
+				dropped[loser] = m.Round
+			}
+		}
+	}
+	var winners, losers []string
+	for _, player := range t.Players {
+		switch {
+		case losses[player] == 0:
+			winners = append(winners, player)
+		case losses[player] == 1 && t.Format == FormatDouble:
+			losers = append(losers, player)
+		}
+	}
+	sort.SliceStable(winners, func(i, j int) bool { return position[winners[i]] < position[winners[j]] })
+	sort.SliceStable(losers, func(i, j int) bool {
+		if dropped[losers[i]] != dropped[losers[j]] {
+			return dropped[losers[i]] < dropped[losers[j]]
+		}
+		return position[losers[i]] < position[losers[j]]
+	})
+
+	if len(winners)+len(losers) < 2 {
+		return nil
+	}
+	if t.Format == FormatSingle {
+		return pairBracket(winners, "")
+	}
+	if len(winners)+len(losers) == 2 {
+		finalists := append(winners, losers...)
+		return []Match{{Bracket: BracketFinal, Players: [2]string{finalists[0], finalists[1]}}}
+	}
+	return append(pairBracket(winners, BracketWinners), pairBracket(losers, BracketLosers)...)
+}
+
+// pairBracket pairs neighbours, giving the last player a bye if one is left
+func pairBracket(players []string, bracket string) []Match {
+	matches := []Match{}
+	for i := 0; i < len(players); i += 2 {
+		m := Match{Bracket: bracket, Players: [2]string{players[i]}}
+		if i+1 < len(players) {
+			m.Players[1] = players[i+1]
+		} else {
+			m.Result = ResultBye
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// seeding places the players in the slots of a bracket whose size is the
+// next power of two, so that seed 1 meets seed 2 only in the final. Empty
+// slots are byes and always fall against the top seeds.
+func seeding(players []string) []string {
+	order := []int{0}
+	for len(order) < len(players) {
+		next := make([]int, 0, 2*len(order))
+		for _, seed := range order {
+			next = append(next, seed, 2*len(order)-1-seed)
+		}
+		order = next
+	}
+	slots := make([]string, len(order))
+	for i, seed := range order {
+		if seed < len(players) {
+			slots[i] = players[seed]
+		}
+	}
+	return slots
+}
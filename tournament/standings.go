@@ -0,0 +1,117 @@
+package tournament
+
+import "sort"
+
+// Standing is a player's results in a tournament. Swiss standings are
+// ordered by points and then the Buchholz and Sonneborn-Berger tie-breaks;
+// elimination standings by how far the player got.
+type Standing struct {
+	Rank   int     `json:"rank"`
+	Player string  `json:"player"`
+	Points float64 `json:"points"`
+	Wins   int     `json:"wins"`
+	Draws  int     `json:"draws"`
+	Losses int     `json:"losses"`
+	Byes   int     `json:"byes"`
+	// Buchholz sums the points of every opponent
+	Buchholz float64 `json:"buchholz"`
+	// SonnebornBerger sums the points of beaten opponents and half the
+	// points of drawn ones
+	SonnebornBerger float64 `json:"sonneborn_berger"`
+	// Eliminated is the round an elimination player was knocked out in
+	Eliminated int `json:"eliminated,omitempty"`
+}
+
+// Standings ranks the players on the results so far
+func (t *Tournament) Standings() []Standing {
+	index := map[string]int{}
+	standings := make([]Standing, len(t.Players))
+	for i, player := range t.Players {
+		index[player] = i
+		standings[i].Player = player
+	}
+	for _, m := range t.Matches {
+		first := &standings[index[m.Players[0]]]
+		switch m.Result {
+		case ResultBye:
+			first.Byes++
+			first.Points++
+			continue
+		case ResultPending:
+			continue
+		}
+		second := &standings[index[m.Players[1]]]
+		switch m.Result {
+		case ResultFirst:
+			first.Wins++
+			first.Points++
+			second.Losses++
+		case ResultSecond:
+			second.Wins++
+			second.Points++
+			first.Losses++
+		case ResultDraw:
+			first.Draws++
+			second.Draws++
+			first.Points += 0.5
+			second.Points += 0.5
+		}
+	}
+
+	maxLosses := 1
+	if t.Format == FormatDouble {
+		maxLosses = 2
+	}
+	losses := map[string]int{}
+	for _, m := range t.Matches {
+		if m.Result == ResultBye || m.Result == ResultPending {
+			continue
+		}
+		first, second := &standings[index[m.Players[0]]], &standings[index[m.Players[1]]]
+		first.Buchholz += second.Points
+		second.Buchholz += first.Points
+		switch m.Result {
+		case ResultFirst:
+			first.SonnebornBerger += second.Points
+		case ResultSecond:
+			second.SonnebornBerger += first.Points
+		case ResultDraw:
+			first.SonnebornBerger += second.Points / 2
+			second.SonnebornBerger += first.Points / 2
+		}
+		if loser := m.Loser(); loser != "" {
+			losses[loser]++
+			if losses[loser] == maxLosses {
+				standings[index[loser]].Eliminated = m.Round
+			}
+		}
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if t.Format != FormatSwiss {
+			// Players still in the tournament, then the latest knocked out
+			if (a.Eliminated == 0) != (b.Eliminated == 0) {
+				return a.Eliminated == 0
+			}
+			if a.Eliminated != b.Eliminated {
+				return a.Eliminated > b.Eliminated
+			}
+			return a.Wins > b.Wins
+		}
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.Buchholz != b.Buchholz {
+			return a.Buchholz > b.Buchholz
+		}
+		if a.SonnebornBerger != b.SonnebornBerger {
+			return a.SonnebornBerger > b.SonnebornBerger
+		}
+		return a.Wins > b.Wins
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	return standings
+}
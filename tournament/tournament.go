@@ -0,0 +1,280 @@
+// Package tournament runs human Connect 4 tournaments: registration, Swiss
+// rounds or single and double elimination brackets, and standings.
+//
+// Each match is played on its own table. In Swiss rounds the first game that
+// finishes on the table decides the match; in elimination brackets drawn
+// games are replayed until one player wins.
+package tournament
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Formats of a tournament
+const (
+	FormatSwiss  = "swiss"
+	FormatSingle = "single"
+	FormatDouble = "double"
+)
+
+// Tournament states
+const (
+	StatusRegistration = "registration"
+	StatusRunning      = "running"
+	StatusFinished     = "finished"
+)
+
+// Match results, read as in game notation from the first seat's point of view
+const (
+	ResultPending = ""
+	ResultFirst   = "1-0"
+	ResultSecond  = "0-1"
+	ResultDraw    = "1/2-1/2"
+	ResultBye     = "bye"
+)
+
+// Brackets of a double elimination tournament
+const (
+	BracketWinners = "winners"
+	BracketLosers  = "losers"
+	BracketFinal   = "final"
+)
+
+// Limits of a tournament
+const (
+	MinPlayers     = 2
+	MaxPlayers     = 128
+	MaxSwissRounds = 20
+)
+
+// Errors returned by Register and Start
+var (
+	ErrNotRegistering = errors.New("Registration is closed")
+	ErrNameTaken      = errors.New("That name is already registered")
+)
+
+// Match pairs two players on a table. A bye has no second player.
+type Match struct {
+	Round   int       `json:"round"`
+	Bracket string    `json:"bracket,omitempty"`
+	Players [2]string `json:"players"`
+	TableID string    `json:"table_id,omitempty"`
+	Result  string    `json:"result"`
+}
+
+// Winner returns the player who won the match, or "" for a draw or no result
+func (m Match) Winner() string {
+	switch m.Result {
+	case ResultFirst, ResultBye:
+		return m.Players[0]
+	case ResultSecond:
+		return m.Players[1]
+	}
+	return ""
+}
+
+// Loser returns the player who lost the match, or "" if nobody did
+func (m Match) Loser() string {
+	switch m.Result {
+	case ResultFirst:
+		return m.Players[1]
+	case ResultSecond:
+		return m.Players[0]
+	}
+	return ""
+}
+
+// Tournament is a tournament and every match played in it
+type Tournament struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Status string `json:"status"`
+	// Rounds is the number of Swiss rounds, or the rounds played so far in
+	// an elimination tournament
+	Rounds int `json:"rounds"`
+	// Round is the round being played, 0 before the start
+	Round int `json:"round"`
+	// Players are in seeding order, which is the order they registered in
+	Players  []string  `json:"players"`
+	Matches  []Match   `json:"matches"`
+	Champion string    `json:"champion,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// New opens registration for a tournament. Swiss tournaments with no rounds
+// given play enough rounds to separate the players when registration closes.
+func New(id, name, format string, rounds int) (*Tournament, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("A tournament needs a name")
+	}
+	switch format {
+	case FormatSwiss:
+		if rounds < 0 || rounds > MaxSwissRounds {
+			return nil, fmt.Errorf("Swiss tournaments play 1 to %d rounds", MaxSwissRounds)
+		}
+	case FormatSingle, FormatDouble:
+		if rounds != 0 {
+			return nil, errors.New("Elimination tournaments play as many rounds as they need")
+		}
+	default:
+		return nil, fmt.Errorf("Unknown format %q. Use swiss, single or double.", format)
+	}
+	return &Tournament{
+		ID:      id,
+		Name:    name,
+		Format:  format,
+		Status:  StatusRegistration,
+		Rounds:  rounds,
+		Players: []string{},
+		Matches: []Match{},
+		Created: time.Now().UTC(),
+	}, nil
+}
+
+// Register enters a player while registration is open
+func (t *Tournament) Register(name string) error {
+	if t.Status != StatusRegistration {
+		return ErrNotRegistering
+	}
+	if strings.TrimSpace(name) == "" {
+		return errors.New("Players need a name")
+	}
+	if len(t.Players) >= MaxPlayers {
+		return fmt.Errorf("The tournament is full at %d players", MaxPlayers)
+	}
+	for _, player := range t.Players {
+		if player == name {
+			return ErrNameTaken
+		}
+	}
+	t.Players = append(t.Players, name)
+	return nil
+}
+
+// Start closes registration and pairs the first round, returning its matches
+func (t *Tournament) Start() ([]*Match, error) {
+	if t.Status != StatusRegistration {
+		return nil, errors.New("The tournament has already started")
+	}
+	if len(t.Players) < MinPlayers {
+		return nil, fmt.Errorf("A tournament needs at least %d players", MinPlayers)
+	}
+	if t.Format == FormatSwiss && t.Rounds == 0 {
+		t.Rounds = bits.Len(uint(len(t.Players) - 1))
+	}
+	t.Status = StatusRunning
+	return t.nextRound(), nil
+}
+
+// MatchAt returns the pending match played on a table
+func (t *Tournament) MatchAt(tableID string) *Match {
+	for i := range t.Matches {
+		if m := &t.Matches[i]; m.TableID == tableID && m.Result == ResultPending {
+			return m
+		}
+	}
+	return nil
+}
+
+// Report records the result of the pending match on a table. A draw is only
+// recorded in Swiss tournaments; elimination matches stay pending for a
+// replay. When the report completes the round it pairs the next one, or
+// finishes the tournament, and returns the new matches.
+func (t *Tournament) Report(tableID, result string) (*Match, []*Match, error) {
+	m := t.MatchAt(tableID)
+	if m == nil {
+		return nil, nil, errors.New("No match is waiting for a result on that table")
+	}
+	switch result {
+	case ResultFirst, ResultSecond:
+	case ResultDraw:
+		if t.Format != FormatSwiss {
+			return m, nil, nil
+		}
+	default:
+		return nil, nil, fmt.Errorf("Unknown result %q", result)
+	}
+	m.Result = result
+	reported := *m
+	for _, other := range t.Matches {
+		if other.Round == t.Round && other.Result == ResultPending {
+			return &reported, nil, nil
+		}
+	}
+	return &reported, t.nextRound(), nil
+}
+
+// nextRound pairs the next round, or finishes the tournament when there is
+// none, and returns the new matches
+func (t *Tournament) nextRound() []*Match {
+	var pairs []Match
+	if t.Format == FormatSwiss {
+		if t.Round < t.Rounds {
+			pairs = t.pairSwiss()
+		}
+	} else {
+		pairs = t.pairElimination()
+	}
+	if len(pairs) == 0 {
+		t.Status = StatusFinished
+		if standings := t.Standings(); len(standings) > 0 {
+			t.Champion = standings[0].Player
+		}
+		return nil
+	}
+	t.Round++
+	if t.Format != FormatSwiss {
+		t.Rounds = t.Round
+	}
+	first := len(t.Matches)
+	for _, m := range pairs {
+		m.Round = t.Round
+		t.Matches = append(t.Matches, m)
+	}
+	matches := make([]*Match, 0, len(pairs))
+	for i := first; i < len(t.Matches); i++ {
+		matches = append(matches, &t.Matches[i])
+	}
+	return matches
+}
+
+// Stream is the broker stream that announces a tournament's pairings
+func Stream(id string) string {
+	return "tournament-" + id
+}
+
+func tournamentKey(id string) string {
+	return "tournament:" + id
+}
+
+// Get retrieves a tournament
+func Get(ctx context.Context, id string, client *redis.Client) (*Tournament, error) {
+	val, err := client.Get(ctx, tournamentKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var t Tournament
+	if err := json.Unmarshal([]byte(val), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Save stores a tournament
+func Save(ctx context.Context, t *Tournament, client *redis.Client) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return client.Set(ctx, tournamentKey(t.ID), data, 0).Err()
+}
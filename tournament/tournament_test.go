@@ -0,0 +1,232 @@
+package tournament
+
+import (
+	"fmt"
+	"testing"
+)
+
+// newStarted registers the players and starts the tournament
+func newStarted(t *testing.T, format string, rounds int, players ...string) (*Tournament, []*Match) {
+	t.Helper()
+	tour, err := New("id", "Office cup", format, rounds)
+	if err != nil {
+		t.Fatalf("Error creating tournament: %v", err)
+	}
+	for _, player := range players {
+		if err := tour.Register(player); err != nil {
+			t.Fatalf("Error registering %s: %v", player, err)
+		}
+	}
+	matches, err := tour.Start()
+	if err != nil {
+		t.Fatalf("Error starting tournament: %v", err)
+	}
+	// Give every match a table as the server does
+	for i, m := range matches {
+		m.TableID = fmt.Sprintf("r%d-%d", tour.Round, i)
+	}
+	return tour, matches
+}
+
+// play reports a result for every match, the first seat winning unless the
+// player is listed as a winner in the second seat, and returns the next round
+func play(t *testing.T, tour *Tournament, matches []*Match, winners ...string) []*Match {
+	t.Helper()
+	wins := map[string]bool{}
+	for _, w := range winners {
+		wins[w] = true
+	}
+	tables := []string{}
+	results := []string{}
+	for _, m := range matches {
+		if m.Result == ResultBye {
+			continue
+		}
+		result := ResultFirst
+		if wins[m.Players[1]] {
+			result = ResultSecond
+		}
+		tables = append(tables, m.TableID)
+		results = append(results, result)
+	}
+	var next []*Match
+	for i, table := range tables {
+		_, round, err := tour.Report(table, results[i])
+		if err != nil {
+			t.Fatalf("Error reporting %s: %v", table, err)
+		}
+		if round != nil {
+			next = round
+		}
+	}
+	for i, m := range next {
+		m.TableID = fmt.Sprintf("r%d-%d", tour.Round, i)
+	}
+	return next
+}
+
+// TestRegister tests registration rules
+func TestRegister(t *testing.T) {
+	tour, err := New("id", "Office cup", FormatSwiss, 0)
+	if err != nil {
+		t.Fatalf("Error creating tournament: %v", err)
+	}
+	if err := tour.Register("alice"); err != nil {
+		t.Fatalf("Error registering: %v", err)
+	}
+	if err := tour.Register("alice"); err != ErrNameTaken {
+		t.Errorf("Expected ErrNameTaken, got %v", err)
+	}
+	if _, err := tour.Start(); err == nil {
+		t.Errorf("Expected an error starting with one player")
+	}
+	tour.Register("bob")
+	if _, err := tour.Start(); err != nil {
+		t.Fatalf("Error starting: %v", err)
+	}
+	if err := tour.Register("carol"); err != ErrNotRegistering {
+		t.Errorf("Expected ErrNotRegistering, got %v", err)
+	}
+	if _, err := New("id", "Cup", "league", 0); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+// TestSwiss tests pairing by score without rematches, byes and tie-breaks
+func TestSwiss(t *testing.T) {
+	tour, round := newStarted(t, FormatSwiss, 0, "a", "b", "c", "d", "e")
+	if tour.Rounds != 3 {
+		t.Errorf("Expected 3 rounds for 5 players, got %d", tour.Rounds)
+	}
+	if last := round[len(round)-1]; last.Result != ResultBye || last.Players[0] != "e" {
+		t.Errorf("Expected the lowest seed to have the bye, got %+v", last)
+	}
+
+	met := map[[2]string]bool{}
+	byes := map[string]bool{}
+	for round != nil {
+		for _, m := range round {
+			if m.Result == ResultBye {
+				if byes[m.Players[0]] {
+					t.Errorf("Expected one bye for %s", m.Players[0])
+				}
+				byes[m.Players[0]] = true
+				continue
+			}
+			if met[m.Players] || met[[2]string{m.Players[1], m.Players[0]}] {
+				t.Errorf("Expected no rematch of %v", m.Players)
+			}
+			met[m.Players] = true
+		}
+		round = play(t, tour, round)
+	}
+	if tour.Status != StatusFinished || tour.Round != 3 {
+		t.Fatalf("Expected the tournament to finish after 3 rounds, got %s after %d", tour.Status, tour.Round)
+	}
+	standings := tour.Standings()
+	if tour.Champion != standings[0].Player {
+		t.Errorf("Expected the leader %s to be champion, got %s", standings[0].Player, tour.Champion)
+	}
+	for i := 1; i < len(standings); i++ {
+		if standings[i].Points > standings[i-1].Points {
+			t.Errorf("Expected standings ordered by points, got %+v", standings)
+		}
+	}
+}
+
+// TestSwissTieBreaks tests Buchholz and Sonneborn-Berger
+func TestSwissTieBreaks(t *testing.T) {
+	tour, round := newStarted(t, FormatSwiss, 1, "a", "b", "c", "d")
+	// a beats b and d beats c
+	play(t, tour, round, "d")
+	for _, s := range tour.Standings() {
+		if s.Player == "a" && (s.Buchholz != 0 || s.SonnebornBerger != 0) {
+			t.Errorf("Expected no tie-break points for beating a pointless opponent, got %+v", s)
+		}
+		if s.Player == "b" && s.Buchholz != 1 {
+			t.Errorf("Expected b's Buchholz to be a's point, got %+v", s)
+		}
+	}
+}
+
+// TestSingleElimination tests seeding with byes and the bracket to the final
+func TestSingleElimination(t *testing.T) {
+	tour, round := newStarted(t, FormatSingle, 0, "a", "b", "c", "d", "e")
+	byes := 0
+	for _, m := range round {
+		if m.Result == ResultBye {
+			byes++
+		}
+	}
+	if len(round) != 4 || byes != 3 {
+		t.Fatalf("Expected 4 first round matches with 3 byes, got %+v", round)
+	}
+	// The only match is seed 4 against seed 5, which e wins
+	round = play(t, tour, round, "e")
+	if len(round) != 2 || round[0].Players != [2]string{"a", "e"} || round[1].Players != [2]string{"b", "c"} {
+		t.Fatalf("Expected a-e and b-c in round 2, got %+v %+v", round[0], round[1])
+	}
+	round = play(t, tour, round, "c")
+	if len(round) != 1 || round[0].Players != [2]string{"a", "c"} {
+		t.Fatalf("Expected a final between a and c, got %+v", round)
+	}
+	if round = play(t, tour, round, "c"); round != nil {
+		t.Fatalf("Expected no round after the final, got %+v", round)
+	}
+	if tour.Status != StatusFinished || tour.Champion != "c" {
+		t.Errorf("Expected c to win the tournament, got %s %q", tour.Status, tour.Champion)
+	}
+	if standings := tour.Standings(); standings[1].Player != "a" || standings[1].Eliminated != 3 {
+		t.Errorf("Expected the losing finalist second, got %+v", standings[1])
+	}
+}
+
+// TestEliminationDraw tests that a drawn elimination match waits for a replay
+func TestEliminationDraw(t *testing.T) {
+	tour, round := newStarted(t, FormatSingle, 0, "a", "b")
+	m, next, err := tour.Report(round[0].TableID, ResultDraw)
+	if err != nil || next != nil || m.Result != ResultPending {
+		t.Fatalf("Expected the draw to leave the match pending, got %+v %v", m, err)
+	}
+	if _, _, err := tour.Report(round[0].TableID, ResultSecond); err != nil {
+		t.Fatalf("Error reporting the replay: %v", err)
+	}
+	if tour.Champion != "b" {
+		t.Errorf("Expected b to win the replay and the tournament, got %q", tour.Champion)
+	}
+}
+
+// TestDoubleElimination tests the losers bracket and a reset final
+func TestDoubleElimination(t *testing.T) {
+	tour, round := newStarted(t, FormatDouble, 0, "a", "b", "c", "d")
+	// Winners bracket a-d and b-c
+	round = play(t, tour, round)
+	if len(round) != 2 || round[0].Bracket != BracketWinners || round[1].Bracket != BracketLosers {
+		t.Fatalf("Expected a winners and a losers match, got %+v %+v", round[0], round[1])
+	}
+	if round[1].Players != [2]string{"d", "c"} {
+		t.Errorf("Expected d and c in the losers bracket, got %v", round[1].Players)
+	}
+	// a beats b, c knocks out d
+	round = play(t, tour, round, "c")
+	if len(round) != 2 {
+		t.Fatalf("Expected a bye for a and b against c, got %+v", round)
+	}
+	// c dropped into the losers bracket first, then b knocks them out
+	if round[1].Players != [2]string{"c", "b"} {
+		t.Errorf("Expected c and b in the losers bracket, got %v", round[1].Players)
+	}
+	round = play(t, tour, round, "b")
+	if len(round) != 1 || round[0].Bracket != BracketFinal || round[0].Players != [2]string{"a", "b"} {
+		t.Fatalf("Expected a final between a and b, got %+v", round)
+	}
+	// b wins, forcing a second final
+	round = play(t, tour, round, "b")
+	if len(round) != 1 || round[0].Bracket != BracketFinal {
+		t.Fatalf("Expected the final to be played again, got %+v", round)
+	}
+	round = play(t, tour, round, "b")
+	if round != nil || tour.Champion != "b" {
+		t.Errorf("Expected b to win the tournament, got %q", tour.Champion)
+	}
+}